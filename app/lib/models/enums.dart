@@ -167,6 +167,137 @@ enum ShoppingHistoryStatus {
   }
 }
 
+enum StorageKind {
+  fridge,
+  freezer,
+  pantry;
+
+  String toJson() => name.toUpperCase();
+
+  static StorageKind fromJson(String json) => values.byName(json.toLowerCase());
+}
+
+enum FridgeRole {
+  owner,
+  editor,
+  viewer;
+
+  String toJson() => name.toUpperCase();
+
+  static FridgeRole fromJson(String json) => values.byName(json.toLowerCase());
+}
+
+enum MealType {
+  breakfast,
+  lunch,
+  dinner,
+  snack;
+
+  String toJson() => name.toUpperCase();
+
+  static MealType fromJson(String json) => values.byName(json.toLowerCase());
+}
+
+enum DietTag {
+  gluten,
+  lactose,
+  eggs,
+  nuts,
+  peanuts,
+  soy,
+  sesame,
+  fish,
+  shellfish,
+  meat,
+  pork,
+  alcohol,
+  honey;
+
+  String toJson() => name.toUpperCase();
+
+  static DietTag fromJson(String json) => values.byName(json.toLowerCase());
+}
+
+enum BadgeCriterion {
+  recipesCooked,
+  postsShared,
+  likesReceived,
+  zeroWasteDays,
+  fridgesShared;
+
+  String toJson() {
+    switch (this) {
+      case BadgeCriterion.recipesCooked:
+        return 'RECIPES_COOKED';
+      case BadgeCriterion.postsShared:
+        return 'POSTS_SHARED';
+      case BadgeCriterion.likesReceived:
+        return 'LIKES_RECEIVED';
+      case BadgeCriterion.zeroWasteDays:
+        return 'ZERO_WASTE_DAYS';
+      case BadgeCriterion.fridgesShared:
+        return 'FRIDGES_SHARED';
+    }
+  }
+
+  static BadgeCriterion fromJson(String json) =>
+      values.firstWhere((e) => e.toJson() == json);
+}
+
+enum LeaderboardPeriod {
+  week,
+  month,
+  allTime;
+
+  String toJson() {
+    switch (this) {
+      case LeaderboardPeriod.week:
+        return 'WEEK';
+      case LeaderboardPeriod.month:
+        return 'MONTH';
+      case LeaderboardPeriod.allTime:
+        return 'ALL_TIME';
+    }
+  }
+
+  static LeaderboardPeriod fromJson(String json) =>
+      values.firstWhere((e) => e.toJson() == json);
+}
+
+enum LeaderboardScope {
+  global,
+  household,
+  friends;
+
+  String toJson() => name.toUpperCase();
+
+  static LeaderboardScope fromJson(String json) =>
+      values.byName(json.toLowerCase());
+}
+
+enum PointsReason {
+  openingBalance,
+  recipeCooked,
+  postShared,
+  postDeleted;
+
+  String toJson() {
+    switch (this) {
+      case PointsReason.openingBalance:
+        return 'OPENING_BALANCE';
+      case PointsReason.recipeCooked:
+        return 'RECIPE_COOKED';
+      case PointsReason.postShared:
+        return 'POST_SHARED';
+      case PointsReason.postDeleted:
+        return 'POST_DELETED';
+    }
+  }
+
+  static PointsReason fromJson(String json) =>
+      values.firstWhere((e) => e.toJson() == json);
+}
+
 enum Currency {
   usd,
   eur;
@@ -175,3 +306,23 @@ enum Currency {
 
   static Currency fromJson(String json) => values.byName(json.toLowerCase());
 }
+
+
+enum InventoryEventType {
+  added,
+  updated,
+  consumed,
+  wasted,
+  cooked,
+  imported,
+  locked,
+  unlocked,
+  deleted,
+  restored,
+  moved;
+
+  String toJson() => name.toUpperCase();
+
+  static InventoryEventType fromJson(String json) =>
+      values.byName(json.toLowerCase());
+}
@@ -3,34 +3,88 @@ import 'enums.dart';
 class Fridge {
   final String id;
   final String name;
+  final StorageKind kind;
   final List<String> ownerId;
   final List<InventoryItem> items;
+  final List<FridgeMember> members;
+  final FridgeRole? myRole;
 
   Fridge({
     required this.id,
     required this.name,
+    this.kind = StorageKind.fridge,
     required this.ownerId,
     required this.items,
+    this.members = const [],
+    this.myRole,
   });
 
+  bool get canEdit => myRole != FridgeRole.viewer;
+
   factory Fridge.fromJson(Map<String, dynamic> json) {
     return Fridge(
       id: json['id'] as String,
       name: json['name'] as String,
+      kind: json['kind'] != null
+          ? StorageKind.fromJson(json['kind'] as String)
+          : StorageKind.fridge,
       ownerId: (json['ownerId'] as List<dynamic>?)?.cast<String>() ?? [],
       items:
           (json['items'] as List<dynamic>?)
               ?.map((e) => InventoryItem.fromJson(e as Map<String, dynamic>))
               .toList() ??
           [],
+      members:
+          (json['members'] as List<dynamic>?)
+              ?.map((e) => FridgeMember.fromJson(e as Map<String, dynamic>))
+              .toList() ??
+          [],
+      myRole: json['myRole'] != null
+          ? FridgeRole.fromJson(json['myRole'] as String)
+          : null,
     );
   }
 
   Map<String, dynamic> toJson() => {
     'id': id,
     'name': name,
+    'kind': kind.toJson(),
     'ownerId': ownerId,
     'items': items.map((e) => e.toJson()).toList(),
+    'members': members.map((e) => e.toJson()).toList(),
+    if (myRole != null) 'myRole': myRole!.toJson(),
+  };
+}
+
+class FridgeMember {
+  final String userId;
+  final String? nickname;
+  final FridgeRole role;
+  final DateTime? joinedAt;
+
+  FridgeMember({
+    required this.userId,
+    this.nickname,
+    required this.role,
+    this.joinedAt,
+  });
+
+  factory FridgeMember.fromJson(Map<String, dynamic> json) {
+    return FridgeMember(
+      userId: json['userId'] as String,
+      nickname: json['nickname'] as String?,
+      role: FridgeRole.fromJson(json['role'] as String),
+      joinedAt: json['joinedAt'] != null
+          ? DateTime.parse(json['joinedAt'] as String)
+          : null,
+    );
+  }
+
+  Map<String, dynamic> toJson() => {
+    'userId': userId,
+    'nickname': nickname,
+    'role': role.toJson(),
+    'joinedAt': joinedAt?.toIso8601String(),
   };
 }
 
@@ -220,18 +274,195 @@ class ProductLock {
 class SharedFridgeLink {
   final String authorId;
   final String inviteCode;
+  final String? fridgeId;
+  final FridgeRole role;
+  final DateTime? expiresAt;
 
-  SharedFridgeLink({required this.authorId, required this.inviteCode});
+  SharedFridgeLink({
+    required this.authorId,
+    required this.inviteCode,
+    this.fridgeId,
+    this.role = FridgeRole.editor,
+    this.expiresAt,
+  });
 
   factory SharedFridgeLink.fromJson(Map<String, dynamic> json) {
     return SharedFridgeLink(
       authorId: json['authorId'] as String,
       inviteCode: json['inviteCode'] as String,
+      fridgeId: json['fridgeId'] as String?,
+      role: json['role'] != null
+          ? FridgeRole.fromJson(json['role'] as String)
+          : FridgeRole.editor,
+      expiresAt: json['expiresAt'] != null
+          ? DateTime.parse(json['expiresAt'] as String)
+          : null,
     );
   }
 
   Map<String, dynamic> toJson() => {
     'authorId': authorId,
     'inviteCode': inviteCode,
+    'fridgeId': fridgeId,
+    'role': role.toJson(),
+    'expiresAt': expiresAt?.toIso8601String(),
   };
 }
+
+class WasteEvent {
+  final String id;
+  final String fridgeId;
+  final String itemId;
+  final String itemName;
+  final String? category;
+  final double amount;
+  final Unit unit;
+  final double cost;
+  final String currency;
+  final String? reason;
+  final DateTime wastedAt;
+
+  WasteEvent({
+    required this.id,
+    required this.fridgeId,
+    required this.itemId,
+    required this.itemName,
+    this.category,
+    required this.amount,
+    required this.unit,
+    required this.cost,
+    required this.currency,
+    this.reason,
+    required this.wastedAt,
+  });
+
+  factory WasteEvent.fromJson(Map<String, dynamic> json) {
+    return WasteEvent(
+      id: json['id'] as String,
+      fridgeId: json['fridgeId'] as String,
+      itemId: json['itemId'] as String,
+      itemName: json['itemName'] as String,
+      category: json['category'] as String?,
+      amount: (json['amount'] as num).toDouble(),
+      unit: Unit.fromJson(json['unit'] as String),
+      cost: (json['cost'] as num).toDouble(),
+      currency: json['currency'] as String,
+      reason: json['reason'] as String?,
+      wastedAt: DateTime.parse(json['wastedAt'] as String),
+    );
+  }
+}
+
+class WasteReportBucket {
+  final String key;
+  final int events;
+  final double cost;
+
+  WasteReportBucket({
+    required this.key,
+    required this.events,
+    required this.cost,
+  });
+
+  factory WasteReportBucket.fromJson(Map<String, dynamic> json) {
+    return WasteReportBucket(
+      key: json['key'] as String,
+      events: json['events'] as int,
+      cost: (json['cost'] as num).toDouble(),
+    );
+  }
+}
+
+class WasteReport {
+  final DateTime from;
+  final DateTime to;
+  final String currency;
+  final double totalCost;
+  final int totalEvents;
+  final List<WasteReportBucket> byCategory;
+  final List<WasteReportBucket> byReason;
+  final List<WasteEvent> events;
+
+  WasteReport({
+    required this.from,
+    required this.to,
+    required this.currency,
+    required this.totalCost,
+    required this.totalEvents,
+    required this.byCategory,
+    required this.byReason,
+    required this.events,
+  });
+
+  factory WasteReport.fromJson(Map<String, dynamic> json) {
+    return WasteReport(
+      from: DateTime.parse(json['from'] as String),
+      to: DateTime.parse(json['to'] as String),
+      currency: json['currency'] as String,
+      totalCost: (json['totalCost'] as num).toDouble(),
+      totalEvents: json['totalEvents'] as int,
+      byCategory: (json['byCategory'] as List<dynamic>)
+          .map((e) => WasteReportBucket.fromJson(e as Map<String, dynamic>))
+          .toList(),
+      byReason: (json['byReason'] as List<dynamic>)
+          .map((e) => WasteReportBucket.fromJson(e as Map<String, dynamic>))
+          .toList(),
+      events: (json['events'] as List<dynamic>)
+          .map((e) => WasteEvent.fromJson(e as Map<String, dynamic>))
+          .toList(),
+    );
+  }
+}
+
+class InventoryEvent {
+  final String id;
+  final String fridgeId;
+  final String itemId;
+  final String itemName;
+  final InventoryEventType type;
+  final String actorId;
+  final String? actorNickname;
+  final double? amount;
+  final Unit? unit;
+  final double? quantityBefore;
+  final double? quantityAfter;
+  final String? recipeId;
+  final String? operationId;
+  final DateTime occurredAt;
+
+  InventoryEvent({
+    required this.id,
+    required this.fridgeId,
+    required this.itemId,
+    required this.itemName,
+    required this.type,
+    required this.actorId,
+    this.actorNickname,
+    this.amount,
+    this.unit,
+    this.quantityBefore,
+    this.quantityAfter,
+    this.recipeId,
+    this.operationId,
+    required this.occurredAt,
+  });
+
+  factory InventoryEvent.fromJson(Map<String, dynamic> json) {
+    return InventoryEvent(
+      id: json['id'] as String,
+      fridgeId: json['fridgeId'] as String,
+      itemId: json['itemId'] as String,
+      itemName: json['itemName'] as String,
+      type: InventoryEventType.fromJson(json['type'] as String),
+      actorId: json['actorId'] as String,
+      actorNickname: json['actorNickname'] as String?,
+      amount: (json['amount'] as num?)?.toDouble(),
+      unit: json['unit'] != null ? Unit.fromJson(json['unit'] as String) : null,
+      quantityBefore: (json['quantityBefore'] as num?)?.toDouble(),
+      quantityAfter: (json['quantityAfter'] as num?)?.toDouble(),
+      recipeId: json['recipeId'] as String?,
+      operationId: json['operationId'] as String?,
+      occurredAt: DateTime.parse(json['occurredAt'] as String),
+    );
+  }
+}
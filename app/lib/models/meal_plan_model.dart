@@ -0,0 +1,92 @@
+import 'enums.dart';
+
+class MealPlan {
+  final String id;
+  final String fridgeId;
+  final DateTime weekStart;
+  final List<MealSlot> slots;
+  final DateTime? updatedAt;
+
+  MealPlan({
+    required this.id,
+    required this.fridgeId,
+    required this.weekStart,
+    required this.slots,
+    this.updatedAt,
+  });
+
+  factory MealPlan.fromJson(Map<String, dynamic> json) {
+    return MealPlan(
+      id: json['id'] as String,
+      fridgeId: json['fridgeId'] as String,
+      weekStart: DateTime.parse(json['weekStart'] as String),
+      slots:
+          (json['slots'] as List<dynamic>?)
+              ?.map((e) => MealSlot.fromJson(e as Map<String, dynamic>))
+              .toList() ??
+          [],
+      updatedAt: json['updatedAt'] != null
+          ? DateTime.parse(json['updatedAt'] as String)
+          : null,
+    );
+  }
+
+  List<MealSlot> slotsOn(DateTime day) => slots
+      .where(
+        (s) =>
+            s.date.year == day.year &&
+            s.date.month == day.month &&
+            s.date.day == day.day,
+      )
+      .toList();
+}
+
+class MealSlot {
+  final String id;
+  final DateTime date;
+  final MealType mealType;
+  final String recipeId;
+  final String? recipeTitle;
+  final int portions;
+  final String scheduledBy;
+  final bool reserved;
+  final DateTime? cookedAt;
+  final List<String> warnings;
+
+  MealSlot({
+    required this.id,
+    required this.date,
+    required this.mealType,
+    required this.recipeId,
+    this.recipeTitle,
+    required this.portions,
+    required this.scheduledBy,
+    required this.reserved,
+    this.cookedAt,
+    required this.warnings,
+  });
+
+  factory MealSlot.fromJson(Map<String, dynamic> json) {
+    return MealSlot(
+      id: json['id'] as String,
+      date: DateTime.parse(json['date'] as String),
+      mealType: MealType.fromJson(json['mealType'] as String),
+      recipeId: json['recipeId'] as String,
+      recipeTitle:
+          (json['recipe'] as Map<String, dynamic>?)?['title'] as String?,
+      portions: json['portions'] as int,
+      scheduledBy: json['scheduledBy'] as String,
+      reserved: json['reserved'] as bool? ?? false,
+      cookedAt: json['cookedAt'] != null
+          ? DateTime.parse(json['cookedAt'] as String)
+          : null,
+      warnings:
+          (json['warnings'] as List<dynamic>?)
+              ?.map((e) => e as String)
+              .toList() ??
+          [],
+    );
+  }
+
+  bool get isCovered => warnings.isEmpty;
+}
@@ -3,4 +3,5 @@ export 'user_model.dart';
 export 'inventory_model.dart';
 export 'shopping_model.dart';
 export 'recipe_model.dart';
+export 'meal_plan_model.dart';
 export 'social_model.dart';
\ No newline at end of file
@@ -15,6 +15,11 @@ class Recipe {
   final int? ecoPointsReward;
   final int? ttlSecondsRemaining;
   final bool generatedByAI;
+  final RecipeAttribution? savedFrom;
+  final int? servings;
+  final int? preparationPortions;
+  final List<DietTag> dietTags;
+  final List<String> dietWarnings;
 
   Recipe({
     required this.id,
@@ -30,6 +35,11 @@ class Recipe {
     this.ecoPointsReward,
     this.ttlSecondsRemaining,
     required this.generatedByAI,
+    this.savedFrom,
+    this.servings,
+    this.preparationPortions,
+    this.dietTags = const [],
+    this.dietWarnings = const [],
   });
 
   factory Recipe.fromJson(Map<String, dynamic> json) {
@@ -51,6 +61,20 @@ class Recipe {
       ecoPointsReward: json['ecoPointsReward'] as int?,
       ttlSecondsRemaining: json['ttlSecondsRemaining'] as int?,
       generatedByAI: json['generatedByAI'] as bool? ?? false,
+      savedFrom: json['savedFrom'] == null
+          ? null
+          : RecipeAttribution.fromJson(
+              json['savedFrom'] as Map<String, dynamic>,
+            ),
+      servings: json['servings'] as int?,
+      preparationPortions: json['preparationPortions'] as int?,
+      dietTags:
+          (json['dietTags'] as List<dynamic>?)
+              ?.map((e) => DietTag.fromJson(e as String))
+              .toList() ??
+          [],
+      dietWarnings:
+          (json['dietWarnings'] as List<dynamic>?)?.cast<String>() ?? [],
     );
   }
 
@@ -68,6 +92,37 @@ class Recipe {
     'ecoPointsReward': ecoPointsReward,
     'ttlSecondsRemaining': ttlSecondsRemaining,
     'generatedByAI': generatedByAI,
+    'savedFrom': savedFrom?.toJson(),
+    'servings': servings,
+    'preparationPortions': preparationPortions,
+    'dietTags': dietTags.map((e) => e.toJson()).toList(),
+    'dietWarnings': dietWarnings,
+  };
+}
+
+class RecipeAttribution {
+  final String postId;
+  final String authorId;
+  final String authorNickname;
+
+  RecipeAttribution({
+    required this.postId,
+    required this.authorId,
+    required this.authorNickname,
+  });
+
+  factory RecipeAttribution.fromJson(Map<String, dynamic> json) {
+    return RecipeAttribution(
+      postId: json['postId'] as String,
+      authorId: json['authorId'] as String,
+      authorNickname: json['authorNickname'] as String,
+    );
+  }
+
+  Map<String, dynamic> toJson() => {
+    'postId': postId,
+    'authorId': authorId,
+    'authorNickname': authorNickname,
   };
 }
 
@@ -172,6 +227,111 @@ class RecipeIngredientInput {
   };
 }
 
+class IngredientMatchCandidate {
+  final String itemId;
+  final String itemName;
+  final double score;
+  final bool sufficient;
+
+  IngredientMatchCandidate({
+    required this.itemId,
+    required this.itemName,
+    required this.score,
+    required this.sufficient,
+  });
+
+  factory IngredientMatchCandidate.fromJson(Map<String, dynamic> json) {
+    final item = json['item'] as Map<String, dynamic>;
+    return IngredientMatchCandidate(
+      itemId: item['id'] as String,
+      itemName: item['name'] as String,
+      score: (json['score'] as num).toDouble(),
+      sufficient: json['sufficient'] as bool,
+    );
+  }
+}
+
+class IngredientMatchSuggestion {
+  final int ingredientIndex;
+  final String ingredientName;
+  final String? currentInventoryItemId;
+  final List<IngredientMatchCandidate> candidates;
+
+  IngredientMatchSuggestion({
+    required this.ingredientIndex,
+    required this.ingredientName,
+    this.currentInventoryItemId,
+    required this.candidates,
+  });
+
+  factory IngredientMatchSuggestion.fromJson(Map<String, dynamic> json) {
+    return IngredientMatchSuggestion(
+      ingredientIndex: json['ingredientIndex'] as int,
+      ingredientName: json['ingredientName'] as String,
+      currentInventoryItemId: json['currentInventoryItemId'] as String?,
+      candidates: (json['candidates'] as List<dynamic>)
+          .map(
+            (e) => IngredientMatchCandidate.fromJson(e as Map<String, dynamic>),
+          )
+          .toList(),
+    );
+  }
+}
+
+class MissingIngredient {
+  final String name;
+  final double quantity;
+  final Unit unit;
+
+  MissingIngredient({
+    required this.name,
+    required this.quantity,
+    required this.unit,
+  });
+
+  factory MissingIngredient.fromJson(Map<String, dynamic> json) {
+    return MissingIngredient(
+      name: json['name'] as String,
+      quantity: (json['quantity'] as num).toDouble(),
+      unit: Unit.fromJson(json['unit'] as String),
+    );
+  }
+}
+
+class CookableRecipe {
+  final String title;
+  final String? recipeId;
+  final String? postId;
+  final double score;
+  final double coverage;
+  final int expiringItemsUsed;
+  final List<MissingIngredient> missingIngredients;
+
+  CookableRecipe({
+    required this.title,
+    this.recipeId,
+    this.postId,
+    required this.score,
+    required this.coverage,
+    required this.expiringItemsUsed,
+    required this.missingIngredients,
+  });
+
+  factory CookableRecipe.fromJson(Map<String, dynamic> json) {
+    return CookableRecipe(
+      title: json['title'] as String,
+      recipeId: json['recipe']?['id'] as String?,
+      postId: json['post']?['id'] as String?,
+      score: (json['score'] as num).toDouble(),
+      coverage: (json['coverage'] as num).toDouble(),
+      expiringItemsUsed: json['expiringItemsUsed'] as int,
+      missingIngredients: (json['missingIngredients'] as List<dynamic>)
+          .map((e) => MissingIngredient.fromJson(e as Map<String, dynamic>))
+          .toList(),
+    );
+  }
+}
+
 class CreateRecipeInput {
   final String title;
   final String? description;
@@ -180,6 +340,7 @@ class CreateRecipeInput {
   final int? prepTimeMinutes;
   final int? calories;
   final int? ecoPointsReward;
+  final int? servings;
 
   CreateRecipeInput({
     required this.title,
@@ -189,6 +350,7 @@ class CreateRecipeInput {
     this.prepTimeMinutes,
     this.calories,
     this.ecoPointsReward,
+    this.servings,
   });
 
   Map<String, dynamic> toJson() => {
@@ -199,6 +361,7 @@ class CreateRecipeInput {
     'prepTimeMinutes': prepTimeMinutes,
     'calories': calories,
     'ecoPointsReward': ecoPointsReward,
+    'servings': servings,
   };
 }
 
@@ -210,6 +373,7 @@ class UpdateRecipeInput {
   final List<String>? steps;
   final int? prepTimeMinutes;
   final int? calories;
+  final int? servings;
 
   UpdateRecipeInput({
     this.title,
@@ -219,6 +383,7 @@ class UpdateRecipeInput {
     this.steps,
     this.prepTimeMinutes,
     this.calories,
+    this.servings,
   });
 
   Map<String, dynamic> toJson() => {
@@ -230,5 +395,6 @@ class UpdateRecipeInput {
     if (steps != null) 'steps': steps,
     if (prepTimeMinutes != null) 'prepTimeMinutes': prepTimeMinutes,
     if (calories != null) 'calories': calories,
+    if (servings != null) 'servings': servings,
   };
 }
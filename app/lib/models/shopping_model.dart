@@ -1,4 +1,5 @@
 import 'enums.dart';
+import 'recipe_model.dart';
 
 class ShoppingHistoryEntry {
   final String id;
@@ -117,3 +118,109 @@ class HistoryItem {
     'confidence': confidence,
   };
 }
+
+class ShoppingList {
+  final String id;
+  final String fridgeId;
+  final List<ShoppingListItem> items;
+  final DateTime? updatedAt;
+
+  ShoppingList({
+    required this.id,
+    required this.fridgeId,
+    required this.items,
+    this.updatedAt,
+  });
+
+  factory ShoppingList.fromJson(Map<String, dynamic> json) {
+    return ShoppingList(
+      id: json['id'] as String,
+      fridgeId: json['fridgeId'] as String,
+      items:
+          (json['items'] as List<dynamic>?)
+              ?.map((e) => ShoppingListItem.fromJson(e as Map<String, dynamic>))
+              .toList() ??
+          [],
+      updatedAt: json['updatedAt'] != null
+          ? DateTime.parse(json['updatedAt'] as String)
+          : null,
+    );
+  }
+}
+
+class ShoppingListItem {
+  final String id;
+  final String name;
+  final double? quantity;
+  final Unit? unit;
+  final String? category;
+  final bool checked;
+  final String addedBy;
+  final DateTime addedAt;
+
+  ShoppingListItem({
+    required this.id,
+    required this.name,
+    this.quantity,
+    this.unit,
+    this.category,
+    required this.checked,
+    required this.addedBy,
+    required this.addedAt,
+  });
+
+  factory ShoppingListItem.fromJson(Map<String, dynamic> json) {
+    return ShoppingListItem(
+      id: json['id'] as String,
+      name: json['name'] as String,
+      quantity: (json['quantity'] as num?)?.toDouble(),
+      unit: json['unit'] != null ? Unit.fromJson(json['unit'] as String) : null,
+      category: json['category'] as String?,
+      checked: json['checked'] as bool? ?? false,
+      addedBy: json['addedBy'] as String,
+      addedAt: DateTime.parse(json['addedAt'] as String),
+    );
+  }
+}
+
+class GeneratedShoppingList {
+  final List<ShoppingListGroup> groups;
+  final ShoppingHistoryEntry? entry;
+
+  GeneratedShoppingList({required this.groups, this.entry});
+
+  factory GeneratedShoppingList.fromJson(Map<String, dynamic> json) {
+    return GeneratedShoppingList(
+      groups:
+          (json['groups'] as List<dynamic>?)
+              ?.map(
+                (e) => ShoppingListGroup.fromJson(e as Map<String, dynamic>),
+              )
+              .toList() ??
+          [],
+      entry: json['entry'] != null
+          ? ShoppingHistoryEntry.fromJson(json['entry'] as Map<String, dynamic>)
+          : null,
+    );
+  }
+}
+
+class ShoppingListGroup {
+  final String? category;
+  final List<MissingIngredient> items;
+
+  ShoppingListGroup({this.category, required this.items});
+
+  factory ShoppingListGroup.fromJson(Map<String, dynamic> json) {
+    return ShoppingListGroup(
+      category: json['category'] as String?,
+      items:
+          (json['items'] as List<dynamic>?)
+              ?.map(
+                (e) => MissingIngredient.fromJson(e as Map<String, dynamic>),
+              )
+              .toList() ??
+          [],
+    );
+  }
+}
@@ -8,6 +8,7 @@ class RecipeSnapshot {
   final int? prepTimeMinutes;
   final int? calories;
   final int? ecoPointsReward;
+  final List<DietTag> dietTags;
 
   RecipeSnapshot({
     required this.title,
@@ -17,6 +18,7 @@ class RecipeSnapshot {
     this.prepTimeMinutes,
     this.calories,
     this.ecoPointsReward,
+    this.dietTags = const [],
   });
 
   factory RecipeSnapshot.fromJson(Map<String, dynamic> json) {
@@ -38,6 +40,11 @@ class RecipeSnapshot {
       prepTimeMinutes: json['prepTimeMinutes'] as int?,
       calories: json['calories'] as int?,
       ecoPointsReward: json['ecoPointsReward'] as int?,
+      dietTags:
+          (json['dietTags'] as List<dynamic>?)
+              ?.map((e) => DietTag.fromJson(e as String))
+              .toList() ??
+          [],
     );
   }
 
@@ -49,6 +56,7 @@ class RecipeSnapshot {
     'prepTimeMinutes': prepTimeMinutes,
     'calories': calories,
     'ecoPointsReward': ecoPointsReward,
+    'dietTags': dietTags.map((e) => e.toJson()).toList(),
   };
 }
 
@@ -213,6 +221,63 @@ class LeaderboardEntry {
   };
 }
 
+class LeaderboardPosition {
+  final int? rank;
+  final int score;
+  final List<LeaderboardEntry> above;
+  final List<LeaderboardEntry> below;
+
+  LeaderboardPosition({
+    this.rank,
+    required this.score,
+    required this.above,
+    required this.below,
+  });
+
+  factory LeaderboardPosition.fromJson(Map<String, dynamic> json) {
+    return LeaderboardPosition(
+      rank: json['rank'] as int?,
+      score: json['score'] as int,
+      above: (json['above'] as List<dynamic>? ?? [])
+          .map((e) => LeaderboardEntry.fromJson(e as Map<String, dynamic>))
+          .toList(),
+      below: (json['below'] as List<dynamic>? ?? [])
+          .map((e) => LeaderboardEntry.fromJson(e as Map<String, dynamic>))
+          .toList(),
+    );
+  }
+}
+
+class LeaderboardArchive {
+  final LeaderboardPeriod period;
+  final String key;
+  final DateTime closedAt;
+  final List<LeaderboardEntry> standings;
+  final List<LeaderboardEntry> winners;
+
+  LeaderboardArchive({
+    required this.period,
+    required this.key,
+    required this.closedAt,
+    required this.standings,
+    required this.winners,
+  });
+
+  factory LeaderboardArchive.fromJson(Map<String, dynamic> json) {
+    return LeaderboardArchive(
+      period: LeaderboardPeriod.fromJson(json['period'] as String),
+      key: json['key'] as String,
+      closedAt: DateTime.parse(json['closedAt'] as String),
+      standings: (json['standings'] as List<dynamic>? ?? [])
+          .map((e) => LeaderboardEntry.fromJson(e as Map<String, dynamic>))
+          .toList(),
+      winners: (json['winners'] as List<dynamic>? ?? [])
+          .map((e) => LeaderboardEntry.fromJson(e as Map<String, dynamic>))
+          .toList(),
+    );
+  }
+}
+
 class CreatePostInput {
   final String recipeId;
   final String? caption;
@@ -8,6 +8,7 @@ class User {
   final AccountOrigin origin;
   final GamificationProfile gamification;
   final UserPreferences? preferences;
+  final List<String> friendIds;
 
   User({
     required this.id,
@@ -17,6 +18,7 @@ class User {
     required this.origin,
     required this.gamification,
     this.preferences,
+    this.friendIds = const [],
   });
 
   factory User.fromJson(Map<String, dynamic> json) {
@@ -30,6 +32,7 @@ class User {
       preferences: json['preferences'] != null
           ? UserPreferences.fromJson(json['preferences'] as Map<String, dynamic>)
           : null,
+      friendIds: (json['friendIds'] as List<dynamic>?)?.cast<String>() ?? [],
     );
   }
 
@@ -41,6 +44,7 @@ class User {
         'origin': origin.toJson(),
         'gamification': gamification.toJson(),
         'preferences': preferences?.toJson(),
+        'friendIds': friendIds,
       };
 }
 
@@ -49,6 +53,8 @@ class GamificationProfile {
   final int currentLevel; 
   final int nextLevelThreshold;
   final List<String> badges;
+  final List<EarnedBadge> earnedBadges;
+  final DateTime? badgesTrackedSince;
   final double? wastedMoneyYTD;
 
   GamificationProfile({
@@ -56,6 +62,8 @@ class GamificationProfile {
     required this.currentLevel,
     required this.nextLevelThreshold,
     required this.badges,
+    this.earnedBadges = const [],
+    this.badgesTrackedSince,
     this.wastedMoneyYTD,
   });
 
@@ -67,6 +75,13 @@ class GamificationProfile {
           : int.parse(json['currentLevel'].toString()),
       nextLevelThreshold: json['nextLevelThreshold'] as int,
       badges: (json['badges'] as List<dynamic>?)?.cast<String>() ?? [],
+      earnedBadges: (json['earnedBadges'] as List<dynamic>?)
+              ?.map((e) => EarnedBadge.fromJson(e as Map<String, dynamic>))
+              .toList() ??
+          [],
+      badgesTrackedSince: json['badgesTrackedSince'] != null
+          ? DateTime.parse(json['badgesTrackedSince'] as String)
+          : null,
       wastedMoneyYTD: (json['wastedMoneyYTD'] as num?)?.toDouble(),
     );
   }
@@ -76,19 +91,107 @@ class GamificationProfile {
         'currentLevel': currentLevel,
         'nextLevelThreshold': nextLevelThreshold,
         'badges': badges,
+        'earnedBadges': earnedBadges.map((e) => e.toJson()).toList(),
+        'badgesTrackedSince': badgesTrackedSince?.toIso8601String(),
         'wastedMoneyYTD': wastedMoneyYTD,
       };
 }
 
+class Badge {
+  final String id;
+  final String title;
+  final String description;
+  final BadgeCriterion criterion;
+  final int threshold;
+
+  Badge({
+    required this.id,
+    required this.title,
+    required this.description,
+    required this.criterion,
+    required this.threshold,
+  });
+
+  factory Badge.fromJson(Map<String, dynamic> json) {
+    return Badge(
+      id: json['id'] as String,
+      title: json['title'] as String,
+      description: json['description'] as String,
+      criterion: BadgeCriterion.fromJson(json['criterion'] as String),
+      threshold: json['threshold'] as int,
+    );
+  }
+
+  Map<String, dynamic> toJson() => {
+        'id': id,
+        'title': title,
+        'description': description,
+        'criterion': criterion.toJson(),
+        'threshold': threshold,
+      };
+}
+
+class EarnedBadge {
+  final String badgeId;
+  final DateTime earnedAt;
+  final Badge? badge;
+
+  EarnedBadge({required this.badgeId, required this.earnedAt, this.badge});
+
+  factory EarnedBadge.fromJson(Map<String, dynamic> json) {
+    return EarnedBadge(
+      badgeId: json['badgeId'] as String,
+      earnedAt: DateTime.parse(json['earnedAt'] as String),
+      badge: json['badge'] != null
+          ? Badge.fromJson(json['badge'] as Map<String, dynamic>)
+          : null,
+    );
+  }
+
+  Map<String, dynamic> toJson() => {
+        'badgeId': badgeId,
+        'earnedAt': earnedAt.toIso8601String(),
+        'badge': badge?.toJson(),
+      };
+}
+
+class PointsEntry {
+  final String id;
+  final int points;
+  final PointsReason reason;
+  final String? sourceId;
+  final DateTime createdAt;
+
+  PointsEntry({
+    required this.id,
+    required this.points,
+    required this.reason,
+    this.sourceId,
+    required this.createdAt,
+  });
+
+  factory PointsEntry.fromJson(Map<String, dynamic> json) {
+    return PointsEntry(
+      id: json['id'] as String,
+      points: json['points'] as int,
+      reason: PointsReason.fromJson(json['reason'] as String),
+      sourceId: json['sourceId'] as String?,
+      createdAt: DateTime.parse(json['createdAt'] as String),
+    );
+  }
+}
+
 class UserPreferences {
   final List<String>? dietaryRestrictions;
   final int? defaultPortions;
   final Currency currency;
+  final String? activeFridgeId;
 
   UserPreferences({
     this.dietaryRestrictions,
     this.defaultPortions,
     required this.currency,
+    this.activeFridgeId,
   });
 
   factory UserPreferences.fromJson(Map<String, dynamic> json) {
@@ -98,6 +201,7 @@ class UserPreferences {
           [],
       defaultPortions: json['defaultPortions'] as int?,
       currency: Currency.fromJson(json['currency'] as String),
+      activeFridgeId: json['activeFridgeId'] as String?,
     );
   }
 
@@ -105,6 +209,7 @@ class UserPreferences {
         'dietaryRestrictions': dietaryRestrictions,
         'defaultPortions': defaultPortions,
         'currency': currency.toJson(),
+        'activeFridgeId': activeFridgeId,
       };
 }
 
@@ -112,11 +217,13 @@ class UserPreferencesInput {
   final List<String>? dietaryRestrictions;
   final int? defaultPortions;
   final Currency? currency;
+  final String? activeFridgeId;
 
   UserPreferencesInput({
     this.dietaryRestrictions,
     this.defaultPortions,
     this.currency,
+    this.activeFridgeId,
   });
 
   Map<String, dynamic> toJson() => {
@@ -124,5 +231,6 @@ class UserPreferencesInput {
           'dietaryRestrictions': dietaryRestrictions,
         if (defaultPortions != null) 'defaultPortions': defaultPortions,
         if (currency != null) 'currency': currency!.toJson(),
+        if (activeFridgeId != null) 'activeFridgeId': activeFridgeId,
       };
 }
\ No newline at end of file
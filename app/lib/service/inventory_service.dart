@@ -1,5 +1,6 @@
 import 'package:flutter_riverpod/flutter_riverpod.dart';
 import 'package:graphql_flutter/graphql_flutter.dart';
+import '../models/enums.dart';
 import '../models/inventory_model.dart';
 
 class FridgeRefreshNotifier extends Notifier<int> {
@@ -13,6 +14,18 @@ final fridgeRefreshProvider = NotifierProvider<FridgeRefreshNotifier, int>(
   FridgeRefreshNotifier.new,
 );
 
+class InventoryEventPage {
+  final List<InventoryEvent> events;
+  final String? endCursor;
+  final bool hasNextPage;
+
+  const InventoryEventPage({
+    required this.events,
+    required this.endCursor,
+    required this.hasNextPage,
+  });
+}
+
 class InventoryService {
   final GraphQLClient client;
 
@@ -24,7 +37,15 @@ class InventoryService {
       myFridge {
         id
         name
+        kind
         ownerId
+        myRole
+        members {
+          userId
+          nickname
+          role
+          joinedAt
+        }
         items {
           id
           name
@@ -81,10 +102,97 @@ class InventoryService {
     throw Exception('Unexpected myFridge payload: ${raw.runtimeType}');
   }
 
-  Future<InventoryItem> addInventoryItem(AddInventoryItemInput input) async {
+  Future<Fridge> createFridge(
+    String name, {
+    StorageKind kind = StorageKind.fridge,
+  }) async {
+    const String mutation = r'''
+      mutation CreateFridge($name: String!, $kind: StorageKind) {
+        createFridge(name: $name, kind: $kind) {
+          id
+          name
+          kind
+          ownerId
+        }
+      }
+    ''';
+
+    final MutationOptions options = MutationOptions(
+      document: gql(mutation),
+      variables: {'name': name, 'kind': kind.toJson()},
+    );
+
+    final QueryResult result = await client.mutate(options);
+
+    if (result.hasException) {
+      throw Exception(result.exception.toString());
+    }
+
+    if (result.data == null || result.data!['createFridge'] == null) {
+      throw Exception('Failed to create fridge');
+    }
+
+    return Fridge.fromJson(result.data!['createFridge']);
+  }
+
+  Future<Fridge> renameFridge(String id, String name) async {
     const String mutation = r'''
-      mutation AddInventoryItem($input: AddInventoryItemInput!) {
-        addInventoryItem(input: $input) {
+      mutation RenameFridge($id: ID!, $name: String!) {
+        renameFridge(id: $id, name: $name) {
+          id
+          name
+          kind
+          ownerId
+        }
+      }
+    ''';
+
+    final MutationOptions options = MutationOptions(
+      document: gql(mutation),
+      variables: {'id': id, 'name': name},
+    );
+
+    final QueryResult result = await client.mutate(options);
+
+    if (result.hasException) {
+      throw Exception(result.exception.toString());
+    }
+
+    if (result.data == null || result.data!['renameFridge'] == null) {
+      throw Exception('Failed to rename fridge');
+    }
+
+    return Fridge.fromJson(result.data!['renameFridge']);
+  }
+
+  Future<bool> deleteFridge(String id) async {
+    const String mutation = r'''
+      mutation DeleteFridge($id: ID!) {
+        deleteFridge(id: $id)
+      }
+    ''';
+
+    final MutationOptions options = MutationOptions(
+      document: gql(mutation),
+      variables: {'id': id},
+    );
+
+    final QueryResult result = await client.mutate(options);
+
+    if (result.hasException) {
+      throw Exception(result.exception.toString());
+    }
+
+    return result.data?['deleteFridge'] as bool? ?? false;
+  }
+
+  Future<InventoryItem> addInventoryItem(
+    AddInventoryItemInput input, {
+    String? fridgeId,
+  }) async {
+    const String mutation = r'''
+      mutation AddInventoryItem($input: AddInventoryItemInput!, $fridgeId: ID) {
+        addInventoryItem(input: $input, fridgeId: $fridgeId) {
           id
           name
           brand
@@ -110,7 +218,7 @@ class InventoryService {
 
     final MutationOptions options = MutationOptions(
       document: gql(mutation),
-      variables: {'input': input.toJson()},
+      variables: {'input': input.toJson(), 'fridgeId': fridgeId},
     );
 
     final QueryResult result = await client.mutate(options);
@@ -128,11 +236,12 @@ class InventoryService {
 
   Future<InventoryItem> updateInventoryItem(
     String id,
-    UpdateInventoryItemInput input,
-  ) async {
+    UpdateInventoryItemInput input, {
+    String? fridgeId,
+  }) async {
     const String mutation = r'''
-      mutation UpdateInventoryItem($id: ID!, $input: UpdateInventoryItemInput!) {
-        updateInventoryItem(id: $id, input: $input) {
+      mutation UpdateInventoryItem($id: ID!, $input: UpdateInventoryItemInput!, $fridgeId: ID) {
+        updateInventoryItem(id: $id, input: $input, fridgeId: $fridgeId) {
           id
           name
           brand
@@ -158,7 +267,7 @@ class InventoryService {
 
     final MutationOptions options = MutationOptions(
       document: gql(mutation),
-      variables: {'id': id, 'input': input.toJson()},
+      variables: {'id': id, 'input': input.toJson(), 'fridgeId': fridgeId},
     );
 
     final QueryResult result = await client.mutate(options);
@@ -174,16 +283,20 @@ class InventoryService {
     return InventoryItem.fromJson(result.data!['updateInventoryItem']);
   }
 
-  Future<bool> deleteInventoryItem(String id) async {
+  Future<bool> deleteInventoryItem(
+    String id, {
+    String? operationId,
+    String? fridgeId,
+  }) async {
     const String mutation = r'''
-      mutation DeleteInventoryItem($id: ID!) {
-        deleteInventoryItem(id: $id)
+      mutation DeleteInventoryItem($id: ID!, $operationId: ID, $fridgeId: ID) {
+        deleteInventoryItem(id: $id, operationId: $operationId, fridgeId: $fridgeId)
       }
     ''';
 
     final MutationOptions options = MutationOptions(
       document: gql(mutation),
-      variables: {'id': id},
+      variables: {'id': id, 'operationId': operationId, 'fridgeId': fridgeId},
     );
 
     final QueryResult result = await client.mutate(options);
@@ -195,10 +308,15 @@ class InventoryService {
     return result.data?['deleteInventoryItem'] as bool? ?? false;
   }
 
-  Future<InventoryItem> consumeInventoryItem(String id, double amount) async {
+  Future<InventoryItem> consumeInventoryItem(
+    String id,
+    double amount, {
+    String? operationId,
+    String? fridgeId,
+  }) async {
     const String mutation = r'''
-      mutation ConsumeInventoryItem($id: ID!, $amount: Float!) {
-        consumeInventoryItem(id: $id, amount: $amount) {
+      mutation ConsumeInventoryItem($id: ID!, $amount: Float!, $operationId: ID, $fridgeId: ID) {
+        consumeInventoryItem(id: $id, amount: $amount, operationId: $operationId, fridgeId: $fridgeId) {
           id
           quantity {
             value
@@ -211,7 +329,12 @@ class InventoryService {
 
     final MutationOptions options = MutationOptions(
       document: gql(mutation),
-      variables: {'id': id, 'amount': amount},
+      variables: {
+        'id': id,
+        'amount': amount,
+        'operationId': operationId,
+        'fridgeId': fridgeId,
+      },
     );
 
     final QueryResult result = await client.mutate(options);
@@ -231,11 +354,13 @@ class InventoryService {
   Future<InventoryItem> wasteInventoryItem(
     String id,
     double amount,
-    String? reason,
-  ) async {
+    String? reason, {
+    String? operationId,
+    String? fridgeId,
+  }) async {
     const String mutation = r'''
-      mutation WasteInventoryItem($id: ID!, $amount: Float!, $reason: String) {
-        wasteInventoryItem(id: $id, amount: $amount, reason: $reason) {
+      mutation WasteInventoryItem($id: ID!, $amount: Float!, $reason: String, $operationId: ID, $fridgeId: ID) {
+        wasteInventoryItem(id: $id, amount: $amount, reason: $reason, operationId: $operationId, fridgeId: $fridgeId) {
           id
           quantity {
             value
@@ -248,7 +373,13 @@ class InventoryService {
 
     final MutationOptions options = MutationOptions(
       document: gql(mutation),
-      variables: {'id': id, 'amount': amount, 'reason': reason},
+      variables: {
+        'id': id,
+        'amount': amount,
+        'reason': reason,
+        'operationId': operationId,
+        'fridgeId': fridgeId,
+      },
     );
 
     final QueryResult result = await client.mutate(options);
@@ -264,6 +395,233 @@ class InventoryService {
     return InventoryItem.fromJson(result.data!['wasteInventoryItem']);
   }
 
+  Future<InventoryItem> moveInventoryItem(
+    String id,
+    String toFridgeId, {
+    String? fromFridgeId,
+  }) async {
+    const String mutation = r'''
+      mutation MoveInventoryItem($id: ID!, $toFridgeId: ID!, $fromFridgeId: ID) {
+        moveInventoryItem(id: $id, toFridgeId: $toFridgeId, fromFridgeId: $fromFridgeId) {
+          id
+          name
+          brand
+          category
+          quantity {
+            value
+            unit
+          }
+          price
+          status
+          virtualAvailable
+          expiryDate
+          expiryType
+          addedAt
+          activeLocks {
+            recipeId
+            amount
+            startedAt
+          }
+        }
+      }
+    ''';
+
+    final MutationOptions options = MutationOptions(
+      document: gql(mutation),
+      variables: {
+        'id': id,
+        'toFridgeId': toFridgeId,
+        'fromFridgeId': fromFridgeId,
+      },
+    );
+
+    final QueryResult result = await client.mutate(options);
+
+    if (result.hasException) {
+      throw Exception(result.exception.toString());
+    }
+
+    if (result.data == null || result.data!['moveInventoryItem'] == null) {
+      throw Exception('Failed to move inventory item');
+    }
+
+    return InventoryItem.fromJson(result.data!['moveInventoryItem']);
+  }
+
+  Future<List<InventoryItem>> undoInventoryOperation(String operationId) async {
+    const String mutation = r'''
+      mutation UndoInventoryOperation($operationId: ID!) {
+        undoInventoryOperation(operationId: $operationId) {
+          id
+          name
+          brand
+          category
+          quantity {
+            value
+            unit
+          }
+          price
+          status
+          virtualAvailable
+          expiryDate
+          expiryType
+          addedAt
+          activeLocks {
+            recipeId
+            amount
+            startedAt
+          }
+        }
+      }
+    ''';
+
+    final MutationOptions options = MutationOptions(
+      document: gql(mutation),
+      variables: {'operationId': operationId},
+    );
+
+    final QueryResult result = await client.mutate(options);
+
+    if (result.hasException) {
+      throw Exception(result.exception.toString());
+    }
+
+    final List<dynamic> items =
+        result.data?['undoInventoryOperation'] as List<dynamic>? ?? [];
+    return items
+        .map((e) => InventoryItem.fromJson(e as Map<String, dynamic>))
+        .toList();
+  }
+
+  Future<WasteReport> getWasteReport(DateTime from, DateTime to) async {
+    const String query = r'''
+      query WasteReport($from: DateTime!, $to: DateTime!) {
+        wasteReport(from: $from, to: $to) {
+          from
+          to
+          currency
+          totalCost
+          totalEvents
+          byCategory { key events cost }
+          byReason { key events cost }
+          events {
+            id
+            fridgeId
+            itemId
+            itemName
+            category
+            amount
+            unit
+            cost
+            currency
+            reason
+            wastedAt
+          }
+        }
+      }
+    ''';
+
+    final QueryOptions options = QueryOptions(
+      document: gql(query),
+      variables: {
+        'from': from.toUtc().toIso8601String(),
+        'to': to.toUtc().toIso8601String(),
+      },
+      fetchPolicy: FetchPolicy.networkOnly,
+    );
+
+    final QueryResult result = await client.query(options);
+
+    if (result.hasException) {
+      throw Exception(result.exception.toString());
+    }
+
+    if (result.data == null || result.data!['wasteReport'] == null) {
+      throw Exception('Failed to load waste report');
+    }
+
+    return WasteReport.fromJson(result.data!['wasteReport']);
+  }
+
+  static const String _inventoryEventFields = '''
+    edges {
+      node {
+        id
+        fridgeId
+        itemId
+        itemName
+        type
+        actorId
+        actorNickname
+        amount
+        unit
+        quantityBefore
+        quantityAfter
+        recipeId
+        operationId
+        occurredAt
+      }
+    }
+    pageInfo {
+      hasNextPage
+      endCursor
+    }
+  ''';
+
+  Future<InventoryEventPage> getInventoryEvents({
+    String? itemId,
+    String? fridgeId,
+    int first = 20,
+    String? after,
+  }) async {
+    final String query = itemId == null
+        ? '''
+      query InventoryEvents(\$fridgeId: ID, \$first: Int, \$after: String) {
+        inventoryEvents(fridgeId: \$fridgeId, first: \$first, after: \$after) {
+          $_inventoryEventFields
+        }
+      }
+    '''
+        : '''
+      query InventoryItemEvents(\$itemId: ID!, \$first: Int, \$after: String) {
+        inventoryItemEvents(itemId: \$itemId, first: \$first, after: \$after) {
+          $_inventoryEventFields
+        }
+      }
+    ''';
+
+    final QueryOptions options = QueryOptions(
+      document: gql(query),
+      variables: {
+        'first': first,
+        'after': after,
+        if (itemId != null) 'itemId': itemId,
+        if (itemId == null) 'fridgeId': fridgeId,
+      },
+      fetchPolicy: FetchPolicy.networkOnly,
+    );
+
+    final QueryResult result = await client.query(options);
+
+    if (result.hasException) {
+      throw Exception(result.exception.toString());
+    }
+
+    final field = itemId == null ? 'inventoryEvents' : 'inventoryItemEvents';
+    final conn = result.data?[field] as Map<String, dynamic>?;
+    final List<dynamic> edges = conn?['edges'] as List<dynamic>? ?? [];
+    final pageInfo = conn?['pageInfo'] as Map<String, dynamic>?;
+    return InventoryEventPage(
+      events: edges
+          .map(
+            (e) => InventoryEvent.fromJson(e['node'] as Map<String, dynamic>),
+          )
+          .toList(),
+      endCursor: pageInfo?['endCursor'] as String?,
+      hasNextPage: pageInfo?['hasNextPage'] as bool? ?? false,
+    );
+  }
+
   Future<InventoryItem> getInventoryItem(String itemId) async {
     final fridges = await getMyFridges();
     for (final fridge in fridges) {
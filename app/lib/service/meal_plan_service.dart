@@ -0,0 +1,128 @@
+import 'package:flutter_riverpod/flutter_riverpod.dart';
+import 'package:graphql_flutter/graphql_flutter.dart';
+import 'package:mocc/service/graphql_config.dart';
+import '../models/enums.dart';
+import '../models/meal_plan_model.dart';
+
+final mealPlanServiceProvider = Provider<MealPlanService>((ref) {
+  final client = ref.watch(graphQLClientProvider);
+  return MealPlanService(client);
+});
+
+class MealPlanService {
+  final GraphQLClient client;
+
+  MealPlanService(this.client);
+
+  static const String mealPlanFields = r'''
+        id
+        fridgeId
+        weekStart
+        updatedAt
+        slots {
+          id
+          date
+          mealType
+          recipeId
+          recipe {
+            id
+            title
+          }
+          portions
+          scheduledBy
+          reserved
+          cookedAt
+          warnings
+        }''';
+
+  Future<MealPlan> getMealPlan({DateTime? week, String? fridgeId}) async {
+    final QueryOptions options = QueryOptions(
+      document: gql('''
+        query MealPlan(\$weekStart: String, \$fridgeId: ID) {
+          mealPlan(weekStart: \$weekStart, fridgeId: \$fridgeId) {
+            $mealPlanFields
+          }
+        }
+      '''),
+      variables: {
+        'weekStart': week != null ? _day(week) : null,
+        'fridgeId': fridgeId,
+      },
+      fetchPolicy: FetchPolicy.networkOnly,
+    );
+
+    final QueryResult result = await client.query(options);
+
+    if (result.hasException) {
+      throw Exception(result.exception.toString());
+    }
+
+    return MealPlan.fromJson(result.data!['mealPlan']);
+  }
+
+  Future<MealPlan> scheduleMeal({
+    required DateTime date,
+    required MealType mealType,
+    required String recipeId,
+    int? portions,
+    String? fridgeId,
+  }) async {
+    final MutationOptions options = MutationOptions(
+      document: gql('''
+        mutation ScheduleMeal(\$input: ScheduleMealInput!, \$fridgeId: ID) {
+          scheduleMeal(input: \$input, fridgeId: \$fridgeId) {
+            $mealPlanFields
+          }
+        }
+      '''),
+      variables: {
+        'input': {
+          'date': _day(date),
+          'mealType': mealType.toJson(),
+          'recipeId': recipeId,
+          'portions': portions,
+        },
+        'fridgeId': fridgeId,
+      },
+    );
+
+    final QueryResult result = await client.mutate(options);
+
+    if (result.hasException) {
+      throw Exception(result.exception.toString());
+    }
+
+    return MealPlan.fromJson(result.data!['scheduleMeal']);
+  }
+
+  Future<MealPlan> unscheduleMeal(
+    MealSlot slot, {
+    String? fridgeId,
+  }) async {
+    final MutationOptions options = MutationOptions(
+      document: gql('''
+        mutation UnscheduleMeal(\$id: ID!, \$date: String!, \$fridgeId: ID) {
+          unscheduleMeal(id: \$id, date: \$date, fridgeId: \$fridgeId) {
+            $mealPlanFields
+          }
+        }
+      '''),
+      variables: {
+        'id': slot.id,
+        'date': _day(slot.date),
+        'fridgeId': fridgeId,
+      },
+    );
+
+    final QueryResult result = await client.mutate(options);
+
+    if (result.hasException) {
+      throw Exception(result.exception.toString());
+    }
+
+    return MealPlan.fromJson(result.data!['unscheduleMeal']);
+  }
+
+  static String _day(DateTime d) =>
+      '${d.year.toString().padLeft(4, '0')}-${d.month.toString().padLeft(2, '0')}-${d.day.toString().padLeft(2, '0')}';
+}
@@ -27,53 +27,85 @@ class RecipeService {
     });
   }
 
-  Future<List<Recipe>> getMyRecipes({
-    RecipeStatus? status,
-    bool includeAi = false,
-  }) async {
-    const String query = r'''
-      query MyRecipes($status: RecipeStatus) {
-        myRecipes(status: $status) {
-          id
-          authorId
-          title
-          description
-          status
-          ingredients {
-            name
-            quantity
-            unit
-            inventoryItemId
-            isAvailableInFridge
+  static const String _myRecipesQuery = r'''
+    query MyRecipes($status: RecipeStatus, $first: Int, $after: String) {
+      myRecipes(status: $status, first: $first, after: $after) {
+        edges {
+          node {
+            id
+            authorId
+            title
+            description
+            status
+            ingredients {
+              name
+              quantity
+              unit
+              inventoryItemId
+              isAvailableInFridge
+            }
+            steps
+            prepTimeMinutes
+            calories
+            ecoPointsReward
+            ttlSecondsRemaining
+            generatedByAI
+          servings
+          preparationPortions
+          dietTags
+          dietWarnings
+            savedFrom {
+              postId
+              authorId
+              authorNickname
+            }
           }
-          steps
-          prepTimeMinutes
-          calories
-          ecoPointsReward
-          ttlSecondsRemaining
-          generatedByAI
+        }
+        pageInfo {
+          hasNextPage
+          endCursor
         }
       }
-    ''';
+    }
+  ''';
 
-    final QueryOptions options = QueryOptions(
-      document: gql(query),
-      variables: {'status': status?.toJson()},
-      fetchPolicy: FetchPolicy.networkOnly,
-    );
+  // Walks every page of myRecipes, the screens show the whole list at once.
+  Future<List<Recipe>> _fetchMyRecipes(RecipeStatus? status) async {
+    final recipes = <Recipe>[];
+    String? after;
 
-    final QueryResult result = await client.query(options);
+    while (true) {
+      final QueryOptions options = QueryOptions(
+        document: gql(_myRecipesQuery),
+        variables: {'status': status?.toJson(), 'first': 100, 'after': after},
+        fetchPolicy: FetchPolicy.networkOnly,
+      );
 
-    if (result.hasException) {
-      throw Exception(result.exception.toString());
-    }
+      final QueryResult result = await client.query(options);
 
-    final List<dynamic> recipesJson =
-        result.data?['myRecipes'] as List<dynamic>? ?? [];
+      if (result.hasException) {
+        throw Exception(result.exception.toString());
+      }
 
-    final allRecipes = recipesJson
-        .map((e) => Recipe.fromJson(e as Map<String, dynamic>))
-        .toList();
+      final connection = result.data?['myRecipes'];
+      final List<dynamic> edges = connection?['edges'] as List<dynamic>? ?? [];
+      recipes.addAll(
+        edges.map((e) => Recipe.fromJson(e['node'] as Map<String, dynamic>)),
+      );
+
+      final pageInfo = connection?['pageInfo'];
+      after = pageInfo?['endCursor'] as String?;
+      if (pageInfo?['hasNextPage'] != true || after == null) {
+        return recipes;
+      }
+    }
+  }
+
+  Future<List<Recipe>> getMyRecipes({
+    RecipeStatus? status,
+    bool includeAi = false,
+  }) async {
+    final allRecipes = await _fetchMyRecipes(status);
 
     final currentRecipeCount = allRecipes.length;
     final currentAiCount = allRecipes.where((r) => r.generatedByAI).length;
@@ -93,9 +125,30 @@ class RecipeService {
   }
 
   Future<List<Recipe>> getMyAiRecipes({RecipeStatus? status}) async {
+    final allRecipes = await _fetchMyRecipes(status);
+
+    final currentRecipeCount = allRecipes.length;
+    final currentAiCount = allRecipes.where((r) => r.generatedByAI).length;
+
+    if (_pendingRecipes.isNotEmpty &&
+        (currentAiCount > _lastAiCount ||
+            currentRecipeCount > _lastRecipeCount)) {
+      _pendingRecipes.clear();
+    }
+    _lastAiCount = currentAiCount;
+    _lastRecipeCount = currentRecipeCount;
+
+    final fetchedRecipes = allRecipes
+        .where((recipe) => recipe.generatedByAI)
+        .toList();
+
+    return [..._pendingRecipes, ...fetchedRecipes];
+  }
+
+  Future<Recipe?> getRecipe(String id) async {
     const String query = r'''
-      query MyRecipes($status: RecipeStatus) {
-        myRecipes(status: $status) {
+      query Recipe($id: ID!) {
+        recipe(id: $id) {
           id
           authorId
           title
@@ -108,19 +161,37 @@ class RecipeService {
             inventoryItemId
             isAvailableInFridge
           }
+          cookedItems {
+            id
+            name
+            usedQuantity
+            quantity {
+              value
+              unit
+            }
+          }
           steps
           prepTimeMinutes
           calories
           ecoPointsReward
           ttlSecondsRemaining
           generatedByAI
+          servings
+          preparationPortions
+          dietTags
+          dietWarnings
+          savedFrom {
+            postId
+            authorId
+            authorNickname
+          }
         }
       }
     ''';
 
     final QueryOptions options = QueryOptions(
       document: gql(query),
-      variables: {'status': status?.toJson()},
+      variables: {'id': id},
       fetchPolicy: FetchPolicy.networkOnly,
     );
 
@@ -130,35 +201,18 @@ class RecipeService {
       throw Exception(result.exception.toString());
     }
 
-    final List<dynamic> recipesJson =
-        result.data?['myRecipes'] as List<dynamic>? ?? [];
-
-    final allRecipes = recipesJson
-        .map((e) => Recipe.fromJson(e as Map<String, dynamic>))
-        .toList();
-
-    final currentRecipeCount = allRecipes.length;
-    final currentAiCount = allRecipes.where((r) => r.generatedByAI).length;
-
-    if (_pendingRecipes.isNotEmpty &&
-        (currentAiCount > _lastAiCount ||
-            currentRecipeCount > _lastRecipeCount)) {
-      _pendingRecipes.clear();
+    final data = result.data?['recipe'];
+    if (data == null) {
+      return null;
     }
-    _lastAiCount = currentAiCount;
-    _lastRecipeCount = currentRecipeCount;
-
-    final fetchedRecipes = allRecipes
-        .where((recipe) => recipe.generatedByAI)
-        .toList();
 
-    return [..._pendingRecipes, ...fetchedRecipes];
+    return Recipe.fromJson(data);
   }
 
-  Future<Recipe?> getRecipe(String id) async {
+  Future<Recipe?> getScaledRecipe(String id, int portions) async {
     const String query = r'''
-      query Recipe($id: ID!) {
-        recipe(id: $id) {
+      query ScaledRecipe($id: ID!, $portions: Int!) {
+        scaledRecipe(id: $id, portions: $portions) {
           id
           authorId
           title
@@ -171,28 +225,19 @@ class RecipeService {
             inventoryItemId
             isAvailableInFridge
           }
-          cookedItems {
-            id
-            name
-            usedQuantity
-            quantity {
-              value
-              unit
-            }
-          }
           steps
           prepTimeMinutes
           calories
           ecoPointsReward
-          ttlSecondsRemaining
           generatedByAI
+          servings
         }
       }
     ''';
 
     final QueryOptions options = QueryOptions(
       document: gql(query),
-      variables: {'id': id},
+      variables: {'id': id, 'portions': portions},
       fetchPolicy: FetchPolicy.networkOnly,
     );
 
@@ -202,7 +247,7 @@ class RecipeService {
       throw Exception(result.exception.toString());
     }
 
-    final data = result.data?['recipe'];
+    final data = result.data?['scaledRecipe'];
     if (data == null) {
       return null;
     }
@@ -241,6 +286,10 @@ class RecipeService {
           ecoPointsReward
           ttlSecondsRemaining
           generatedByAI
+          servings
+          preparationPortions
+          dietTags
+          dietWarnings
         }
       }
     ''';
@@ -263,10 +312,14 @@ class RecipeService {
     return Recipe.fromJson(result.data!['createRecipe']);
   }
 
-  Future<Recipe> updateRecipe(String id, UpdateRecipeInput input) async {
+  Future<Recipe> updateRecipe(
+    String id,
+    UpdateRecipeInput input, {
+    int? portions,
+  }) async {
     const String mutation = r'''
-      mutation UpdateRecipe($id: ID!, $input: UpdateRecipeInput!) {
-        updateRecipe(id: $id, input: $input) {
+      mutation UpdateRecipe($id: ID!, $input: UpdateRecipeInput!, $portions: Int) {
+        updateRecipe(id: $id, input: $input, portions: $portions) {
           id
           authorId
           title
@@ -294,13 +347,17 @@ class RecipeService {
           ecoPointsReward
           ttlSecondsRemaining
           generatedByAI
+          servings
+          preparationPortions
+          dietTags
+          dietWarnings
         }
       }
     ''';
 
     final MutationOptions options = MutationOptions(
       document: gql(mutation),
-      variables: {'id': id, 'input': input.toJson()},
+      variables: {'id': id, 'input': input.toJson(), 'portions': portions},
     );
 
     final QueryResult result = await client.mutate(options);
@@ -316,6 +373,99 @@ class RecipeService {
     return Recipe.fromJson(result.data!['updateRecipe']);
   }
 
+  Future<List<IngredientMatchSuggestion>> suggestIngredientMatches(
+    String recipeId,
+  ) async {
+    const String query = r'''
+      query SuggestIngredientMatches($recipeId: ID!) {
+        suggestIngredientMatches(recipeId: $recipeId) {
+          ingredientIndex
+          ingredientName
+          currentInventoryItemId
+          candidates {
+            item {
+              id
+              name
+            }
+            score
+            sufficient
+          }
+        }
+      }
+    ''';
+
+    final QueryOptions options = QueryOptions(
+      document: gql(query),
+      variables: {'recipeId': recipeId},
+      fetchPolicy: FetchPolicy.networkOnly,
+    );
+
+    final QueryResult result = await client.query(options);
+
+    if (result.hasException) {
+      throw Exception(result.exception.toString());
+    }
+
+    final List<dynamic> suggestionsJson =
+        result.data?['suggestIngredientMatches'] as List<dynamic>? ?? [];
+    return suggestionsJson
+        .map(
+          (e) => IngredientMatchSuggestion.fromJson(e as Map<String, dynamic>),
+        )
+        .toList();
+  }
+
+  /// [source] is either 'MINE' or 'FEED'.
+  Future<List<CookableRecipe>> getCookableRecipes({
+    String source = 'MINE',
+    int limit = 10,
+    bool respectDiet = true,
+  }) async {
+    const String query = r'''
+      query CookableRecipes($source: RecipeSource, $limit: Int, $respectDiet: Boolean) {
+        cookableRecipes(source: $source, limit: $limit, respectDiet: $respectDiet) {
+          title
+          recipe {
+            id
+          }
+          post {
+            id
+          }
+          score
+          coverage
+          expiringItemsUsed
+          missingIngredients {
+            name
+            quantity
+            unit
+          }
+        }
+      }
+    ''';
+
+    final QueryOptions options = QueryOptions(
+      document: gql(query),
+      variables: {
+        'source': source,
+        'limit': limit,
+        'respectDiet': respectDiet,
+      },
+      fetchPolicy: FetchPolicy.networkOnly,
+    );
+
+    final QueryResult result = await client.query(options);
+
+    if (result.hasException) {
+      throw Exception(result.exception.toString());
+    }
+
+    final List<dynamic> cookableJson =
+        result.data?['cookableRecipes'] as List<dynamic>? ?? [];
+    return cookableJson
+        .map((e) => CookableRecipe.fromJson(e as Map<String, dynamic>))
+        .toList();
+  }
+
   Future<bool> deleteRecipe(String id) async {
     const String mutation = r'''
       mutation DeleteRecipe($id: ID!) {
@@ -337,20 +487,43 @@ class RecipeService {
     return result.data?['deleteRecipe'] as bool? ?? false;
   }
 
-  Future<Recipe> saveRecipe(String id) async {
+  Future<Recipe> saveRecipeFromPost(String postId) async {
     const String mutation = r'''
-      mutation SaveRecipe($id: ID!) {
-        saveRecipe(id: $id) {
+      mutation SaveRecipeFromPost($postId: ID!) {
+        saveRecipeFromPost(postId: $postId) {
           id
+          authorId
           title
+          description
           status
+          ingredients {
+            name
+            quantity
+            unit
+            inventoryItemId
+            isAvailableInFridge
+          }
+          steps
+          prepTimeMinutes
+          calories
+          ecoPointsReward
+          generatedByAI
+          servings
+          preparationPortions
+          dietTags
+          dietWarnings
+          savedFrom {
+            postId
+            authorId
+            authorNickname
+          }
         }
       }
     ''';
 
     final MutationOptions options = MutationOptions(
       document: gql(mutation),
-      variables: {'id': id},
+      variables: {'postId': postId},
     );
 
     final QueryResult result = await client.mutate(options);
@@ -359,17 +532,17 @@ class RecipeService {
       throw Exception(result.exception.toString());
     }
 
-    if (result.data == null || result.data!['saveRecipe'] == null) {
+    if (result.data == null || result.data!['saveRecipeFromPost'] == null) {
       throw Exception('Failed to save recipe');
     }
 
-    return Recipe.fromJson(result.data!['saveRecipe']);
+    return Recipe.fromJson(result.data!['saveRecipeFromPost']);
   }
 
-  Future<Recipe> cookRecipe(String id) async {
+  Future<Recipe> cookRecipe(String id, {int? portions}) async {
     const String mutation = r'''
-      mutation CookRecipe($id: ID!) {
-        cookRecipe(id: $id) {
+      mutation CookRecipe($id: ID!, $portions: Int) {
+        cookRecipe(id: $id, portions: $portions) {
           id
           title
           status
@@ -388,7 +561,7 @@ class RecipeService {
 
     final MutationOptions options = MutationOptions(
       document: gql(mutation),
-      variables: {'id': id},
+      variables: {'id': id, 'portions': portions},
     );
 
     final QueryResult result = await client.mutate(options);
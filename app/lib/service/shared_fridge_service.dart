@@ -1,4 +1,5 @@
 import 'package:graphql_flutter/graphql_flutter.dart';
+import '../models/enums.dart';
 import '../models/inventory_model.dart';
 
 class SharedFridgeService {
@@ -6,17 +7,31 @@ class SharedFridgeService {
 
   SharedFridgeService(this.client);
 
-  Future<SharedFridgeLink> generateSharedFridgeLink() async {
+  Future<SharedFridgeLink> generateSharedFridgeLink({
+    String? fridgeId,
+    FridgeRole role = FridgeRole.editor,
+    int? expiresInMinutes,
+  }) async {
     const String mutation = r'''
-      mutation GenerateSharedFridgeLink {
-        generateSharedFridgeLink {
+      mutation GenerateSharedFridgeLink($fridgeId: ID, $role: FridgeRole, $expiresInMinutes: Int) {
+        generateSharedFridgeLink(fridgeId: $fridgeId, role: $role, expiresInMinutes: $expiresInMinutes) {
           authorId
           inviteCode
+          fridgeId
+          role
+          expiresAt
         }
       }
     ''';
 
-    final MutationOptions options = MutationOptions(document: gql(mutation));
+    final MutationOptions options = MutationOptions(
+      document: gql(mutation),
+      variables: {
+        'fridgeId': fridgeId,
+        'role': role.toJson(),
+        'expiresInMinutes': expiresInMinutes,
+      },
+    );
 
     final QueryResult result = await client.mutate(options);
 
@@ -32,6 +47,27 @@ class SharedFridgeService {
     return SharedFridgeLink.fromJson(result.data!['generateSharedFridgeLink']);
   }
 
+  Future<bool> revokeSharedFridgeLink(String inviteCode) async {
+    const String mutation = r'''
+      mutation RevokeSharedFridgeLink($inviteCode: ID!) {
+        revokeSharedFridgeLink(inviteCode: $inviteCode)
+      }
+    ''';
+
+    final MutationOptions options = MutationOptions(
+      document: gql(mutation),
+      variables: {'inviteCode': inviteCode},
+    );
+
+    final QueryResult result = await client.mutate(options);
+
+    if (result.hasException) {
+      throw Exception(result.exception.toString());
+    }
+
+    return result.data?['revokeSharedFridgeLink'] as bool? ?? false;
+  }
+
   Future<String?> addFridgeShared(String inviteCode) async {
     const String mutation = r'''
       mutation AddFridgeShared($sharedId: ID) {
@@ -52,4 +88,111 @@ class SharedFridgeService {
 
     return result.data?['addFridgeShared'] as String?;
   }
+
+  Future<List<FridgeMember>> getFridgeMembers(String fridgeId) async {
+    const String query = r'''
+      query FridgeMembers($fridgeId: ID!) {
+        fridgeMembers(fridgeId: $fridgeId) {
+          userId
+          nickname
+          role
+          joinedAt
+        }
+      }
+    ''';
+
+    final QueryOptions options = QueryOptions(
+      document: gql(query),
+      variables: {'fridgeId': fridgeId},
+      fetchPolicy: FetchPolicy.networkOnly,
+    );
+
+    final QueryResult result = await client.query(options);
+
+    if (result.hasException) {
+      throw Exception(result.exception.toString());
+    }
+
+    final List<dynamic> raw =
+        result.data?['fridgeMembers'] as List<dynamic>? ?? [];
+    return raw
+        .map((e) => FridgeMember.fromJson(e as Map<String, dynamic>))
+        .toList();
+  }
+
+  Future<bool> leaveFridge(String fridgeId) async {
+    const String mutation = r'''
+      mutation LeaveFridge($fridgeId: ID!) {
+        leaveFridge(fridgeId: $fridgeId)
+      }
+    ''';
+
+    final MutationOptions options = MutationOptions(
+      document: gql(mutation),
+      variables: {'fridgeId': fridgeId},
+    );
+
+    final QueryResult result = await client.mutate(options);
+
+    if (result.hasException) {
+      throw Exception(result.exception.toString());
+    }
+
+    return result.data?['leaveFridge'] as bool? ?? false;
+  }
+
+  Future<bool> removeFridgeMember(String fridgeId, String userId) async {
+    const String mutation = r'''
+      mutation RemoveFridgeMember($fridgeId: ID!, $userId: ID!) {
+        removeFridgeMember(fridgeId: $fridgeId, userId: $userId)
+      }
+    ''';
+
+    final MutationOptions options = MutationOptions(
+      document: gql(mutation),
+      variables: {'fridgeId': fridgeId, 'userId': userId},
+    );
+
+    final QueryResult result = await client.mutate(options);
+
+    if (result.hasException) {
+      throw Exception(result.exception.toString());
+    }
+
+    return result.data?['removeFridgeMember'] as bool? ?? false;
+  }
+
+  Future<FridgeMember> updateMemberRole(
+    String fridgeId,
+    String userId,
+    FridgeRole role,
+  ) async {
+    const String mutation = r'''
+      mutation UpdateMemberRole($fridgeId: ID!, $userId: ID!, $role: FridgeRole!) {
+        updateMemberRole(fridgeId: $fridgeId, userId: $userId, role: $role) {
+          userId
+          nickname
+          role
+          joinedAt
+        }
+      }
+    ''';
+
+    final MutationOptions options = MutationOptions(
+      document: gql(mutation),
+      variables: {'fridgeId': fridgeId, 'userId': userId, 'role': role.toJson()},
+    );
+
+    final QueryResult result = await client.mutate(options);
+
+    if (result.hasException) {
+      throw Exception(result.exception.toString());
+    }
+
+    if (result.data == null || result.data!['updateMemberRole'] == null) {
+      throw Exception('Failed to update member role');
+    }
+
+    return FridgeMember.fromJson(result.data!['updateMemberRole']);
+  }
 }
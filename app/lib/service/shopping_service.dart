@@ -20,28 +20,36 @@ class ShoppingService {
   ShoppingService(this.client);
 
   static const String getShoppingHistoryQuery = r'''
-    query GetShoppingHistory($limit: Int, $offset: Int) {
-      shoppingHistory(limit: $limit, offset: $offset) {
-        id
-        authorId
-        date
-        storeName
-        totalAmount
-        currency
-        isImported
-        receiptImageUrl
-        status
-        itemsSnapshot {
-          id
-          name
-          price
-          quantity
-          unit
-          category
-          brand
-          expiryDate
-          expiryType
-          confidence
+    query GetShoppingHistory($first: Int, $after: String) {
+      shoppingHistory(first: $first, after: $after) {
+        edges {
+          node {
+            id
+            authorId
+            date
+            storeName
+            totalAmount
+            currency
+            isImported
+            receiptImageUrl
+            status
+            itemsSnapshot {
+              id
+              name
+              price
+              quantity
+              unit
+              category
+              brand
+              expiryDate
+              expiryType
+              confidence
+            }
+          }
+        }
+        pageInfo {
+          hasNextPage
+          endCursor
         }
       }
     }
@@ -70,8 +78,8 @@ class ShoppingService {
   ''';
 
   static const String importShoppingHistoryToFridgeMutation = r'''
-    mutation ImportShoppingHistoryToFridge($id: ID!) {
-      importShoppingHistoryToFridge(id: $id) {
+    mutation ImportShoppingHistoryToFridge($id: ID!, $fridgeId: ID) {
+      importShoppingHistoryToFridge(id: $id, fridgeId: $fridgeId) {
         id
         isImported
       }
@@ -106,6 +114,22 @@ class ShoppingService {
     }
   ''';
 
+  static const String shoppingListFields = r'''
+        id
+        fridgeId
+        updatedAt
+        items {
+          id
+          name
+          quantity
+          unit
+          category
+          checked
+          addedBy
+          addedAt
+        }
+  ''';
+
   Future<ShoppingHistoryEntry?> getShoppingHistoryEntry(String id) async {
     const String query = getShoppingHistoryEntryQuery;
 
@@ -129,12 +153,16 @@ class ShoppingService {
 
   static const String getSuggestionsQuery = r'''
     query GetSuggestions {
-      shoppingHistory(limit: 50) {
-        storeName
-        itemsSnapshot {
-          name
-          category
-          brand
+      shoppingHistory(first: 50) {
+        edges {
+          node {
+            storeName
+            itemsSnapshot {
+              name
+              category
+              brand
+            }
+          }
         }
       }
       myFridge {
@@ -148,14 +176,14 @@ class ShoppingService {
   ''';
 
   Future<List<ShoppingHistoryEntry>> getShoppingHistory({
-    int limit = 10,
-    int offset = 0,
+    int first = 10,
+    String? after,
   }) async {
     const String query = getShoppingHistoryQuery;
 
     final QueryOptions options = QueryOptions(
       document: gql(query),
-      variables: {'limit': limit, 'offset': offset},
+      variables: {'first': first, 'after': after},
       fetchPolicy: FetchPolicy.networkOnly,
     );
 
@@ -165,10 +193,14 @@ class ShoppingService {
       throw Exception(result.exception.toString());
     }
 
-    final List<dynamic> historyJson =
-        result.data?['shoppingHistory'] as List<dynamic>? ?? [];
-    return historyJson
-        .map((e) => ShoppingHistoryEntry.fromJson(e as Map<String, dynamic>))
+    final List<dynamic> edges =
+        result.data?['shoppingHistory']?['edges'] as List<dynamic>? ?? [];
+    return edges
+        .map(
+          (e) => ShoppingHistoryEntry.fromJson(
+            e['node'] as Map<String, dynamic>,
+          ),
+        )
         .toList();
   }
 
@@ -226,12 +258,15 @@ class ShoppingService {
     return result.data?['updateShoppingHistory']['id'] as String;
   }
 
-  Future<ShoppingHistoryEntry> importShoppingHistoryToFridge(String id) async {
+  Future<ShoppingHistoryEntry> importShoppingHistoryToFridge(
+    String id, {
+    String? fridgeId,
+  }) async {
     const String mutation = importShoppingHistoryToFridgeMutation;
 
     final MutationOptions options = MutationOptions(
       document: gql(mutation),
-      variables: {'id': id},
+      variables: {'id': id, 'fridgeId': fridgeId},
     );
 
     final QueryResult result = await client.mutate(options);
@@ -252,6 +287,226 @@ class ShoppingService {
     );
   }
 
+  Future<ShoppingList> getShoppingList({String? fridgeId}) async {
+    final QueryOptions options = QueryOptions(
+      document: gql('''
+        query GetShoppingList(\$fridgeId: ID) {
+          shoppingList(fridgeId: \$fridgeId) {
+            $shoppingListFields
+          }
+        }
+      '''),
+      variables: {'fridgeId': fridgeId},
+      fetchPolicy: FetchPolicy.networkOnly,
+    );
+
+    final QueryResult result = await client.query(options);
+
+    if (result.hasException) {
+      throw Exception(result.exception.toString());
+    }
+
+    return ShoppingList.fromJson(result.data!['shoppingList']);
+  }
+
+  Future<ShoppingList> addShoppingListItem({
+    required String name,
+    double? quantity,
+    Unit? unit,
+    String? category,
+    String? fridgeId,
+  }) async {
+    return _mutateShoppingList(
+      '''
+        mutation AddShoppingListItem(\$input: ShoppingListItemInput!, \$fridgeId: ID) {
+          addShoppingListItem(input: \$input, fridgeId: \$fridgeId) {
+            $shoppingListFields
+          }
+        }
+      ''',
+      'addShoppingListItem',
+      {
+        'input': {
+          'name': name,
+          'quantity': quantity,
+          'unit': unit?.toJson(),
+          'category': category,
+        },
+        'fridgeId': fridgeId,
+      },
+    );
+  }
+
+  Future<ShoppingList> updateShoppingListItem(
+    String id, {
+    String? name,
+    double? quantity,
+    Unit? unit,
+    String? category,
+    bool? checked,
+    String? fridgeId,
+  }) async {
+    final Map<String, dynamic> input = {
+      if (name != null) 'name': name,
+      if (quantity != null) 'quantity': quantity,
+      if (unit != null) 'unit': unit.toJson(),
+      if (category != null) 'category': category,
+      if (checked != null) 'checked': checked,
+    };
+
+    return _mutateShoppingList(
+      '''
+        mutation UpdateShoppingListItem(\$id: ID!, \$input: UpdateShoppingListItemInput!, \$fridgeId: ID) {
+          updateShoppingListItem(id: \$id, input: \$input, fridgeId: \$fridgeId) {
+            $shoppingListFields
+          }
+        }
+      ''',
+      'updateShoppingListItem',
+      {'id': id, 'input': input, 'fridgeId': fridgeId},
+    );
+  }
+
+  Future<ShoppingList> removeShoppingListItem(
+    String id, {
+    String? fridgeId,
+  }) async {
+    return _mutateShoppingList(
+      '''
+        mutation RemoveShoppingListItem(\$id: ID!, \$fridgeId: ID) {
+          removeShoppingListItem(id: \$id, fridgeId: \$fridgeId) {
+            $shoppingListFields
+          }
+        }
+      ''',
+      'removeShoppingListItem',
+      {'id': id, 'fridgeId': fridgeId},
+    );
+  }
+
+  Future<ShoppingList> clearShoppingList({
+    bool checkedOnly = true,
+    String? fridgeId,
+  }) async {
+    return _mutateShoppingList(
+      '''
+        mutation ClearShoppingList(\$checkedOnly: Boolean, \$fridgeId: ID) {
+          clearShoppingList(checkedOnly: \$checkedOnly, fridgeId: \$fridgeId) {
+            $shoppingListFields
+          }
+        }
+      ''',
+      'clearShoppingList',
+      {'checkedOnly': checkedOnly, 'fridgeId': fridgeId},
+    );
+  }
+
+  Future<String> checkoutShoppingList({
+    String? storeName,
+    double? totalAmount,
+    bool importToFridge = false,
+    String? fridgeId,
+  }) async {
+    const String mutation = r'''
+      mutation CheckoutShoppingList($input: CheckoutShoppingListInput, $fridgeId: ID) {
+        checkoutShoppingList(input: $input, fridgeId: $fridgeId) {
+          id
+        }
+      }
+    ''';
+
+    final MutationOptions options = MutationOptions(
+      document: gql(mutation),
+      variables: {
+        'input': {
+          'storeName': storeName,
+          'totalAmount': totalAmount,
+          'importToFridge': importToFridge,
+        },
+        'fridgeId': fridgeId,
+      },
+    );
+
+    final QueryResult result = await client.mutate(options);
+
+    if (result.hasException) {
+      throw Exception(result.exception.toString());
+    }
+
+    return result.data?['checkoutShoppingList']['id'] as String;
+  }
+
+  Future<GeneratedShoppingList> generateShoppingList(
+    List<String> recipeIds, {
+    int? portions,
+    bool save = false,
+  }) async {
+    const String mutation = r'''
+      mutation GenerateShoppingList($recipeIds: [ID!]!, $portions: Int, $save: Boolean) {
+        generateShoppingList(recipeIds: $recipeIds, portions: $portions, save: $save) {
+          groups {
+            category
+            items {
+              name
+              quantity
+              unit
+            }
+          }
+          entry {
+            id
+            authorId
+            date
+            storeName
+            totalAmount
+            currency
+            isImported
+            receiptImageUrl
+            status
+            itemsSnapshot {
+              id
+              name
+              quantity
+              unit
+              category
+            }
+          }
+        }
+      }
+    ''';
+
+    final MutationOptions options = MutationOptions(
+      document: gql(mutation),
+      variables: {'recipeIds': recipeIds, 'portions': portions, 'save': save},
+    );
+
+    final QueryResult result = await client.mutate(options);
+
+    if (result.hasException) {
+      throw Exception(result.exception.toString());
+    }
+
+    return GeneratedShoppingList.fromJson(result.data!['generateShoppingList']);
+  }
+
+  Future<ShoppingList> _mutateShoppingList(
+    String mutation,
+    String field,
+    Map<String, dynamic> variables,
+  ) async {
+    final MutationOptions options = MutationOptions(
+      document: gql(mutation),
+      variables: variables,
+    );
+
+    final QueryResult result = await client.mutate(options);
+
+    if (result.hasException) {
+      throw Exception(result.exception.toString());
+    }
+
+    return ShoppingList.fromJson(result.data![field]);
+  }
+
   Future<String> generateUploadSasToken(String filename, String purpose) async {
     const String mutation = r'''
       mutation GenerateUploadSasToken($filename: String!, $purpose: UploadPurpose!) {
@@ -1,5 +1,6 @@
 import 'package:graphql_flutter/graphql_flutter.dart';
 import 'package:flutter_riverpod/flutter_riverpod.dart';
+import '../models/enums.dart';
 import '../models/social_model.dart';
 
 class SocialRefreshNotifier extends Notifier<int> {
@@ -13,43 +14,68 @@ final socialRefreshProvider = NotifierProvider<SocialRefreshNotifier, int>(
   SocialRefreshNotifier.new,
 );
 
+class FeedPage {
+  final List<Post> posts;
+  final String? endCursor;
+  final bool hasNextPage;
+
+  const FeedPage({
+    required this.posts,
+    required this.endCursor,
+    required this.hasNextPage,
+  });
+}
+
 class SocialService {
   final GraphQLClient client;
 
   SocialService(this.client);
 
-  Future<List<Post>> getFeed({int limit = 20, int offset = 0}) async {
+  Future<FeedPage> getFeed({
+    int first = 20,
+    String? after,
+    bool respectDiet = true,
+  }) async {
     const String query = r'''
-      query Feed($limit: Int, $offset: Int) {
-        feed(limit: $limit, offset: $offset) {
-          id
-          authorId
-          authorNickname
-          createdAt
-          imageUrl
-          caption
-          likesCount
-          likedBy
-          recipeSnapshot {
-             title
-             description
-             ingredients {
-               name
-               quantity
-               unit
-             }
-             steps
-             prepTimeMinutes
-             calories
-             ecoPointsReward
+      query Feed($first: Int, $after: String, $respectDiet: Boolean) {
+        feed(first: $first, after: $after, respectDiet: $respectDiet) {
+          edges {
+            node {
+              id
+              authorId
+              authorNickname
+              createdAt
+              imageUrl
+              caption
+              likesCount
+              likedBy
+              recipeSnapshot {
+                 title
+                 description
+                 ingredients {
+                   name
+                   quantity
+                   unit
+                 }
+                 steps
+                 prepTimeMinutes
+                 calories
+                 ecoPointsReward
+                 dietTags
+              }
+              comments {
+                id
+                userId
+                userNickname
+                text
+                createdAt
+                removed
+              }
+            }
           }
-          comments {
-            id
-            userId
-            userNickname
-            text
-            createdAt
-            removed
+          pageInfo {
+            hasNextPage
+            endCursor
           }
         }
       }
@@ -57,7 +83,7 @@ class SocialService {
 
     final QueryOptions options = QueryOptions(
       document: gql(query),
-      variables: {'limit': limit, 'offset': offset},
+      variables: {'first': first, 'after': after, 'respectDiet': respectDiet},
       fetchPolicy: FetchPolicy.networkOnly,
     );
 
@@ -67,11 +93,16 @@ class SocialService {
       throw result.exception!;
     }
 
-    final List<dynamic> postsJson =
-        result.data?['feed'] as List<dynamic>? ?? [];
-    return postsJson
-        .map((e) => Post.fromJson(e as Map<String, dynamic>))
-        .toList();
+    final feed = result.data?['feed'] as Map<String, dynamic>?;
+    final List<dynamic> edges = feed?['edges'] as List<dynamic>? ?? [];
+    final pageInfo = feed?['pageInfo'] as Map<String, dynamic>?;
+    return FeedPage(
+      posts: edges
+          .map((e) => Post.fromJson(e['node'] as Map<String, dynamic>))
+          .toList(),
+      endCursor: pageInfo?['endCursor'] as String?,
+      hasNextPage: pageInfo?['hasNextPage'] as bool? ?? false,
+    );
   }
 
   Future<Post?> getPostById(
@@ -79,18 +110,18 @@ class SocialService {
     int pageSize = 50,
     int maxPages = 5,
   }) async {
-    var offset = 0;
+    String? after;
     for (var page = 0; page < maxPages; page++) {
-      final posts = await getFeed(limit: pageSize, offset: offset);
-      for (final post in posts) {
+      final feed = await getFeed(first: pageSize, after: after);
+      for (final post in feed.posts) {
         if (post.id == postId) {
           return post;
         }
       }
-      if (posts.length < pageSize) {
+      if (!feed.hasNextPage) {
         break;
       }
-      offset += pageSize;
+      after = feed.endCursor;
     }
     return null;
   }
@@ -119,6 +150,7 @@ class SocialService {
              prepTimeMinutes
              calories
              ecoPointsReward
+             dietTags
           }
           comments {
             id
@@ -195,6 +227,7 @@ class SocialService {
              prepTimeMinutes
              calories
              ecoPointsReward
+             dietTags
           }
           comments {
             id
@@ -250,6 +283,7 @@ class SocialService {
              prepTimeMinutes
              calories
              ecoPointsReward
+             dietTags
           }
           comments {
             id
@@ -305,6 +339,7 @@ class SocialService {
              prepTimeMinutes
              calories
              ecoPointsReward
+             dietTags
           }
           comments {
             id
@@ -368,10 +403,15 @@ class SocialService {
     return Comment.fromJson(result.data!['addComment']);
   }
 
-  Future<List<LeaderboardEntry>> getLeaderboard({int top = 50}) async {
+  Future<List<LeaderboardEntry>> getLeaderboard({
+    int top = 50,
+    LeaderboardPeriod period = LeaderboardPeriod.allTime,
+    LeaderboardScope scope = LeaderboardScope.global,
+    String? fridgeId,
+  }) async {
     const String query = r'''
-      query Leaderboard($top: Int) {
-        leaderboard(top: $top) {
+      query Leaderboard($top: Int, $period: LeaderboardPeriod, $scope: LeaderboardScope, $fridgeId: ID) {
+        leaderboard(top: $top, period: $period, scope: $scope, fridgeId: $fridgeId) {
           rank
           nickname
           score
@@ -380,7 +420,12 @@ class SocialService {
     ''';
     final QueryOptions options = QueryOptions(
       document: gql(query),
-      variables: {'top': top},
+      variables: {
+        'top': top,
+        'period': period.toJson(),
+        'scope': scope.toJson(),
+        'fridgeId': fridgeId,
+      },
       fetchPolicy: FetchPolicy.networkOnly,
     );
 
@@ -397,6 +442,87 @@ class SocialService {
         .toList();
   }
 
+  Future<LeaderboardPosition> getMyLeaderboardPosition({
+    LeaderboardPeriod period = LeaderboardPeriod.allTime,
+    int neighbours = 2,
+  }) async {
+    const String query = r'''
+      query MyLeaderboardPosition($period: LeaderboardPeriod, $neighbours: Int) {
+        myLeaderboardPosition(period: $period, neighbours: $neighbours) {
+          rank
+          score
+          above {
+            rank
+            nickname
+            score
+          }
+          below {
+            rank
+            nickname
+            score
+          }
+        }
+      }
+    ''';
+    final QueryOptions options = QueryOptions(
+      document: gql(query),
+      variables: {'period': period.toJson(), 'neighbours': neighbours},
+      fetchPolicy: FetchPolicy.networkOnly,
+    );
+
+    final QueryResult result = await client.query(options);
+
+    if (result.hasException) {
+      throw result.exception!;
+    }
+
+    return LeaderboardPosition.fromJson(
+      result.data?['myLeaderboardPosition'] as Map<String, dynamic>,
+    );
+  }
+
+  Future<List<LeaderboardArchive>> getLeaderboardArchive(
+    LeaderboardPeriod period, {
+    int last = 4,
+  }) async {
+    const String query = r'''
+      query LeaderboardArchive($period: LeaderboardPeriod!, $last: Int) {
+        leaderboardArchive(period: $period, last: $last) {
+          period
+          key
+          closedAt
+          standings {
+            rank
+            nickname
+            score
+          }
+          winners {
+            rank
+            nickname
+            score
+          }
+        }
+      }
+    ''';
+    final QueryOptions options = QueryOptions(
+      document: gql(query),
+      variables: {'period': period.toJson(), 'last': last},
+      fetchPolicy: FetchPolicy.networkOnly,
+    );
+
+    final QueryResult result = await client.query(options);
+
+    if (result.hasException) {
+      throw result.exception!;
+    }
+
+    final List<dynamic> archiveJson =
+        result.data?['leaderboardArchive'] as List<dynamic>? ?? [];
+    return archiveJson
+        .map((e) => LeaderboardArchive.fromJson(e as Map<String, dynamic>))
+        .toList();
+  }
+
   Future<String> generateUploadSasToken(String filename) async {
     const String mutation = r'''
       mutation GenerateUploadSasToken($filename: String!, $purpose: UploadPurpose!) {
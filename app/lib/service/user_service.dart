@@ -2,6 +2,18 @@ import 'package:flutter/foundation.dart';
 import 'package:graphql_flutter/graphql_flutter.dart';
 import '../models/user_model.dart';
 
+class PointsHistoryPage {
+  final List<PointsEntry> entries;
+  final String? endCursor;
+  final bool hasNextPage;
+
+  const PointsHistoryPage({
+    required this.entries,
+    required this.endCursor,
+    required this.hasNextPage,
+  });
+}
+
 class UserService {
   final GraphQLClient client;
 
@@ -21,12 +33,25 @@ class UserService {
             currentLevel
             nextLevelThreshold
             badges
+            earnedBadges {
+              badgeId
+              earnedAt
+              badge {
+                id
+                title
+                description
+                criterion
+                threshold
+              }
+            }
+            badgesTrackedSince
             wastedMoneyYTD
           }
           preferences {
             dietaryRestrictions
             defaultPortions
             currency
+            activeFridgeId
           }
         }
       }
@@ -85,6 +110,7 @@ class UserService {
             dietaryRestrictions
             defaultPortions
             currency
+            activeFridgeId
           }
         }
       }
@@ -124,12 +150,25 @@ class UserService {
             currentLevel
             nextLevelThreshold
             badges
+            earnedBadges {
+              badgeId
+              earnedAt
+              badge {
+                id
+                title
+                description
+                criterion
+                threshold
+              }
+            }
+            badgesTrackedSince
             wastedMoneyYTD
           }
           preferences {
             dietaryRestrictions
             defaultPortions
             currency
+            activeFridgeId
           }
         }
       }
@@ -153,6 +192,83 @@ class UserService {
     return User.fromJson(result.data!['updateUserPreferences']);
   }
 
+  Future<List<Badge>> getBadgeCatalog() async {
+    const String query = r'''
+      query BadgeCatalog {
+        badgeCatalog {
+          id
+          title
+          description
+          criterion
+          threshold
+        }
+      }
+    ''';
+
+    final QueryOptions options = QueryOptions(document: gql(query));
+
+    final QueryResult result = await client.query(options);
+
+    if (result.hasException) {
+      throw Exception(result.exception.toString());
+    }
+
+    final List<dynamic> badgesJson =
+        result.data?['badgeCatalog'] as List<dynamic>? ?? [];
+    return badgesJson
+        .map((e) => Badge.fromJson(e as Map<String, dynamic>))
+        .toList();
+  }
+
+  Future<PointsHistoryPage> getPointsHistory({
+    int first = 20,
+    String? after,
+  }) async {
+    const String query = r'''
+      query MyPointsHistory($first: Int, $after: String) {
+        myPointsHistory(first: $first, after: $after) {
+          edges {
+            cursor
+            node {
+              id
+              points
+              reason
+              sourceId
+              createdAt
+            }
+          }
+          pageInfo {
+            hasNextPage
+            endCursor
+          }
+        }
+      }
+    ''';
+
+    final QueryOptions options = QueryOptions(
+      document: gql(query),
+      variables: {'first': first, 'after': after},
+      fetchPolicy: FetchPolicy.networkOnly,
+    );
+
+    final QueryResult result = await client.query(options);
+
+    if (result.hasException) {
+      throw Exception(result.exception.toString());
+    }
+
+    final conn = result.data?['myPointsHistory'] as Map<String, dynamic>?;
+    final List<dynamic> edges = conn?['edges'] as List<dynamic>? ?? [];
+    final pageInfo = conn?['pageInfo'] as Map<String, dynamic>?;
+    return PointsHistoryPage(
+      entries: edges
+          .map((e) => PointsEntry.fromJson(e['node'] as Map<String, dynamic>))
+          .toList(),
+      endCursor: pageInfo?['endCursor'] as String?,
+      hasNextPage: pageInfo?['hasNextPage'] as bool? ?? false,
+    );
+  }
+
   Future<void> updateNickname(String newNickname) async {
     const String mutation = r'''
       mutation UpdateUserPreferences($newNickname: String!) {
@@ -174,6 +290,48 @@ class UserService {
     }
   }
 
+  Future<void> addFriend(String nickname) async {
+    const String mutation = r'''
+      mutation AddFriend($nickname: String!) {
+        addFriend(nickname: $nickname) {
+          friendIds
+        }
+      }
+    ''';
+
+    final MutationOptions options = MutationOptions(
+      document: gql(mutation),
+      variables: {'nickname': nickname},
+    );
+
+    final QueryResult result = await client.mutate(options);
+
+    if (result.hasException) {
+      throw result.exception!;
+    }
+  }
+
+  Future<void> removeFriend(String userId) async {
+    const String mutation = r'''
+      mutation RemoveFriend($userId: ID!) {
+        removeFriend(userId: $userId) {
+          friendIds
+        }
+      }
+    ''';
+
+    final MutationOptions options = MutationOptions(
+      document: gql(mutation),
+      variables: {'userId': userId},
+    );
+
+    final QueryResult result = await client.mutate(options);
+
+    if (result.hasException) {
+      throw result.exception!;
+    }
+  }
+
   Future<void> registerDevice(String handle, String platform) async {
     const String mutation = r'''
       mutation RegisterDevice($handle: String!, $platform: String!) {
@@ -59,6 +59,18 @@ class CodegenLoader extends AssetLoader{
   "no_badges_yet": "No badges yet",
   "no_entries_yet": "No entries yet",
   "leaderboard_preview": "Leaderboard preview",
+  "leaderboard_week": "This week",
+  "leaderboard_month": "This month",
+  "leaderboard_all_time": "All time",
+  "last_winners": "Last winners ({})",
+  "your_position": "You are #{} with {} points",
+  "not_ranked_yet": "Earn points to enter this leaderboard",
+  "leaderboard_global": "Everyone",
+  "leaderboard_household": "Household",
+  "leaderboard_friends": "Friends",
+  "add_friend": "Add friend",
+  "friend_nickname": "Friend's nickname",
+  "friend_added": "Friend added",
   "something_went_wrong": "Something went wrong",
   "eco_progress": "Eco progress",
   "pts": "pts",
@@ -156,6 +168,7 @@ class CodegenLoader extends AssetLoader{
   "no_recipes_found": "No recipes found",
   "error_saving_recipe": "Error saving recipe: {}",
   "error_loading_recipe": "Error loading recipe: {}",
+  "diet_warnings": "Check your dietary restrictions: {}",
   "cannot_consume_locked_item": "Cannot consume {item} completely because it is used in active recipes",
   "social": "Social",
   "feed": "Feed",
@@ -296,7 +309,13 @@ class CodegenLoader extends AssetLoader{
   "status_waking_up_hint": "This might take a minute initially due to cold start.",
   "status_failed_hint": "Please check your internet or try again later.",
   "status_secure_hint": "Please wait while we secure your session.",
-  "data_refreshed": "Data refreshed"
+  "data_refreshed": "Data refreshed",
+  "save_to_cookbook": "Save to cookbook",
+  "recipe_saved_to_cookbook": "Recipe saved to your cookbook",
+  "saved_from_author": "Saved from a post by {}",
+  "link_from_fridge": "Link from fridge",
+  "ingredients_linked": "{} ingredients linked, save to confirm",
+  "lock_expires_in": "Ingredients stay reserved for {} more minutes"
 };
 static const Map<String,dynamic> _it = {
   "hello": "Ciao!",
@@ -343,6 +362,18 @@ static const Map<String,dynamic> _it = {
   "no_badges_yet": "Ancora nessun badge",
   "no_entries_yet": "Nessun elemento",
   "leaderboard_preview": "Anteprima classifica",
+  "leaderboard_week": "Questa settimana",
+  "leaderboard_month": "Questo mese",
+  "leaderboard_all_time": "Di sempre",
+  "last_winners": "Ultimi vincitori ({})",
+  "your_position": "Sei #{} con {} punti",
+  "not_ranked_yet": "Guadagna punti per entrare in classifica",
+  "leaderboard_global": "Tutti",
+  "leaderboard_household": "Casa",
+  "leaderboard_friends": "Amici",
+  "add_friend": "Aggiungi amico",
+  "friend_nickname": "Nickname dell'amico",
+  "friend_added": "Amico aggiunto",
   "something_went_wrong": "Qualcosa è andato storto",
   "comment_removed_moderation": "Commento rimosso dalla moderazione",
   "eco_progress": "Progresso eco",
@@ -441,6 +472,7 @@ static const Map<String,dynamic> _it = {
   "no_recipes_found": "Nessuna ricetta trovata",
   "error_saving_recipe": "Errore durante il salvataggio della ricetta: {}",
   "error_loading_recipe": "Errore durante il caricamento della ricetta: {}",
+  "diet_warnings": "Controlla le tue restrizioni alimentari: {}",
   "cannot_consume_locked_item": "Impossibile consumare {item} completamente perché è utilizzato in ricette attive",
   "social": "Social",
   "feed": "Feed",
@@ -580,7 +612,13 @@ static const Map<String,dynamic> _it = {
   "status_waking_up_hint": "Potrebbe richiedere un minuto inizialmente a causa dell'avvio a freddo.",
   "status_failed_hint": "Controlla la tua connessione internet o riprova più tardi.",
   "status_secure_hint": "Attendi mentre proteggiamo la tua sessione.",
-  "data_refreshed": "Dati aggiornati"
+  "data_refreshed": "Dati aggiornati",
+  "save_to_cookbook": "Salva nel ricettario",
+  "recipe_saved_to_cookbook": "Ricetta salvata nel ricettario",
+  "saved_from_author": "Salvata da un post di {}",
+  "link_from_fridge": "Abbina dal frigo",
+  "ingredients_linked": "{} ingredienti abbinati, salva per confermare",
+  "lock_expires_in": "Gli ingredienti restano riservati ancora per {} minuti"
 };
 static const Map<String, Map<String,dynamic>> mapLocales = {"en": _en, "it": _it};
 }
@@ -47,6 +47,18 @@ abstract class  LocaleKeys {
   static const no_badges_yet = 'no_badges_yet';
   static const no_entries_yet = 'no_entries_yet';
   static const leaderboard_preview = 'leaderboard_preview';
+  static const leaderboard_week = 'leaderboard_week';
+  static const leaderboard_month = 'leaderboard_month';
+  static const leaderboard_all_time = 'leaderboard_all_time';
+  static const last_winners = 'last_winners';
+  static const your_position = 'your_position';
+  static const not_ranked_yet = 'not_ranked_yet';
+  static const leaderboard_global = 'leaderboard_global';
+  static const leaderboard_household = 'leaderboard_household';
+  static const leaderboard_friends = 'leaderboard_friends';
+  static const add_friend = 'add_friend';
+  static const friend_nickname = 'friend_nickname';
+  static const friend_added = 'friend_added';
   static const something_went_wrong = 'something_went_wrong';
   static const eco_progress = 'eco_progress';
   static const pts = 'pts';
@@ -136,6 +148,7 @@ abstract class  LocaleKeys {
   static const no_recipes_found = 'no_recipes_found';
   static const error_saving_recipe = 'error_saving_recipe';
   static const error_loading_recipe = 'error_loading_recipe';
+  static const diet_warnings = 'diet_warnings';
   static const cannot_consume_locked_item = 'cannot_consume_locked_item';
   static const social = 'social';
   static const feed = 'feed';
@@ -277,5 +290,11 @@ abstract class  LocaleKeys {
   static const status_failed_hint = 'status_failed_hint';
   static const status_secure_hint = 'status_secure_hint';
   static const data_refreshed = 'data_refreshed';
+  static const save_to_cookbook = 'save_to_cookbook';
+  static const recipe_saved_to_cookbook = 'recipe_saved_to_cookbook';
+  static const saved_from_author = 'saved_from_author';
+  static const link_from_fridge = 'link_from_fridge';
+  static const ingredients_linked = 'ingredients_linked';
+  static const lock_expires_in = 'lock_expires_in';
 
 }
@@ -123,7 +123,9 @@ class _FridgeScreenState extends ConsumerState<FridgeScreen>
 
   Future<void> _shareFridge(String fridgeId) async {
     try {
-      final link = await sharedFridgeService.generateSharedFridgeLink();
+      final link = await sharedFridgeService.generateSharedFridgeLink(
+        fridgeId: fridgeId,
+      );
       if (!mounted) return;
 
       await showDialog(
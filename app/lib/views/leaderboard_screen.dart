@@ -1,8 +1,10 @@
 import 'package:easy_localization/easy_localization.dart';
 import 'package:flutter/material.dart';
 import 'package:flutter_riverpod/flutter_riverpod.dart';
+import 'package:mocc/models/enums.dart';
 import 'package:mocc/models/social_model.dart';
 import 'package:mocc/service/social_service.dart';
+import 'package:mocc/service/user_service.dart';
 
 import '../service/graphql_config.dart';
 
@@ -16,47 +18,224 @@ class LeaderboardScreen extends ConsumerStatefulWidget {
 class _LeaderboardScreenState extends ConsumerState<LeaderboardScreen> {
   late final userService = ref.read(graphQLClientProvider);
   late final SocialService socialService = SocialService(userService);
-  late final Future<List<LeaderboardEntry>> entries = socialService
-      .getLeaderboard(top: 50);
+  late final UserService userSvc = UserService(userService);
+  LeaderboardPeriod period = LeaderboardPeriod.allTime;
+  LeaderboardScope scope = LeaderboardScope.global;
+  late Future<List<LeaderboardEntry>> entries = socialService.getLeaderboard(
+    top: 50,
+    period: period,
+    scope: scope,
+  );
+  late Future<LeaderboardPosition> position = socialService
+      .getMyLeaderboardPosition(period: period, neighbours: 0);
+  Future<List<LeaderboardArchive>>? archive;
+
+  void _selectPeriod(LeaderboardPeriod selected) {
+    setState(() {
+      period = selected;
+      entries = socialService.getLeaderboard(
+        top: 50,
+        period: selected,
+        scope: scope,
+      );
+      position = socialService.getMyLeaderboardPosition(
+        period: selected,
+        neighbours: 0,
+      );
+      archive = selected == LeaderboardPeriod.allTime
+          ? null
+          : socialService.getLeaderboardArchive(selected, last: 1);
+    });
+  }
+
+  void _selectScope(LeaderboardScope selected) {
+    setState(() {
+      scope = selected;
+      entries = socialService.getLeaderboard(
+        top: 50,
+        period: period,
+        scope: selected,
+      );
+    });
+  }
+
+  Future<void> _addFriend() async {
+    final TextEditingController nicknameController = TextEditingController();
+    await showDialog(
+      context: context,
+      builder: (dialogContext) => AlertDialog(
+        title: Text(tr('add_friend')),
+        content: TextField(
+          controller: nicknameController,
+          decoration: InputDecoration(labelText: tr('friend_nickname')),
+        ),
+        actions: [
+          TextButton(
+            onPressed: () => Navigator.pop(dialogContext),
+            child: Text(tr('cancel')),
+          ),
+          FilledButton(
+            onPressed: () async {
+              final messenger = ScaffoldMessenger.of(context);
+              Navigator.pop(dialogContext);
+              final nickname = nicknameController.text.trim();
+              if (nickname.isEmpty) return;
+              try {
+                await userSvc.addFriend(nickname);
+                if (!mounted) return;
+                _selectScope(LeaderboardScope.friends);
+                messenger.showSnackBar(
+                  SnackBar(content: Text(tr('friend_added'))),
+                );
+              } catch (e) {
+                if (!mounted) return;
+                messenger.showSnackBar(
+                  SnackBar(
+                    content: Text(tr('error_occurred', args: [e.toString()])),
+                  ),
+                );
+              }
+            },
+            child: Text(tr('add')),
+          ),
+        ],
+      ),
+    );
+  }
 
   @override
   Widget build(BuildContext context) {
-    return FutureBuilder<List<LeaderboardEntry>>(
-      future: entries,
-      builder: (context, asyncSnapshot) {
-        if (asyncSnapshot.connectionState == ConnectionState.waiting) {
-          return const Scaffold(
-            body: Center(child: CircularProgressIndicator()),
-          );
-        }
-        if (asyncSnapshot.hasError) {
-          return Scaffold(
-            appBar: AppBar(title: const Text('leaderboard').tr()),
-            body: Center(
-              child: Text(
-                tr('error_occurred', args: [asyncSnapshot.error.toString()]),
-              ),
+    return Scaffold(
+      appBar: AppBar(
+        title: const Text('leaderboard').tr(),
+        actions: [
+          if (scope == LeaderboardScope.friends)
+            IconButton(
+              tooltip: tr('add_friend'),
+              icon: const Icon(Icons.person_add_alt_1),
+              onPressed: _addFriend,
             ),
-          );
-        }
-        final entriesList = asyncSnapshot.data ?? [];
-        return Scaffold(
-          appBar: AppBar(title: const Text('leaderboard').tr()),
-          body: ListView.separated(
-            padding: const EdgeInsets.fromLTRB(16, 16, 16, 110),
-            itemCount: entriesList.length,
-            separatorBuilder: (_, _) => const SizedBox(height: 10),
-            itemBuilder: (context, index) {
-              final e = entriesList[index];
-              return _LeaderboardListTile(
-                rank: e.rank,
-                nickname: e.nickname,
-                score: e.score,
+        ],
+      ),
+      body: Column(
+        children: [
+          Padding(
+            padding: const EdgeInsets.fromLTRB(16, 16, 16, 0),
+            child: SegmentedButton<LeaderboardScope>(
+              segments: [
+                ButtonSegment(
+                  value: LeaderboardScope.global,
+                  label: const Text('leaderboard_global').tr(),
+                ),
+                ButtonSegment(
+                  value: LeaderboardScope.household,
+                  label: const Text('leaderboard_household').tr(),
+                ),
+                ButtonSegment(
+                  value: LeaderboardScope.friends,
+                  label: const Text('leaderboard_friends').tr(),
+                ),
+              ],
+              selected: {scope},
+              onSelectionChanged: (s) => _selectScope(s.first),
+            ),
+          ),
+          Padding(
+            padding: const EdgeInsets.fromLTRB(16, 12, 16, 0),
+            child: SegmentedButton<LeaderboardPeriod>(
+              segments: [
+                ButtonSegment(
+                  value: LeaderboardPeriod.week,
+                  label: const Text('leaderboard_week').tr(),
+                ),
+                ButtonSegment(
+                  value: LeaderboardPeriod.month,
+                  label: const Text('leaderboard_month').tr(),
+                ),
+                ButtonSegment(
+                  value: LeaderboardPeriod.allTime,
+                  label: const Text('leaderboard_all_time').tr(),
+                ),
+              ],
+              selected: {period},
+              onSelectionChanged: (s) => _selectPeriod(s.first),
+            ),
+          ),
+          FutureBuilder<LeaderboardPosition>(
+            future: position,
+            builder: (context, snapshot) {
+              final mine = snapshot.data;
+              if (mine == null) {
+                return const SizedBox.shrink();
+              }
+              return Padding(
+                padding: const EdgeInsets.fromLTRB(16, 12, 16, 0),
+                child: Text(
+                  mine.rank == null
+                      ? tr('not_ranked_yet')
+                      : tr(
+                          'your_position',
+                          args: [mine.rank.toString(), mine.score.toString()],
+                        ),
+                  style: Theme.of(context).textTheme.titleSmall,
+                ),
               );
             },
           ),
-        );
-      },
+          if (archive != null)
+            FutureBuilder<List<LeaderboardArchive>>(
+              future: archive,
+              builder: (context, snapshot) {
+                final last = snapshot.data ?? [];
+                if (last.isEmpty || last.first.winners.isEmpty) {
+                  return const SizedBox.shrink();
+                }
+                return Padding(
+                  padding: const EdgeInsets.fromLTRB(16, 12, 16, 0),
+                  child: Text(
+                    '${tr('last_winners', args: [last.first.key])}: '
+                    '${last.first.winners.map((w) => w.nickname).join(', ')}',
+                    style: Theme.of(context).textTheme.bodyMedium,
+                  ),
+                );
+              },
+            ),
+          Expanded(
+            child: FutureBuilder<List<LeaderboardEntry>>(
+              future: entries,
+              builder: (context, asyncSnapshot) {
+                if (asyncSnapshot.connectionState == ConnectionState.waiting) {
+                  return const Center(child: CircularProgressIndicator());
+                }
+                if (asyncSnapshot.hasError) {
+                  return Center(
+                    child: Text(
+                      tr(
+                        'error_occurred',
+                        args: [asyncSnapshot.error.toString()],
+                      ),
+                    ),
+                  );
+                }
+                final entriesList = asyncSnapshot.data ?? [];
+                return ListView.separated(
+                  padding: const EdgeInsets.fromLTRB(16, 16, 16, 110),
+                  itemCount: entriesList.length,
+                  separatorBuilder: (_, _) => const SizedBox(height: 10),
+                  itemBuilder: (context, index) {
+                    final e = entriesList[index];
+                    return _LeaderboardListTile(
+                      rank: e.rank,
+                      nickname: e.nickname,
+                      score: e.score,
+                    );
+                  },
+                );
+              },
+            ),
+          ),
+        ],
+      ),
     );
   }
 }
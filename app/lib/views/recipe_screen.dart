@@ -149,7 +149,24 @@ class _RecipeScreenState extends ConsumerState<RecipeScreen> {
           prepTimeMinutes: int.tryParse(_prepTimeController.text),
           calories: calories,
         );
-        await _recipeService.updateRecipe(widget.recipeId!, input);
+        final updated = await _recipeService.updateRecipe(
+          widget.recipeId!,
+          input,
+        );
+        if (mounted &&
+            updated.status == RecipeStatus.inPreparation &&
+            updated.dietWarnings.isNotEmpty) {
+          ScaffoldMessenger.of(context).showSnackBar(
+            SnackBar(
+              content: Text(
+                tr(
+                  'diet_warnings',
+                  args: [updated.dietWarnings.join('\n')],
+                ),
+              ),
+            ),
+          );
+        }
       }
       if (mounted) {
         context.pop(true);
@@ -167,6 +184,53 @@ class _RecipeScreenState extends ConsumerState<RecipeScreen> {
     }
   }
 
+  // Links every unlinked ingredient to its best fridge match; the links are
+  // only stored once the user saves the recipe.
+  Future<void> _linkFromFridge() async {
+    setState(() => _isLoading = true);
+    try {
+      final suggestions = await _recipeService.suggestIngredientMatches(
+        widget.recipeId!,
+      );
+      var linked = 0;
+      setState(() {
+        for (final s in suggestions) {
+          if (s.ingredientIndex >= _ingredients.length ||
+              s.currentInventoryItemId != null ||
+              s.candidates.isEmpty) {
+            continue;
+          }
+          final ing = _ingredients[s.ingredientIndex];
+          if (ing.inventoryItemId != null) continue;
+          _ingredients[s.ingredientIndex] = RecipeIngredientInput(
+            name: ing.name,
+            quantity: ing.quantity,
+            unit: ing.unit,
+            inventoryItemId: s.candidates.first.itemId,
+          );
+          linked++;
+        }
+      });
+      if (mounted) {
+        ScaffoldMessenger.of(context).showSnackBar(
+          SnackBar(
+            content: Text(
+              tr('ingredients_linked', args: [linked.toString()]),
+            ),
+          ),
+        );
+      }
+    } catch (e) {
+      if (mounted) {
+        ScaffoldMessenger.of(context).showSnackBar(
+          SnackBar(content: Text(tr('error_occurred', args: [e.toString()]))),
+        );
+      }
+    } finally {
+      if (mounted) setState(() => _isLoading = false);
+    }
+  }
+
   bool _validateQuantities() {
     final usage = <String, double>{};
     for (final ing in _ingredients) {
@@ -476,6 +540,16 @@ class _RecipeScreenState extends ConsumerState<RecipeScreen> {
                 const Divider(),
                 const SizedBox(height: 20),
               ],
+              if (_recipe?.savedFrom != null) ...[
+                Text(
+                  tr(
+                    'saved_from_author',
+                    args: [_recipe!.savedFrom!.authorNickname],
+                  ),
+                  style: Theme.of(context).textTheme.bodySmall,
+                ),
+                const SizedBox(height: 10),
+              ],
               TextFormField(
                 controller: _titleController,
                 decoration: InputDecoration(labelText: tr('title')),
@@ -513,6 +587,19 @@ class _RecipeScreenState extends ConsumerState<RecipeScreen> {
                     ),
                   ),
                 ),
+                if (_status == RecipeStatus.inPreparation &&
+                    _recipe?.ttlSecondsRemaining != null) ...[
+                  const SizedBox(height: 8),
+                  Text(
+                    tr(
+                      'lock_expires_in',
+                      args: [
+                        (_recipe!.ttlSecondsRemaining! ~/ 60).toString(),
+                      ],
+                    ),
+                    style: Theme.of(context).textTheme.bodySmall,
+                  ),
+                ],
                 const SizedBox(height: 20),
                 const SizedBox(height: 20),
               ],
@@ -542,6 +629,14 @@ class _RecipeScreenState extends ConsumerState<RecipeScreen> {
                   icon: const Icon(Icons.add),
                   label: Text(tr('add_ingredient')),
                 ),
+              if (widget.recipeId != null &&
+                  _recipe?.generatedByAI != true &&
+                  _status != RecipeStatus.cooked)
+                TextButton.icon(
+                  onPressed: _linkFromFridge,
+                  icon: const Icon(Icons.link),
+                  label: Text(tr('link_from_fridge')),
+                ),
               const SizedBox(height: 20),
               Text(tr('steps'), style: Theme.of(context).textTheme.titleMedium),
               const SizedBox(height: 8),
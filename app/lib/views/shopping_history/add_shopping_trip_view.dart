@@ -615,9 +615,10 @@ class _AddShoppingTripViewState extends ConsumerState<AddShoppingTripView> {
           var suggestedStores = <String>[];
           if (result.data != null) {
             try {
-              final historyData = result.data!['shoppingHistory'];
+              final historyData = result.data!['shoppingHistory']?['edges'];
               if (historyData is List) {
-                for (var h in historyData) {
+                for (var edge in historyData) {
+                  final h = edge is Map ? edge['node'] : null;
                   if (h is Map && h['storeName'] is String) {
                     suggestedStores.add(h['storeName']);
                   }
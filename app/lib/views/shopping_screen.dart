@@ -338,7 +338,7 @@ class _ShoppingScreenState extends ConsumerState<ShoppingScreen>
         key: ValueKey(ref.watch(shoppingRefreshProvider)),
         options: QueryOptions(
           document: gql(_query),
-          variables: const {'limit': _pageSize, 'offset': 0},
+          variables: const {'first': _pageSize},
           pollInterval: _pollInterval,
         ),
         builder: (QueryResult result, {VoidCallback? refetch, FetchMore? fetchMore}) {
@@ -396,7 +396,11 @@ class _ShoppingScreenState extends ConsumerState<ShoppingScreen>
             return const Center(child: CircularProgressIndicator());
           }
 
-          List allEntries = List.from(result.data?['shoppingHistory'] ?? []);
+          final history = result.data?['shoppingHistory'];
+          final pageInfo = history?['pageInfo'];
+          List allEntries = List.from(
+            (history?['edges'] as List? ?? []).map((e) => e['node']),
+          );
           // Filter out dismissed items
           allEntries = allEntries
               .where((e) => !_dismissedIds.contains(e['id'].toString()))
@@ -489,22 +493,25 @@ class _ShoppingScreenState extends ConsumerState<ShoppingScreen>
               onNotification: (ScrollNotification scrollInfo) {
                 if (scrollInfo.metrics.pixels ==
                     scrollInfo.metrics.maxScrollExtent) {
-                  if (fetchMore != null && !_isFetchingMore) {
+                  if (fetchMore != null &&
+                      !_isFetchingMore &&
+                      pageInfo?['hasNextPage'] == true) {
                     _isFetchingMore = true;
                     fetchMore(
                       FetchMoreOptions(
                         variables: {
-                          'offset': allEntries.length,
-                          'limit': _pageSize,
+                          'after': pageInfo?['endCursor'],
+                          'first': _pageSize,
                         },
                         updateQuery: (previousResultData, fetchMoreResultData) {
-                          final List<dynamic> repos = [
-                            ...previousResultData?['shoppingHistory']
+                          final List<dynamic> edges = [
+                            ...previousResultData?['shoppingHistory']?['edges']
                                 as List<dynamic>,
-                            ...fetchMoreResultData?['shoppingHistory']
+                            ...fetchMoreResultData?['shoppingHistory']?['edges']
                                 as List<dynamic>,
                           ];
-                          fetchMoreResultData?['shoppingHistory'] = repos;
+                          fetchMoreResultData?['shoppingHistory']?['edges'] =
+                              edges;
                           return fetchMoreResultData;
                         },
                       ),
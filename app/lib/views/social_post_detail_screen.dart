@@ -3,6 +3,7 @@ import 'package:flutter/material.dart';
 import 'package:flutter_riverpod/flutter_riverpod.dart';
 import 'package:mocc/models/social_model.dart';
 import 'package:mocc/service/graphql_config.dart';
+import 'package:mocc/service/recipe_service.dart';
 import 'package:mocc/service/social_service.dart';
 import 'package:mocc/service/user_service.dart';
 import 'package:mocc/widgets/post_more_menu.dart';
@@ -29,6 +30,7 @@ class _SocialPostDetailScreenState
   String? _currentUserId;
   final _commentController = TextEditingController();
   bool _submittingComment = false;
+  bool _savingRecipe = false;
   bool _loadingPost = false;
   Object? _postError;
 
@@ -106,6 +108,30 @@ class _SocialPostDetailScreenState
     }
   }
 
+  Future<void> _saveToCookbook() async {
+    if (_post == null) return;
+
+    setState(() => _savingRecipe = true);
+
+    try {
+      final client = ref.read(graphQLClientProvider);
+      await RecipeService(client).saveRecipeFromPost(_post!.id);
+      if (mounted) {
+        ScaffoldMessenger.of(
+          context,
+        ).showSnackBar(SnackBar(content: Text(tr('recipe_saved_to_cookbook'))));
+      }
+    } catch (e) {
+      if (mounted) {
+        ScaffoldMessenger.of(context).showSnackBar(
+          SnackBar(content: Text(tr('error_occurred', args: [e.toString()]))),
+        );
+      }
+    } finally {
+      if (mounted) setState(() => _savingRecipe = false);
+    }
+  }
+
   @override
   Widget build(BuildContext context) {
     if (_loadingPost) {
@@ -132,6 +158,11 @@ class _SocialPostDetailScreenState
       appBar: AppBar(
         title: Text(tr('post_details')),
         actions: [
+          IconButton(
+            tooltip: tr('save_to_cookbook'),
+            onPressed: _savingRecipe ? null : _saveToCookbook,
+            icon: const Icon(Icons.bookmark_add_outlined),
+          ),
           PostMoreMenu(
             post: _post!,
             currentUserId: _currentUserId,
@@ -180,7 +180,11 @@ class GamificationProfileCard extends StatelessWidget {
                     spacing: 8,
                     runSpacing: 8,
                     children: _buildBadges(
-                      badges: profile.badges,
+                      badges: profile.earnedBadges.isNotEmpty
+                          ? profile.earnedBadges
+                                .map((b) => b.badge?.title ?? b.badgeId)
+                                .toList()
+                          : profile.badges,
                       background: layerBg,
                       foreground: onSurface,
                       border: layerStroke,
@@ -32,6 +32,7 @@ class _SocialPostListViewState extends ConsumerState<SocialPostListView>
 
   bool _isFetchingMore = false;
   bool _hasMorePosts = true;
+  String? _feedCursor;
 
   @override
   void initState() {
@@ -61,19 +62,18 @@ class _SocialPostListViewState extends ConsumerState<SocialPostListView>
       final userSvc = UserService(client);
 
       final results = await Future.wait([
-        socialSvc.getFeed(limit: 50),
+        socialSvc.getFeed(first: 50),
         userSvc.getUserId(),
       ]);
 
       if (mounted) {
-        final posts = results[0] as List<Post>;
+        final feed = results[0] as FeedPage;
         setState(() {
-          _allPosts = posts;
+          _allPosts = feed.posts;
+          _feedCursor = feed.endCursor;
           _currentUserId = results[1] as String;
           _loading = false;
-          if (posts.length < 50) {
-            _hasMorePosts = false;
-          }
+          _hasMorePosts = feed.hasNextPage;
         });
         _lastSuccessfulLoadAt = DateTime.now();
       }
@@ -104,21 +104,13 @@ class _SocialPostListViewState extends ConsumerState<SocialPostListView>
       final client = ref.read(graphQLClientProvider);
       final socialSvc = SocialService(client);
 
-      final newPosts = await socialSvc.getFeed(
-        limit: 50,
-        offset: _allPosts.length,
-      );
+      final feed = await socialSvc.getFeed(first: 50, after: _feedCursor);
 
       if (mounted) {
         setState(() {
-          if (newPosts.isEmpty) {
-            _hasMorePosts = false;
-          } else {
-            _allPosts.addAll(newPosts);
-            if (newPosts.length < 50) {
-              _hasMorePosts = false;
-            }
-          }
+          _allPosts.addAll(feed.posts);
+          _feedCursor = feed.endCursor ?? _feedCursor;
+          _hasMorePosts = feed.hasNextPage && feed.posts.isNotEmpty;
         });
       }
     } catch (e) {
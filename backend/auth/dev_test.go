@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDevAuthPicksUser(t *testing.T) {
+	var seen string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = GetUserID(r.Context())
+	})
+
+	cases := []struct {
+		name       string
+		defaultID  string
+		header     string
+		wantUser   string
+		wantStatus int
+	}{
+		{name: "header wins", defaultID: "dev", header: "alice", wantUser: "alice", wantStatus: http.StatusOK},
+		{name: "default without header", defaultID: "dev", wantUser: "dev", wantStatus: http.StatusOK},
+		{name: "nobody", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			seen = ""
+			req := httptest.NewRequest(http.MethodPost, "/query", nil)
+			if tc.header != "" {
+				req.Header.Set(DevUserHeader, tc.header)
+			}
+			rec := httptest.NewRecorder()
+			NewDevAuth(tc.defaultID).Middleware(next).ServeHTTP(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status %d, want %d", rec.Code, tc.wantStatus)
+			}
+			if seen != tc.wantUser {
+				t.Fatalf("user %q, want %q", seen, tc.wantUser)
+			}
+		})
+	}
+}
+
+func TestDevAuthValidateToken(t *testing.T) {
+	ctx := context.Background()
+	if uid, err := NewDevAuth("dev").ValidateToken(ctx, "bob"); err != nil || uid != "bob" {
+		t.Fatalf("token as user: %q, err %v", uid, err)
+	}
+	if uid, err := NewDevAuth("dev").ValidateToken(ctx, ""); err != nil || uid != "dev" {
+		t.Fatalf("empty token: %q, err %v", uid, err)
+	}
+	if _, err := NewDevAuth("").ValidateToken(ctx, ""); err == nil {
+		t.Fatalf("empty token without default user was accepted")
+	}
+}
@@ -24,6 +24,11 @@ func GetUserID(ctx context.Context) string {
 	return val
 }
 
+// WithUserID returns a copy of ctx carrying the authenticated user id.
+func WithUserID(ctx context.Context, oid string) context.Context {
+	return context.WithValue(ctx, userCtxKey, oid)
+}
+
 type EntraConfig struct {
 	TenantID         string
 	ExpectedAudience string
@@ -97,8 +102,7 @@ func (v *EntraValidator) Middleware(next http.Handler) http.Handler {
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), userCtxKey, oid)
-		next.ServeHTTP(w, r.WithContext(ctx))
+		next.ServeHTTP(w, r.WithContext(WithUserID(r.Context(), oid)))
 	})
 }
 
@@ -109,9 +113,27 @@ func (v *EntraValidator) shouldSkip(r *http.Request) bool {
 	if r.Method == http.MethodOptions {
 		return true
 	}
+	// Browsers cannot set headers on websocket upgrades; the token arrives in
+	// the connection_init payload instead and is checked with ValidateToken.
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return true
+	}
 	return false
 }
 
+// ValidateToken checks a raw bearer token like the middleware checks the
+// Authorization header and returns the caller's object id.
+func (v *EntraValidator) ValidateToken(ctx context.Context, raw string) (string, error) {
+	oid, status, ok := v.authorizeToken(ctx, raw)
+	if !ok {
+		if status == http.StatusForbidden {
+			return "", fmt.Errorf("forbidden")
+		}
+		return "", fmt.Errorf("unauthorized")
+	}
+	return oid, nil
+}
+
 // redactedHeaders returns a copy of the given headers with sensitive values redacted.
 func redactedHeaders(h http.Header) map[string][]string {
 	if h == nil {
@@ -138,12 +160,21 @@ func (v *EntraValidator) authorize(r *http.Request) (string, int, bool) {
 		return "", http.StatusUnauthorized, false
 	}
 
+	return v.authorizeToken(r.Context(), raw)
+}
+
+func (v *EntraValidator) authorizeToken(ctx context.Context, raw string) (string, int, bool) {
+	if raw == "" {
+		log.Printf("auth: missing token")
+		return "", http.StatusUnauthorized, false
+	}
+
 	algStr, kid, kidTag, status, ok := v.parseAndValidateHeader(raw)
 	if !ok {
 		return "", status, false
 	}
 
-	key, ok := v.lookupKey(r.Context(), kid, kidTag)
+	key, ok := v.lookupKey(ctx, kid, kidTag)
 	if !ok {
 		return "", http.StatusUnauthorized, false
 	}
@@ -10,14 +10,16 @@ import (
 )
 
 type AddInventoryItemInput struct {
-	Name       string         `json:"name"`
-	Brand      *string        `json:"brand,omitempty"`
-	Category   *string        `json:"category,omitempty"`
-	Quantity   *QuantityInput `json:"quantity"`
-	Price      *float64       `json:"price,omitempty"`
-	Status     *ItemStatus    `json:"status,omitempty"`
-	ExpiryDate string         `json:"expiryDate"`
-	ExpiryType ExpiryType     `json:"expiryType"`
+	Name              string         `json:"name"`
+	Brand             *string        `json:"brand,omitempty"`
+	Category          *string        `json:"category,omitempty"`
+	Quantity          *QuantityInput `json:"quantity"`
+	Price             *float64       `json:"price,omitempty"`
+	Status            *ItemStatus    `json:"status,omitempty"`
+	ExpiryDate        string         `json:"expiryDate"`
+	ExpiryType        ExpiryType     `json:"expiryType"`
+	PieceWeightGrams  *float64       `json:"pieceWeightGrams,omitempty"`
+	DensityGramsPerMl *float64       `json:"densityGramsPerMl,omitempty"`
 }
 
 type AddShoppingHistoryInput struct {
@@ -30,6 +32,22 @@ type AddShoppingHistoryInput struct {
 	Status          *ShoppingHistoryStatus      `json:"status,omitempty"`
 }
 
+type Badge struct {
+	ID          string         `json:"id"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Criterion   BadgeCriterion `json:"criterion"`
+	Threshold   int32          `json:"threshold"`
+}
+
+type CheckoutShoppingListInput struct {
+	Date           *string  `json:"date,omitempty"`
+	StoreName      *string  `json:"storeName,omitempty"`
+	TotalAmount    *float64 `json:"totalAmount,omitempty"`
+	ImportToFridge *bool    `json:"importToFridge,omitempty"`
+	OperationID    *string  `json:"operationId,omitempty"`
+}
+
 type Comment struct {
 	ID           string `json:"id"`
 	UserID       string `json:"userId"`
@@ -39,6 +57,16 @@ type Comment struct {
 	Removed      bool   `json:"removed"`
 }
 
+type CookableRecipe struct {
+	Recipe             *Recipe              `json:"recipe,omitempty"`
+	Post               *Post                `json:"post,omitempty"`
+	Title              string               `json:"title"`
+	Score              float64              `json:"score"`
+	Coverage           float64              `json:"coverage"`
+	ExpiringItemsUsed  int32                `json:"expiringItemsUsed"`
+	MissingIngredients []*MissingIngredient `json:"missingIngredients"`
+}
+
 type CreatePostInput struct {
 	RecipeID string  `json:"recipeId"`
 	Caption  *string `json:"caption,omitempty"`
@@ -53,21 +81,45 @@ type CreateRecipeInput struct {
 	PrepTimeMinutes *int32                   `json:"prepTimeMinutes,omitempty"`
 	Calories        *int32                   `json:"calories,omitempty"`
 	EcoPointsReward *int32                   `json:"ecoPointsReward,omitempty"`
+	Servings        *int32                   `json:"servings,omitempty"`
+}
+
+type EarnedBadge struct {
+	BadgeID  string `json:"badgeId"`
+	EarnedAt string `json:"earnedAt"`
+	Badge    *Badge `json:"-"`
 }
 
 type Fridge struct {
 	ID      string           `json:"id"`
 	Name    string           `json:"name"`
+	Kind    StorageKind      `json:"kind"`
 	OwnerID []string         `json:"ownerId,omitempty"`
 	Items   []*InventoryItem `json:"items,omitempty"`
+	Members []*FridgeMember  `json:"members"`
+	MyRole  FridgeRole       `json:"-"`
+}
+
+type FridgeMember struct {
+	UserID   string     `json:"userId"`
+	Nickname *string    `json:"nickname,omitempty"`
+	Role     FridgeRole `json:"role"`
+	JoinedAt *string    `json:"joinedAt,omitempty"`
 }
 
 type GamificationProfile struct {
-	TotalEcoPoints     int32    `json:"totalEcoPoints"`
-	CurrentLevel       int32    `json:"currentLevel"`
-	NextLevelThreshold int32    `json:"nextLevelThreshold"`
-	Badges             []string `json:"badges,omitempty"`
-	WastedMoneyYtd     *float64 `json:"wastedMoneyYTD,omitempty"`
+	TotalEcoPoints     int32          `json:"totalEcoPoints"`
+	CurrentLevel       int32          `json:"currentLevel"`
+	NextLevelThreshold int32          `json:"nextLevelThreshold"`
+	Badges             []string       `json:"badges,omitempty"`
+	EarnedBadges       []*EarnedBadge `json:"earnedBadges,omitempty"`
+	BadgesTrackedSince *string        `json:"badgesTrackedSince,omitempty"`
+	WastedMoneyYtd     *float64       `json:"wastedMoneyYTD,omitempty"`
+}
+
+type GeneratedShoppingList struct {
+	Groups []*ShoppingListGroup  `json:"groups"`
+	Entry  *ShoppingHistoryEntry `json:"entry,omitempty"`
 }
 
 type HistoryItem struct {
@@ -83,19 +135,69 @@ type HistoryItem struct {
 	Confidence *float64    `json:"confidence,omitempty"`
 }
 
+type IngredientMatchCandidate struct {
+	Item       *InventoryItem `json:"item"`
+	Score      float64        `json:"score"`
+	Sufficient bool           `json:"sufficient"`
+}
+
+type IngredientMatchSuggestion struct {
+	IngredientIndex        int32                       `json:"ingredientIndex"`
+	IngredientName         string                      `json:"ingredientName"`
+	CurrentInventoryItemID *string                     `json:"currentInventoryItemId,omitempty"`
+	Candidates             []*IngredientMatchCandidate `json:"candidates"`
+}
+
+type InventoryEvent struct {
+	ID             string             `json:"id"`
+	FridgeID       string             `json:"fridgeId"`
+	ItemID         string             `json:"itemId"`
+	ItemName       string             `json:"itemName"`
+	Type           InventoryEventType `json:"type"`
+	ActorID        string             `json:"actorId"`
+	ActorNickname  *string            `json:"actorNickname,omitempty"`
+	Amount         *float64           `json:"amount,omitempty"`
+	Unit           *Unit              `json:"unit,omitempty"`
+	QuantityBefore *float64           `json:"quantityBefore,omitempty"`
+	QuantityAfter  *float64           `json:"quantityAfter,omitempty"`
+	RecipeID       *string            `json:"recipeId,omitempty"`
+	OperationID    *string            `json:"operationId,omitempty"`
+	OccurredAt     string             `json:"occurredAt"`
+}
+
+type InventoryEventConnection struct {
+	Edges    []*InventoryEventEdge `json:"edges"`
+	PageInfo *PageInfo             `json:"pageInfo"`
+}
+
+type InventoryEventEdge struct {
+	Cursor string          `json:"cursor"`
+	Node   *InventoryEvent `json:"node"`
+}
+
 type InventoryItem struct {
-	ID               string         `json:"id"`
-	Name             string         `json:"name"`
-	Brand            *string        `json:"brand,omitempty"`
-	Category         *string        `json:"category,omitempty"`
-	Quantity         *Quantity      `json:"quantity"`
-	Price            *float64       `json:"price,omitempty"`
-	Status           ItemStatus     `json:"status"`
-	VirtualAvailable float64        `json:"virtualAvailable"`
-	ExpiryDate       string         `json:"expiryDate"`
-	ExpiryType       ExpiryType     `json:"expiryType"`
-	AddedAt          string         `json:"addedAt"`
-	ActiveLocks      []*ProductLock `json:"activeLocks,omitempty"`
+	ID                string         `json:"id"`
+	Name              string         `json:"name"`
+	Brand             *string        `json:"brand,omitempty"`
+	Category          *string        `json:"category,omitempty"`
+	Quantity          *Quantity      `json:"quantity"`
+	Price             *float64       `json:"price,omitempty"`
+	Status            ItemStatus     `json:"status"`
+	VirtualAvailable  float64        `json:"virtualAvailable"`
+	ExpiryDate        string         `json:"expiryDate"`
+	ExpiryType        ExpiryType     `json:"expiryType"`
+	AddedAt           string         `json:"addedAt"`
+	ActiveLocks       []*ProductLock `json:"activeLocks,omitempty"`
+	PieceWeightGrams  *float64       `json:"pieceWeightGrams,omitempty"`
+	DensityGramsPerMl *float64       `json:"densityGramsPerMl,omitempty"`
+}
+
+type LeaderboardArchive struct {
+	Period    LeaderboardPeriod   `json:"period"`
+	Key       string              `json:"key"`
+	ClosedAt  string              `json:"closedAt"`
+	Standings []*LeaderboardEntry `json:"standings"`
+	Winners   []*LeaderboardEntry `json:"winners"`
 }
 
 type LeaderboardEntry struct {
@@ -104,9 +206,74 @@ type LeaderboardEntry struct {
 	Score    int32  `json:"score"`
 }
 
+type LeaderboardPosition struct {
+	Rank  *int32              `json:"rank,omitempty"`
+	Score int32               `json:"score"`
+	Above []*LeaderboardEntry `json:"above"`
+	Below []*LeaderboardEntry `json:"below"`
+}
+
+type MealPlan struct {
+	ID        string      `json:"id"`
+	FridgeID  string      `json:"fridgeId"`
+	WeekStart string      `json:"weekStart"`
+	Slots     []*MealSlot `json:"slots"`
+	UpdatedAt *string     `json:"updatedAt,omitempty"`
+}
+
+type MealSlot struct {
+	ID          string   `json:"id"`
+	Date        string   `json:"date"`
+	MealType    MealType `json:"mealType"`
+	RecipeID    string   `json:"recipeId"`
+	Recipe      *Recipe  `json:"-"`
+	Portions    int32    `json:"portions"`
+	ScheduledBy string   `json:"scheduledBy"`
+	Reserved    bool     `json:"reserved"`
+	CookedAt    *string  `json:"cookedAt,omitempty"`
+	Warnings    []string `json:"-"`
+}
+
+type MissingIngredient struct {
+	Name     string  `json:"name"`
+	Quantity float64 `json:"quantity"`
+	Unit     Unit    `json:"unit"`
+}
+
 type Mutation struct {
 }
 
+type Notification struct {
+	ID        string           `json:"id"`
+	Type      NotificationType `json:"type"`
+	Message   string           `json:"message"`
+	RefID     *string          `json:"refId,omitempty"`
+	CreatedAt string           `json:"createdAt"`
+}
+
+type PageInfo struct {
+	HasNextPage bool    `json:"hasNextPage"`
+	EndCursor   *string `json:"endCursor,omitempty"`
+}
+
+type PointsEntry struct {
+	ID        string       `json:"id"`
+	Points    int32        `json:"points"`
+	Reason    PointsReason `json:"reason"`
+	SourceID  *string      `json:"sourceId,omitempty"`
+	CreatedAt string       `json:"createdAt"`
+}
+
+type PointsEntryConnection struct {
+	Edges    []*PointsEntryEdge `json:"edges"`
+	PageInfo *PageInfo          `json:"pageInfo"`
+}
+
+type PointsEntryEdge struct {
+	Cursor string       `json:"cursor"`
+	Node   *PointsEntry `json:"node"`
+}
+
 type Post struct {
 	ID             string          `json:"id"`
 	AuthorID       string          `json:"authorId"`
@@ -120,6 +287,16 @@ type Post struct {
 	Comments       []*Comment      `json:"comments"`
 }
 
+type PostConnection struct {
+	Edges    []*PostEdge `json:"edges"`
+	PageInfo *PageInfo   `json:"pageInfo"`
+}
+
+type PostEdge struct {
+	Cursor string `json:"cursor"`
+	Node   *Post  `json:"node"`
+}
+
 type ProductLock struct {
 	RecipeID  string  `json:"recipeId"`
 	Amount    float64 `json:"amount"`
@@ -140,19 +317,36 @@ type Query struct {
 }
 
 type Recipe struct {
-	ID                  string              `json:"id"`
-	AuthorID            string              `json:"authorId"`
-	Title               string              `json:"title"`
-	Description         string              `json:"description"`
-	Status              RecipeStatus        `json:"status"`
-	Ingredients         []*RecipeIngredient `json:"ingredients,omitempty"`
-	CookedItems         []*RecipeCookedItem `json:"cookedItems,omitempty"`
-	Steps               []string            `json:"steps,omitempty"`
-	PrepTimeMinutes     *int32              `json:"prepTimeMinutes,omitempty"`
-	Calories            *int32              `json:"calories,omitempty"`
-	EcoPointsReward     *int32              `json:"ecoPointsReward,omitempty"`
-	TTLSecondsRemaining *int32              `json:"ttlSecondsRemaining,omitempty"`
-	GeneratedByAi       bool                `json:"generatedByAI"`
+	ID                   string              `json:"id"`
+	AuthorID             string              `json:"authorId"`
+	Title                string              `json:"title"`
+	Description          string              `json:"description"`
+	Status               RecipeStatus        `json:"status"`
+	Ingredients          []*RecipeIngredient `json:"ingredients,omitempty"`
+	CookedItems          []*RecipeCookedItem `json:"cookedItems,omitempty"`
+	Steps                []string            `json:"steps,omitempty"`
+	PrepTimeMinutes      *int32              `json:"prepTimeMinutes,omitempty"`
+	Calories             *int32              `json:"calories,omitempty"`
+	EcoPointsReward      *int32              `json:"ecoPointsReward,omitempty"`
+	TTLSecondsRemaining  *int32              `json:"ttlSecondsRemaining,omitempty"`
+	GeneratedByAi        bool                `json:"generatedByAI"`
+	SavedFrom            *RecipeAttribution  `json:"savedFrom,omitempty"`
+	PreparationStartedAt *string             `json:"preparationStartedAt,omitempty"`
+	Servings             *int32              `json:"servings,omitempty"`
+	PreparationPortions  *int32              `json:"preparationPortions,omitempty"`
+	DietTags             []DietTag           `json:"-"`
+	DietWarnings         []string            `json:"-"`
+}
+
+type RecipeAttribution struct {
+	PostID         string `json:"postId"`
+	AuthorID       string `json:"authorId"`
+	AuthorNickname string `json:"authorNickname"`
+}
+
+type RecipeConnection struct {
+	Edges    []*RecipeEdge `json:"edges"`
+	PageInfo *PageInfo     `json:"pageInfo"`
 }
 
 type RecipeCookedItem struct {
@@ -166,6 +360,11 @@ type RecipeCookedItem struct {
 	OriginalInventoryID *string   `json:"originalInventoryId,omitempty"`
 }
 
+type RecipeEdge struct {
+	Cursor string  `json:"cursor"`
+	Node   *Recipe `json:"node"`
+}
+
 type RecipeIngredient struct {
 	Name                string         `json:"name"`
 	Quantity            float64        `json:"quantity"`
@@ -196,11 +395,33 @@ type RecipeSnapshot struct {
 	PrepTimeMinutes *int32                      `json:"prepTimeMinutes,omitempty"`
 	Calories        *int32                      `json:"calories,omitempty"`
 	EcoPointsReward *int32                      `json:"ecoPointsReward,omitempty"`
+	Servings        *int32                      `json:"servings,omitempty"`
+	DietTags        []DietTag                   `json:"-"`
+}
+
+type ScheduleMealInput struct {
+	Date     string   `json:"date"`
+	MealType MealType `json:"mealType"`
+	RecipeID string   `json:"recipeId"`
+	Portions *int32   `json:"portions,omitempty"`
 }
 
 type SharedFridgeLink struct {
-	AuthorID   string `json:"authorId"`
-	InviteCode string `json:"inviteCode"`
+	AuthorID   string     `json:"authorId"`
+	InviteCode string     `json:"inviteCode"`
+	FridgeID   string     `json:"fridgeId"`
+	Role       FridgeRole `json:"role"`
+	ExpiresAt  string     `json:"expiresAt"`
+}
+
+type ShoppingHistoryConnection struct {
+	Edges    []*ShoppingHistoryEdge `json:"edges"`
+	PageInfo *PageInfo              `json:"pageInfo"`
+}
+
+type ShoppingHistoryEdge struct {
+	Cursor string                `json:"cursor"`
+	Node   *ShoppingHistoryEntry `json:"node"`
 }
 
 type ShoppingHistoryEntry struct {
@@ -229,15 +450,50 @@ type ShoppingHistoryItemInput struct {
 	Confidence *float64    `json:"confidence,omitempty"`
 }
 
+type ShoppingList struct {
+	ID        string              `json:"id"`
+	FridgeID  string              `json:"fridgeId"`
+	Items     []*ShoppingListItem `json:"items"`
+	UpdatedAt *string             `json:"updatedAt,omitempty"`
+}
+
+type ShoppingListGroup struct {
+	Category *string              `json:"category,omitempty"`
+	Items    []*MissingIngredient `json:"items"`
+}
+
+type ShoppingListItem struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Quantity *float64 `json:"quantity,omitempty"`
+	Unit     *Unit    `json:"unit,omitempty"`
+	Category *string  `json:"category,omitempty"`
+	Checked  bool     `json:"checked"`
+	AddedBy  string   `json:"addedBy"`
+	AddedAt  string   `json:"addedAt"`
+}
+
+type ShoppingListItemInput struct {
+	Name     string   `json:"name"`
+	Quantity *float64 `json:"quantity,omitempty"`
+	Unit     *Unit    `json:"unit,omitempty"`
+	Category *string  `json:"category,omitempty"`
+}
+
+type Subscription struct {
+}
+
 type UpdateInventoryItemInput struct {
-	Name       *string        `json:"name,omitempty"`
-	Brand      *string        `json:"brand,omitempty"`
-	Category   *string        `json:"category,omitempty"`
-	Quantity   *QuantityInput `json:"quantity,omitempty"`
-	Price      *float64       `json:"price,omitempty"`
-	Status     *ItemStatus    `json:"status,omitempty"`
-	ExpiryDate *string        `json:"expiryDate,omitempty"`
-	ExpiryType *ExpiryType    `json:"expiryType,omitempty"`
+	Name              *string        `json:"name,omitempty"`
+	Brand             *string        `json:"brand,omitempty"`
+	Category          *string        `json:"category,omitempty"`
+	Quantity          *QuantityInput `json:"quantity,omitempty"`
+	Price             *float64       `json:"price,omitempty"`
+	Status            *ItemStatus    `json:"status,omitempty"`
+	ExpiryDate        *string        `json:"expiryDate,omitempty"`
+	ExpiryType        *ExpiryType    `json:"expiryType,omitempty"`
+	PieceWeightGrams  *float64       `json:"pieceWeightGrams,omitempty"`
+	DensityGramsPerMl *float64       `json:"densityGramsPerMl,omitempty"`
 }
 
 type UpdateRecipeInput struct {
@@ -248,6 +504,7 @@ type UpdateRecipeInput struct {
 	Steps           []string                 `json:"steps,omitempty"`
 	PrepTimeMinutes *int32                   `json:"prepTimeMinutes,omitempty"`
 	Calories        *int32                   `json:"calories,omitempty"`
+	Servings        *int32                   `json:"servings,omitempty"`
 }
 
 type UpdateShoppingHistoryInput struct {
@@ -260,6 +517,14 @@ type UpdateShoppingHistoryInput struct {
 	Status          *ShoppingHistoryStatus      `json:"status,omitempty"`
 }
 
+type UpdateShoppingListItemInput struct {
+	Name     *string  `json:"name,omitempty"`
+	Quantity *float64 `json:"quantity,omitempty"`
+	Unit     *Unit    `json:"unit,omitempty"`
+	Category *string  `json:"category,omitempty"`
+	Checked  *bool    `json:"checked,omitempty"`
+}
+
 type User struct {
 	ID           string               `json:"id"`
 	Email        string               `json:"email"`
@@ -268,18 +533,52 @@ type User struct {
 	Origin       AccountOrigin        `json:"origin"`
 	Gamification *GamificationProfile `json:"gamification"`
 	Preferences  *UserPreferences     `json:"preferences"`
+	FriendIds    []string             `json:"friendIds,omitempty"`
 }
 
 type UserPreferences struct {
 	DietaryRestrictions []string `json:"dietaryRestrictions,omitempty"`
 	DefaultPortions     *int32   `json:"defaultPortions,omitempty"`
 	Currency            Currency `json:"currency"`
+	ActiveFridgeID      *string  `json:"activeFridgeId,omitempty"`
 }
 
 type UserPreferencesInput struct {
 	DietaryRestrictions []string  `json:"dietaryRestrictions,omitempty"`
 	DefaultPortions     *int32    `json:"defaultPortions,omitempty"`
 	Currency            *Currency `json:"currency,omitempty"`
+	ActiveFridgeID      *string   `json:"activeFridgeId,omitempty"`
+}
+
+type WasteEvent struct {
+	ID       string   `json:"id"`
+	FridgeID string   `json:"fridgeId"`
+	ItemID   string   `json:"itemId"`
+	ItemName string   `json:"itemName"`
+	Category *string  `json:"category,omitempty"`
+	Amount   float64  `json:"amount"`
+	Unit     Unit     `json:"unit"`
+	Cost     float64  `json:"cost"`
+	Currency Currency `json:"currency"`
+	Reason   *string  `json:"reason,omitempty"`
+	WastedAt string   `json:"wastedAt"`
+}
+
+type WasteReport struct {
+	From        string               `json:"from"`
+	To          string               `json:"to"`
+	Currency    Currency             `json:"currency"`
+	TotalCost   float64              `json:"totalCost"`
+	TotalEvents int32                `json:"totalEvents"`
+	ByCategory  []*WasteReportBucket `json:"byCategory"`
+	ByReason    []*WasteReportBucket `json:"byReason"`
+	Events      []*WasteEvent        `json:"events"`
+}
+
+type WasteReportBucket struct {
+	Key    string  `json:"key"`
+	Events int32   `json:"events"`
+	Cost   float64 `json:"cost"`
 }
 
 type AccountOrigin string
@@ -339,6 +638,67 @@ func (e AccountOrigin) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+type BadgeCriterion string
+
+const (
+	BadgeCriterionRecipesCooked BadgeCriterion = "RECIPES_COOKED"
+	BadgeCriterionPostsShared   BadgeCriterion = "POSTS_SHARED"
+	BadgeCriterionLikesReceived BadgeCriterion = "LIKES_RECEIVED"
+	BadgeCriterionZeroWasteDays BadgeCriterion = "ZERO_WASTE_DAYS"
+	BadgeCriterionFridgesShared BadgeCriterion = "FRIDGES_SHARED"
+)
+
+var AllBadgeCriterion = []BadgeCriterion{
+	BadgeCriterionRecipesCooked,
+	BadgeCriterionPostsShared,
+	BadgeCriterionLikesReceived,
+	BadgeCriterionZeroWasteDays,
+	BadgeCriterionFridgesShared,
+}
+
+func (e BadgeCriterion) IsValid() bool {
+	switch e {
+	case BadgeCriterionRecipesCooked, BadgeCriterionPostsShared, BadgeCriterionLikesReceived, BadgeCriterionZeroWasteDays, BadgeCriterionFridgesShared:
+		return true
+	}
+	return false
+}
+
+func (e BadgeCriterion) String() string {
+	return string(e)
+}
+
+func (e *BadgeCriterion) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = BadgeCriterion(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid BadgeCriterion", str)
+	}
+	return nil
+}
+
+func (e BadgeCriterion) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *BadgeCriterion) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e BadgeCriterion) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
 type Currency string
 
 const (
@@ -394,6 +754,83 @@ func (e Currency) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+type DietTag string
+
+const (
+	DietTagGluten    DietTag = "GLUTEN"
+	DietTagLactose   DietTag = "LACTOSE"
+	DietTagEggs      DietTag = "EGGS"
+	DietTagNuts      DietTag = "NUTS"
+	DietTagPeanuts   DietTag = "PEANUTS"
+	DietTagSoy       DietTag = "SOY"
+	DietTagSesame    DietTag = "SESAME"
+	DietTagFish      DietTag = "FISH"
+	DietTagShellfish DietTag = "SHELLFISH"
+	DietTagMeat      DietTag = "MEAT"
+	DietTagPork      DietTag = "PORK"
+	DietTagAlcohol   DietTag = "ALCOHOL"
+	DietTagHoney     DietTag = "HONEY"
+)
+
+var AllDietTag = []DietTag{
+	DietTagGluten,
+	DietTagLactose,
+	DietTagEggs,
+	DietTagNuts,
+	DietTagPeanuts,
+	DietTagSoy,
+	DietTagSesame,
+	DietTagFish,
+	DietTagShellfish,
+	DietTagMeat,
+	DietTagPork,
+	DietTagAlcohol,
+	DietTagHoney,
+}
+
+func (e DietTag) IsValid() bool {
+	switch e {
+	case DietTagGluten, DietTagLactose, DietTagEggs, DietTagNuts, DietTagPeanuts, DietTagSoy, DietTagSesame, DietTagFish, DietTagShellfish, DietTagMeat, DietTagPork, DietTagAlcohol, DietTagHoney:
+		return true
+	}
+	return false
+}
+
+func (e DietTag) String() string {
+	return string(e)
+}
+
+func (e *DietTag) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = DietTag(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid DietTag", str)
+	}
+	return nil
+}
+
+func (e DietTag) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *DietTag) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e DietTag) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
 type ExpiryType string
 
 const (
@@ -449,6 +886,136 @@ func (e ExpiryType) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+type FridgeRole string
+
+const (
+	FridgeRoleOwner  FridgeRole = "OWNER"
+	FridgeRoleEditor FridgeRole = "EDITOR"
+	FridgeRoleViewer FridgeRole = "VIEWER"
+)
+
+var AllFridgeRole = []FridgeRole{
+	FridgeRoleOwner,
+	FridgeRoleEditor,
+	FridgeRoleViewer,
+}
+
+func (e FridgeRole) IsValid() bool {
+	switch e {
+	case FridgeRoleOwner, FridgeRoleEditor, FridgeRoleViewer:
+		return true
+	}
+	return false
+}
+
+func (e FridgeRole) String() string {
+	return string(e)
+}
+
+func (e *FridgeRole) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = FridgeRole(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid FridgeRole", str)
+	}
+	return nil
+}
+
+func (e FridgeRole) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *FridgeRole) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e FridgeRole) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type InventoryEventType string
+
+const (
+	InventoryEventTypeAdded    InventoryEventType = "ADDED"
+	InventoryEventTypeUpdated  InventoryEventType = "UPDATED"
+	InventoryEventTypeConsumed InventoryEventType = "CONSUMED"
+	InventoryEventTypeWasted   InventoryEventType = "WASTED"
+	InventoryEventTypeCooked   InventoryEventType = "COOKED"
+	InventoryEventTypeImported InventoryEventType = "IMPORTED"
+	InventoryEventTypeLocked   InventoryEventType = "LOCKED"
+	InventoryEventTypeUnlocked InventoryEventType = "UNLOCKED"
+	InventoryEventTypeDeleted  InventoryEventType = "DELETED"
+	InventoryEventTypeRestored InventoryEventType = "RESTORED"
+	InventoryEventTypeMoved    InventoryEventType = "MOVED"
+)
+
+var AllInventoryEventType = []InventoryEventType{
+	InventoryEventTypeAdded,
+	InventoryEventTypeUpdated,
+	InventoryEventTypeConsumed,
+	InventoryEventTypeWasted,
+	InventoryEventTypeCooked,
+	InventoryEventTypeImported,
+	InventoryEventTypeLocked,
+	InventoryEventTypeUnlocked,
+	InventoryEventTypeDeleted,
+	InventoryEventTypeRestored,
+	InventoryEventTypeMoved,
+}
+
+func (e InventoryEventType) IsValid() bool {
+	switch e {
+	case InventoryEventTypeAdded, InventoryEventTypeUpdated, InventoryEventTypeConsumed, InventoryEventTypeWasted, InventoryEventTypeCooked, InventoryEventTypeImported, InventoryEventTypeLocked, InventoryEventTypeUnlocked, InventoryEventTypeDeleted, InventoryEventTypeRestored, InventoryEventTypeMoved:
+		return true
+	}
+	return false
+}
+
+func (e InventoryEventType) String() string {
+	return string(e)
+}
+
+func (e *InventoryEventType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = InventoryEventType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid InventoryEventType", str)
+	}
+	return nil
+}
+
+func (e InventoryEventType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *InventoryEventType) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e InventoryEventType) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
 type ItemStatus string
 
 const (
@@ -508,6 +1075,354 @@ func (e ItemStatus) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+type LeaderboardPeriod string
+
+const (
+	LeaderboardPeriodWeek    LeaderboardPeriod = "WEEK"
+	LeaderboardPeriodMonth   LeaderboardPeriod = "MONTH"
+	LeaderboardPeriodAllTime LeaderboardPeriod = "ALL_TIME"
+)
+
+var AllLeaderboardPeriod = []LeaderboardPeriod{
+	LeaderboardPeriodWeek,
+	LeaderboardPeriodMonth,
+	LeaderboardPeriodAllTime,
+}
+
+func (e LeaderboardPeriod) IsValid() bool {
+	switch e {
+	case LeaderboardPeriodWeek, LeaderboardPeriodMonth, LeaderboardPeriodAllTime:
+		return true
+	}
+	return false
+}
+
+func (e LeaderboardPeriod) String() string {
+	return string(e)
+}
+
+func (e *LeaderboardPeriod) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = LeaderboardPeriod(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid LeaderboardPeriod", str)
+	}
+	return nil
+}
+
+func (e LeaderboardPeriod) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *LeaderboardPeriod) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e LeaderboardPeriod) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type LeaderboardScope string
+
+const (
+	LeaderboardScopeGlobal    LeaderboardScope = "GLOBAL"
+	LeaderboardScopeHousehold LeaderboardScope = "HOUSEHOLD"
+	LeaderboardScopeFriends   LeaderboardScope = "FRIENDS"
+)
+
+var AllLeaderboardScope = []LeaderboardScope{
+	LeaderboardScopeGlobal,
+	LeaderboardScopeHousehold,
+	LeaderboardScopeFriends,
+}
+
+func (e LeaderboardScope) IsValid() bool {
+	switch e {
+	case LeaderboardScopeGlobal, LeaderboardScopeHousehold, LeaderboardScopeFriends:
+		return true
+	}
+	return false
+}
+
+func (e LeaderboardScope) String() string {
+	return string(e)
+}
+
+func (e *LeaderboardScope) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = LeaderboardScope(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid LeaderboardScope", str)
+	}
+	return nil
+}
+
+func (e LeaderboardScope) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *LeaderboardScope) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e LeaderboardScope) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type MealType string
+
+const (
+	MealTypeBreakfast MealType = "BREAKFAST"
+	MealTypeLunch     MealType = "LUNCH"
+	MealTypeDinner    MealType = "DINNER"
+	MealTypeSnack     MealType = "SNACK"
+)
+
+var AllMealType = []MealType{
+	MealTypeBreakfast,
+	MealTypeLunch,
+	MealTypeDinner,
+	MealTypeSnack,
+}
+
+func (e MealType) IsValid() bool {
+	switch e {
+	case MealTypeBreakfast, MealTypeLunch, MealTypeDinner, MealTypeSnack:
+		return true
+	}
+	return false
+}
+
+func (e MealType) String() string {
+	return string(e)
+}
+
+func (e *MealType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = MealType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid MealType", str)
+	}
+	return nil
+}
+
+func (e MealType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *MealType) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e MealType) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type NotificationType string
+
+const (
+	NotificationTypePostLiked     NotificationType = "POST_LIKED"
+	NotificationTypePostCommented NotificationType = "POST_COMMENTED"
+	NotificationTypeFridgeShared  NotificationType = "FRIDGE_SHARED"
+	NotificationTypeRecipeExpired NotificationType = "RECIPE_EXPIRED"
+	NotificationTypeBadgeUnlocked NotificationType = "BADGE_UNLOCKED"
+)
+
+var AllNotificationType = []NotificationType{
+	NotificationTypePostLiked,
+	NotificationTypePostCommented,
+	NotificationTypeFridgeShared,
+	NotificationTypeRecipeExpired,
+	NotificationTypeBadgeUnlocked,
+}
+
+func (e NotificationType) IsValid() bool {
+	switch e {
+	case NotificationTypePostLiked, NotificationTypePostCommented, NotificationTypeFridgeShared, NotificationTypeRecipeExpired, NotificationTypeBadgeUnlocked:
+		return true
+	}
+	return false
+}
+
+func (e NotificationType) String() string {
+	return string(e)
+}
+
+func (e *NotificationType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = NotificationType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid NotificationType", str)
+	}
+	return nil
+}
+
+func (e NotificationType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *NotificationType) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e NotificationType) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type PointsReason string
+
+const (
+	PointsReasonOpeningBalance PointsReason = "OPENING_BALANCE"
+	PointsReasonRecipeCooked   PointsReason = "RECIPE_COOKED"
+	PointsReasonPostShared     PointsReason = "POST_SHARED"
+	PointsReasonPostDeleted    PointsReason = "POST_DELETED"
+)
+
+var AllPointsReason = []PointsReason{
+	PointsReasonOpeningBalance,
+	PointsReasonRecipeCooked,
+	PointsReasonPostShared,
+	PointsReasonPostDeleted,
+}
+
+func (e PointsReason) IsValid() bool {
+	switch e {
+	case PointsReasonOpeningBalance, PointsReasonRecipeCooked, PointsReasonPostShared, PointsReasonPostDeleted:
+		return true
+	}
+	return false
+}
+
+func (e PointsReason) String() string {
+	return string(e)
+}
+
+func (e *PointsReason) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PointsReason(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PointsReason", str)
+	}
+	return nil
+}
+
+func (e PointsReason) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *PointsReason) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e PointsReason) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type RecipeSource string
+
+const (
+	RecipeSourceMine RecipeSource = "MINE"
+	RecipeSourceFeed RecipeSource = "FEED"
+)
+
+var AllRecipeSource = []RecipeSource{
+	RecipeSourceMine,
+	RecipeSourceFeed,
+}
+
+func (e RecipeSource) IsValid() bool {
+	switch e {
+	case RecipeSourceMine, RecipeSourceFeed:
+		return true
+	}
+	return false
+}
+
+func (e RecipeSource) String() string {
+	return string(e)
+}
+
+func (e *RecipeSource) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = RecipeSource(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid RecipeSource", str)
+	}
+	return nil
+}
+
+func (e RecipeSource) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *RecipeSource) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e RecipeSource) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
 type RecipeStatus string
 
 const (
@@ -624,6 +1539,63 @@ func (e ShoppingHistoryStatus) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+type StorageKind string
+
+const (
+	StorageKindFridge  StorageKind = "FRIDGE"
+	StorageKindFreezer StorageKind = "FREEZER"
+	StorageKindPantry  StorageKind = "PANTRY"
+)
+
+var AllStorageKind = []StorageKind{
+	StorageKindFridge,
+	StorageKindFreezer,
+	StorageKindPantry,
+}
+
+func (e StorageKind) IsValid() bool {
+	switch e {
+	case StorageKindFridge, StorageKindFreezer, StorageKindPantry:
+		return true
+	}
+	return false
+}
+
+func (e StorageKind) String() string {
+	return string(e)
+}
+
+func (e *StorageKind) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = StorageKind(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid StorageKind", str)
+	}
+	return nil
+}
+
+func (e StorageKind) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *StorageKind) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e StorageKind) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
 type Unit string
 
 const (
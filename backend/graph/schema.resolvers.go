@@ -3,12 +3,13 @@ package graph
 // This file will be automatically regenerated based on the schema, any resolver
 // implementations
 // will be copied through when generating and any unknown code will be moved to the end.
-// Code generated by github.com/99designs/gqlgen version v0.17.86
+// Code generated by github.com/99designs/gqlgen version v0.17.88
 
 import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -20,13 +21,59 @@ import (
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/messaging"
-	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
 	"github.com/google/uuid"
 	"github.com/mariocosenza/mocc/graph/model"
 	"github.com/mariocosenza/mocc/internal/logic"
 )
 
+// Badge is the resolver for the badge field.
+func (r *earnedBadgeResolver) Badge(ctx context.Context, obj *model.EarnedBadge) (*model.Badge, error) {
+	return logic.BadgeByID(obj.BadgeID), nil
+}
+
+// Kind is the resolver for the kind field.
+func (r *fridgeResolver) Kind(ctx context.Context, obj *model.Fridge) (model.StorageKind, error) {
+	if obj.Kind == "" {
+		return model.StorageKindFridge, nil
+	}
+	return obj.Kind, nil
+}
+
+// Items is the resolver for the items field.
+func (r *fridgeResolver) Items(ctx context.Context, obj *model.Fridge) ([]*model.InventoryItem, error) {
+	return r.FetchFridgeItems(ctx, obj)
+}
+
+// Members is the resolver for the members field.
+func (r *fridgeResolver) Members(ctx context.Context, obj *model.Fridge) ([]*model.FridgeMember, error) {
+	return r.FridgeMembersOf(ctx, obj), nil
+}
+
+// MyRole is the resolver for the myRole field.
+func (r *fridgeResolver) MyRole(ctx context.Context, obj *model.Fridge) (model.FridgeRole, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	role := logic.FridgeRoleOf(obj, uid)
+	if role == "" {
+		return "", fmt.Errorf("unauthorized")
+	}
+	return role, nil
+}
+
+// Recipe is the resolver for the recipe field.
+func (r *mealSlotResolver) Recipe(ctx context.Context, obj *model.MealSlot) (*model.Recipe, error) {
+	return r.PlannedRecipe(ctx, obj)
+}
+
+// Warnings is the resolver for the warnings field.
+func (r *mealSlotResolver) Warnings(ctx context.Context, obj *model.MealSlot) ([]string, error) {
+	return r.MealSlotWarnings(ctx, obj)
+}
+
 // UpdateUserPreferences is the resolver for the updateUserPreferences field.
 func (r *mutationResolver) UpdateUserPreferences(ctx context.Context, input model.UserPreferencesInput) (*model.User, error) {
 	uid, err := r.ResolveUserID(ctx)
@@ -48,6 +95,16 @@ func (r *mutationResolver) UpdateUserPreferences(ctx context.Context, input mode
 	if input.DietaryRestrictions != nil {
 		user.Preferences.DietaryRestrictions = input.DietaryRestrictions
 	}
+	if input.ActiveFridgeID != nil {
+		if *input.ActiveFridgeID == "" {
+			user.Preferences.ActiveFridgeID = nil
+		} else {
+			if _, err := r.ResolveFridgeID(ctx, uid, input.ActiveFridgeID); err != nil {
+				return nil, err
+			}
+			user.Preferences.ActiveFridgeID = input.ActiveFridgeID
+		}
+	}
 
 	if err := r.UpsertUser(ctx, user); err != nil {
 		return nil, err
@@ -76,223 +133,165 @@ func (r *mutationResolver) UpdateNickname(ctx context.Context, nickname string)
 	r.SyncNickname(ctx, uuid, nickname)
 
 	// Propagate nickname to Social Posts
-	container, err := r.Cosmos.NewContainer(logic.CosmosDatabase, logic.ContainerSocial)
-	if err == nil {
-		pk := azcosmos.NewPartitionKeyString("post")
-		uidParam := azcosmos.QueryParameter{Name: "@uid", Value: uuid}
-		queryOpts := &azcosmos.QueryOptions{
-			QueryParameters: []azcosmos.QueryParameter{uidParam},
-		}
-
-		pager := container.NewQueryItemsPager("SELECT * FROM c WHERE c.authorId = @uid", pk, queryOpts)
-		for pager.More() {
-			resp, err := pager.NextPage(ctx)
-			if err != nil {
-				fmt.Printf("Error paging posts: %v\n", err)
-				break
-			}
-			for _, bytes := range resp.Items {
-				var post model.Post
-				if err := json.Unmarshal(bytes, &post); err == nil {
-					if post.AuthorNickname != nickname {
-						post.AuthorNickname = nickname
-						for i := range post.Comments {
-							if post.Comments[i].UserID == uuid {
-								post.Comments[i].UserNickname = nickname
-							}
-						}
-
-						updatedBytes, _ := json.Marshal(post)
-						var postMap map[string]interface{}
-						json.Unmarshal(updatedBytes, &postMap)
-						postMap["type"] = "post"
-						finalBytes, _ := json.Marshal(postMap)
-
-						if _, err := container.UpsertItem(ctx, pk, finalBytes, nil); err != nil {
-							fmt.Printf("Error updating post %s: %v\n", post.ID, err)
-						} else {
-							fmt.Printf("Successfully updated nickname in post %s\n", post.ID)
-						}
-					}
-				}
-			}
-		}
-
-		// Query 2: Commenter (and not Author, to avoid double write, though double write is safe but wasteful)
-		pagerComments := container.NewQueryItemsPager("SELECT * FROM c WHERE ARRAY_CONTAINS(c.comments, {'userId': @uid}, true) AND c.authorId != @uid", pk, queryOpts)
-		for pagerComments.More() {
-			resp, err := pagerComments.NextPage(ctx)
-			if err != nil {
-				break
-			}
-			for _, bytes := range resp.Items {
-				var post model.Post
-				if err := json.Unmarshal(bytes, &post); err == nil {
-					updated := false
-					for i := range post.Comments {
-						if post.Comments[i].UserID == uuid && post.Comments[i].UserNickname != nickname {
-							post.Comments[i].UserNickname = nickname
-							updated = true
-						}
-					}
-
-					if updated {
-						updatedBytes, _ := json.Marshal(post)
-						var postMap map[string]interface{}
-						json.Unmarshal(updatedBytes, &postMap)
-						postMap["type"] = "post"
-						finalBytes, _ := json.Marshal(postMap)
-						if _, err := container.UpsertItem(ctx, pk, finalBytes, nil); err != nil {
-							fmt.Printf("Error updating post comment %s: %v\n", post.ID, err)
-						} else {
-							fmt.Printf("Successfully updated nickname in post comment %s\n", post.ID)
-						}
-					}
-				}
-			}
-		}
-	} else {
-		fmt.Printf("Error getting social container: %v\n", err)
-	}
+	r.PropagateNickname(ctx, uuid, nickname)
 
 	return r.FetchUser(ctx, uuid)
 }
 
-
-func (r *mutationResolver) AddInventoryItem(ctx context.Context, input model.AddInventoryItemInput) (*model.InventoryItem, error) {
+// AddFriend is the resolver for the addFriend field.
+func (r *mutationResolver) AddFriend(ctx context.Context, nickname string) (*model.User, error) {
 	uid, err := r.ResolveUserID(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	fridge, err := r.FetchFridge(ctx, uid)
+	return r.AddFriendByNickname(ctx, uid, nickname)
+}
+
+// RemoveFriend is the resolver for the removeFriend field.
+func (r *mutationResolver) RemoveFriend(ctx context.Context, userID string) (*model.User, error) {
+	uid, err := r.ResolveUserID(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	now := time.Now()
-	newItem := &model.InventoryItem{
-		ID:               "User@" + uuid.New().String(),
-		Name:             input.Name,
-		Brand:            input.Brand,
-		Category:         input.Category,
-		Quantity:         &model.Quantity{Value: input.Quantity.Value, Unit: input.Quantity.Unit},
-		VirtualAvailable: input.Quantity.Value,
-		Price:            input.Price,
-		Status:           model.ItemStatusAvailable,
-		ExpiryDate:       input.ExpiryDate,
-		ExpiryType:       input.ExpiryType,
-		AddedAt:          now.Format(time.RFC3339),
-		ActiveLocks:      []*model.ProductLock{},
-	}
-	if input.Status != nil {
-		newItem.Status = *input.Status
-	}
-
-	fridge.Items = append(fridge.Items, newItem)
+	return r.RemoveFriendByID(ctx, uid, userID)
+}
 
-	if err := r.UpsertFridge(ctx, fridge); err != nil {
+// CreateFridge is the resolver for the createFridge field.
+func (r *mutationResolver) CreateFridge(ctx context.Context, name string, kind *model.StorageKind) (*model.Fridge, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
 		return nil, err
 	}
 
-	return newItem, nil
+	storageKind := model.StorageKindFridge
+	if kind != nil {
+		storageKind = *kind
+	}
+	return r.CreateStorage(ctx, uid, name, storageKind)
 }
 
-func (r *mutationResolver) UpdateInventoryItem(ctx context.Context, id string, input model.UpdateInventoryItemInput) (*model.InventoryItem, error) {
+// RenameFridge is the resolver for the renameFridge field.
+func (r *mutationResolver) RenameFridge(ctx context.Context, id string, name string) (*model.Fridge, error) {
 	uid, err := r.ResolveUserID(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	fridge, err := r.FetchFridge(ctx, uid)
+	return r.RenameStorage(ctx, uid, id, name)
+}
+
+// DeleteFridge is the resolver for the deleteFridge field.
+func (r *mutationResolver) DeleteFridge(ctx context.Context, id string) (bool, error) {
+	uid, err := r.ResolveUserID(ctx)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
 
-	var item *model.InventoryItem
-	for _, i := range fridge.Items {
-		if i.ID == id {
-			item = i
-			break
-		}
-	}
-	if item == nil {
-		return nil, fmt.Errorf(logic.ErrItemNotFound)
+	if err := r.RemoveStorage(ctx, uid, id); err != nil {
+		return false, err
 	}
+	return true, nil
+}
 
-	if input.Name != nil {
-		item.Name = *input.Name
-	}
-	if input.Brand != nil {
-		item.Brand = input.Brand
-	}
-	if input.Category != nil {
-		item.Category = input.Category
-	}
-	if input.Price != nil {
-		item.Price = input.Price
-	}
-	if input.Status != nil {
-		item.Status = *input.Status
+// AddInventoryItem is the resolver for the addInventoryItem field.
+func (r *mutationResolver) AddInventoryItem(ctx context.Context, input model.AddInventoryItemInput, fridgeID *string) (*model.InventoryItem, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return nil, err
 	}
-	if input.Quantity != nil {
-		item.Quantity = &model.Quantity{Value: input.Quantity.Value, Unit: input.Quantity.Unit}
 
-		item.VirtualAvailable = item.Quantity.Value
-		var activeLocksTotal float64 = 0
-		for _, l := range item.ActiveLocks {
-			activeLocksTotal += l.Amount
-		}
-		item.VirtualAvailable -= activeLocksTotal
-		if item.VirtualAvailable < -0.001 {
-			return nil, fmt.Errorf("cannot reduce quantity below locked amount (%f)", activeLocksTotal)
-		}
-	}
-	if input.ExpiryDate != nil {
-		item.ExpiryDate = *input.ExpiryDate
+	now := time.Now()
+	newItem := &model.InventoryItem{
+		ID:                "User@" + uuid.New().String(),
+		Name:              input.Name,
+		Brand:             input.Brand,
+		Category:          input.Category,
+		Quantity:          &model.Quantity{Value: input.Quantity.Value, Unit: input.Quantity.Unit},
+		VirtualAvailable:  input.Quantity.Value,
+		Price:             input.Price,
+		Status:            model.ItemStatusAvailable,
+		ExpiryDate:        input.ExpiryDate,
+		ExpiryType:        input.ExpiryType,
+		AddedAt:           now.Format(time.RFC3339),
+		ActiveLocks:       []*model.ProductLock{},
+		PieceWeightGrams:  input.PieceWeightGrams,
+		DensityGramsPerMl: input.DensityGramsPerMl,
 	}
-	if input.ExpiryType != nil {
-		item.ExpiryType = *input.ExpiryType
+	if input.Status != nil {
+		newItem.Status = *input.Status
 	}
 
-	if err := r.UpsertFridge(ctx, fridge); err != nil {
+	if err := r.AddInventoryItems(ctx, uid, fridgeID, model.InventoryEventTypeAdded, newItem); err != nil {
 		return nil, err
 	}
 
-	return item, nil
+	return newItem, nil
 }
 
-// DeleteInventoryItem is the resolver for the deleteInventoryItem field.
-func (r *mutationResolver) DeleteInventoryItem(ctx context.Context, id string) (bool, error) {
+// UpdateInventoryItem is the resolver for the updateInventoryItem field.
+func (r *mutationResolver) UpdateInventoryItem(ctx context.Context, id string, input model.UpdateInventoryItemInput, fridgeID *string) (*model.InventoryItem, error) {
 	uid, err := r.ResolveUserID(ctx)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	fridge, err := r.FetchFridge(ctx, uid)
+	return r.MutateInventoryItem(ctx, uid, fridgeID, id, model.InventoryEventTypeUpdated, func(item *model.InventoryItem) (logic.ItemWrite, error) {
+		if input.Name != nil {
+			item.Name = *input.Name
+		}
+		if input.Brand != nil {
+			item.Brand = input.Brand
+		}
+		if input.Category != nil {
+			item.Category = input.Category
+		}
+		if input.Price != nil {
+			item.Price = input.Price
+		}
+		if input.Status != nil {
+			item.Status = *input.Status
+		}
+		if input.PieceWeightGrams != nil {
+			item.PieceWeightGrams = input.PieceWeightGrams
+		}
+		if input.DensityGramsPerMl != nil {
+			item.DensityGramsPerMl = input.DensityGramsPerMl
+		}
+		if input.Quantity != nil {
+			quantity := &model.Quantity{Value: input.Quantity.Value, Unit: input.Quantity.Unit}
+			if err := logic.ChangeItemUnit(item, quantity); err != nil {
+				return logic.ItemKeep, err
+			}
+		}
+		if input.ExpiryDate != nil {
+			item.ExpiryDate = *input.ExpiryDate
+		}
+		if input.ExpiryType != nil {
+			item.ExpiryType = *input.ExpiryType
+		}
+		return logic.ItemReplace, nil
+	})
+}
+
+// DeleteInventoryItem is the resolver for the deleteInventoryItem field.
+func (r *mutationResolver) DeleteInventoryItem(ctx context.Context, id string, operationID *string, fridgeID *string) (bool, error) {
+	uid, err := r.ResolveUserID(ctx)
 	if err != nil {
 		return false, err
 	}
 
-	newItems := []*model.InventoryItem{}
-	found := false
-	for _, i := range fridge.Items {
-		if i.ID == id {
-			if len(i.ActiveLocks) > 0 {
-				return false, fmt.Errorf("cannot delete item %s because it is used in active recipes", i.Name)
-			}
-			found = true
-			continue
+	_, err = r.MutateInventoryItemUndoable(ctx, uid, fridgeID, id, operationID, model.InventoryEventTypeDeleted, func(item *model.InventoryItem) (logic.ItemWrite, error) {
+		if len(item.ActiveLocks) > 0 {
+			return logic.ItemKeep, fmt.Errorf("cannot delete item %s because it is used in active recipes", item.Name)
 		}
-		newItems = append(newItems, i)
-	}
-
-	if !found {
+		return logic.ItemDelete, nil
+	})
+	if errors.Is(err, logic.ErrNoSuchItem) {
 		return false, nil
 	}
-
-	fridge.Items = newItems
-	if err := r.UpsertFridge(ctx, fridge); err != nil {
+	if err != nil {
 		return false, err
 	}
 
@@ -300,54 +299,45 @@ func (r *mutationResolver) DeleteInventoryItem(ctx context.Context, id string) (
 }
 
 // ConsumeInventoryItem is the resolver for the consumeInventoryItem field.
-func (r *mutationResolver) ConsumeInventoryItem(ctx context.Context, id string, amount float64) (*model.InventoryItem, error) {
+func (r *mutationResolver) ConsumeInventoryItem(ctx context.Context, id string, amount float64, unit *model.Unit, operationID *string, fridgeID *string) (*model.InventoryItem, error) {
 	uid, err := r.ResolveUserID(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	fridge, err := r.FetchFridge(ctx, uid)
+	return r.MutateInventoryItemUndoable(ctx, uid, fridgeID, id, operationID, model.InventoryEventTypeConsumed, func(item *model.InventoryItem) (logic.ItemWrite, error) {
+		return logic.DepleteItem(item, amount, unit)
+	})
+}
+
+// WasteInventoryItem is the resolver for the wasteInventoryItem field.
+func (r *mutationResolver) WasteInventoryItem(ctx context.Context, id string, amount float64, reason *string, unit *model.Unit, operationID *string, fridgeID *string) (*model.InventoryItem, error) {
+	uid, err := r.ResolveUserID(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	var item *model.InventoryItem
-	var itemIndex int
-	for i, it := range fridge.Items {
-		if it.ID == id {
-			item = it
-			itemIndex = i
-			break
-		}
-	}
-	if item == nil {
-		return nil, fmt.Errorf(logic.ErrItemNotFound)
-	}
+	return r.RecordWaste(ctx, uid, fridgeID, id, amount, unit, reason, operationID)
+}
 
-	newVal := item.Quantity.Value - amount
-	if newVal < 0 {
-		return nil, fmt.Errorf("insufficient quantity")
+// MoveInventoryItem is the resolver for the moveInventoryItem field.
+func (r *mutationResolver) MoveInventoryItem(ctx context.Context, id string, toFridgeID string, fromFridgeID *string) (*model.InventoryItem, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return nil, err
 	}
-	item.Quantity.Value = newVal
-	item.VirtualAvailable = newVal
 
-	if newVal == 0 {
-		if len(item.ActiveLocks) > 0 {
-			return nil, fmt.Errorf("cannot consume item %s completely because it is used in active recipes", item.Name)
-		}
-		fridge.Items = append(fridge.Items[:itemIndex], fridge.Items[itemIndex+1:]...)
-	}
+	return r.RelocateInventoryItem(ctx, uid, id, fromFridgeID, toFridgeID)
+}
 
-	if err := r.UpsertFridge(ctx, fridge); err != nil {
+// UndoInventoryOperation is the resolver for the undoInventoryOperation field.
+func (r *mutationResolver) UndoInventoryOperation(ctx context.Context, operationID string) ([]*model.InventoryItem, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
 		return nil, err
 	}
 
-	return item, nil
-}
-
-// WasteInventoryItem is the resolver for the wasteInventoryItem field.
-func (r *mutationResolver) WasteInventoryItem(ctx context.Context, id string, amount float64, reason *string) (*model.InventoryItem, error) {
-	return r.ConsumeInventoryItem(ctx, id, amount)
+	return r.UndoOperation(ctx, uid, operationID)
 }
 
 // CreateRecipe is the resolver for the createRecipe field.
@@ -378,6 +368,10 @@ func (r *mutationResolver) CreateRecipe(ctx context.Context, input model.CreateR
 		desc = *input.Description
 	}
 
+	if input.Servings != nil && *input.Servings <= 0 {
+		return nil, fmt.Errorf("servings must be positive")
+	}
+
 	newRecipe := &model.Recipe{
 		ID:              uuid.New().String(),
 		AuthorID:        uid,
@@ -390,6 +384,7 @@ func (r *mutationResolver) CreateRecipe(ctx context.Context, input model.CreateR
 		Calories:        input.Calories,
 		EcoPointsReward: input.EcoPointsReward,
 		GeneratedByAi:   false,
+		Servings:        input.Servings,
 	}
 
 	newRecipe.Status = model.RecipeStatusProposed
@@ -402,12 +397,16 @@ func (r *mutationResolver) CreateRecipe(ctx context.Context, input model.CreateR
 }
 
 // UpdateRecipe is the resolver for the updateRecipe field.
-func (r *mutationResolver) UpdateRecipe(ctx context.Context, id string, input model.UpdateRecipeInput) (*model.Recipe, error) {
+func (r *mutationResolver) UpdateRecipe(ctx context.Context, id string, input model.UpdateRecipeInput, portions *int32) (*model.Recipe, error) {
 	uid, err := r.ResolveUserID(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	if portions != nil && *portions <= 0 {
+		return nil, fmt.Errorf("portions must be positive")
+	}
+
 	recipe, err := r.FetchRecipe(ctx, id)
 	if err != nil {
 		return nil, err
@@ -454,6 +453,13 @@ func (r *mutationResolver) UpdateRecipe(ctx context.Context, id string, input mo
 	if input.Calories != nil {
 		recipe.Calories = input.Calories
 	}
+	if input.Servings != nil {
+		if *input.Servings <= 0 {
+			return nil, fmt.Errorf("servings must be positive")
+		}
+		recipe.Servings = input.Servings
+		ingredientsChanged = true
+	}
 
 	// Status Transition Logic
 	if input.Status != nil {
@@ -461,10 +467,18 @@ func (r *mutationResolver) UpdateRecipe(ctx context.Context, id string, input mo
 		newStatus := *input.Status
 
 		if newStatus != oldStatus {
-			if oldStatus == model.RecipeStatusProposed && newStatus == model.RecipeStatusInPreparation {
-				if err := r.LockIngredients(ctx, uid, recipe); err != nil {
+			if (oldStatus == model.RecipeStatusProposed || oldStatus == model.RecipeStatusSaved) && newStatus == model.RecipeStatusInPreparation {
+				prepared, err := r.PreparedRecipe(ctx, uid, recipe, portions)
+				if err != nil {
+					return nil, err
+				}
+				if err := r.LockIngredients(ctx, uid, prepared); err != nil {
 					return nil, err
 				}
+				recipe.PreparationPortions = portions
+				// The lock TTL counts from here, see ReapExpiredLocks.
+				startedAt := time.Now().Format(time.RFC3339)
+				recipe.PreparationStartedAt = &startedAt
 			}
 
 			if oldStatus == model.RecipeStatusInPreparation && (newStatus == model.RecipeStatusProposed || newStatus == model.RecipeStatusSaved) {
@@ -480,47 +494,36 @@ func (r *mutationResolver) UpdateRecipe(ctx context.Context, id string, input mo
 			}
 			// Now consume the actual quantity (for cooked)
 			if newStatus == model.RecipeStatusCooked {
-				if err := r.ApplyCooking(ctx, uid, recipe); err != nil {
-					return nil, err
+				planned := r.NextPlannedMeal(ctx, uid, recipe.ID)
+				if planned != nil && portions == nil && recipe.PreparationPortions == nil {
+					portions = &planned.Portions
 				}
-
-				points := int32(0)
-				if recipe.EcoPointsReward != nil {
-					points = *recipe.EcoPointsReward
+				cooked, err := r.PreparedRecipe(ctx, uid, recipe, portions)
+				if err != nil {
+					return nil, err
 				}
-
-				if points > 0 {
-					user, err := r.FetchUser(ctx, uid)
-					if err != nil {
-						return nil, err
-					}
-
-					if user.Gamification == nil {
-						user.Gamification = &model.GamificationProfile{
-							CurrentLevel:       1,
-							NextLevelThreshold: 100,
-						}
-					}
-
-					user.Gamification.TotalEcoPoints += points
-					r.EvaluateLevelUp(user)
-
-					if err := r.UpsertUser(ctx, user); err != nil {
-						return nil, err
-					}
-
-					r.UpsertLeaderboardEntry(ctx, user)
-					r.SetUserCache(ctx, user)
+				if err := r.ApplyCooking(ctx, uid, cooked); err != nil {
+					return nil, err
 				}
+				recipe.CookedItems = cooked.CookedItems
+				r.CompletePlannedMeal(ctx, uid, planned)
 			}
 		}
 		recipe.Status = *input.Status
+		if recipe.Status != model.RecipeStatusInPreparation {
+			recipe.PreparationStartedAt = nil
+			recipe.PreparationPortions = nil
+		}
 	} else if ingredientsChanged && recipe.Status == model.RecipeStatusInPreparation {
 		// If status didn't change but ingredients did, and we are InPreparation, re-lock
+		prepared, err := r.PreparedRecipe(ctx, uid, recipe, nil)
+		if err != nil {
+			return nil, err
+		}
 		if err := r.UnlockIngredients(ctx, uid, recipe.ID); err != nil {
 			return nil, err
 		}
-		if err := r.LockIngredients(ctx, uid, recipe); err != nil {
+		if err := r.LockIngredients(ctx, uid, prepared); err != nil {
 			return nil, err
 		}
 	}
@@ -528,6 +531,16 @@ func (r *mutationResolver) UpdateRecipe(ctx context.Context, id string, input mo
 	if err := r.UpsertRecipe(ctx, recipe); err != nil {
 		return nil, err
 	}
+	if input.Status != nil && recipe.Status == model.RecipeStatusCooked {
+		// The stock is already gone, so a failed award must not fail the
+		// update and invite a retry that would deduct it again.
+		if recipe.EcoPointsReward != nil && *recipe.EcoPointsReward > 0 {
+			if err := r.AwardPoints(ctx, uid, model.PointsReasonRecipeCooked, recipe.ID, *recipe.EcoPointsReward); err != nil {
+				r.GetLogger().Printf("level=warn op=UpdateRecipe stage=award_points userId=%s recipeId=%s err=%v", uid, recipe.ID, err)
+			}
+		}
+		r.EvaluateBadges(ctx, uid, model.BadgeCriterionRecipesCooked, model.BadgeCriterionZeroWasteDays)
+	}
 
 	return recipe, nil
 }
@@ -539,7 +552,6 @@ func (r *mutationResolver) DeleteRecipe(ctx context.Context, id string) (bool, e
 		return false, err
 	}
 
-	
 	recipe, err := r.FetchRecipe(ctx, id)
 	if err != nil {
 		return false, err
@@ -555,26 +567,23 @@ func (r *mutationResolver) DeleteRecipe(ctx context.Context, id string) (bool, e
 		}
 	}
 
-	container, err := r.Cosmos.NewContainer(logic.CosmosDatabase, logic.ContainerCookbook)
-	if err != nil {
-		return false, err
-	}
-
-
-	_, err = container.DeleteItem(ctx, azcosmos.NewPartitionKeyString(recipe.AuthorID), id, nil)
-	if err != nil {
+	if err := r.RemoveRecipe(ctx, recipe); err != nil {
 		return false, err
 	}
 	return true, nil
 }
 
 // CookRecipe is the resolver for the cookRecipe field.
-func (r *mutationResolver) CookRecipe(ctx context.Context, id string) (*model.Recipe, error) {
+func (r *mutationResolver) CookRecipe(ctx context.Context, id string, portions *int32) (*model.Recipe, error) {
 	uid, err := r.ResolveUserID(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	if portions != nil && *portions <= 0 {
+		return nil, fmt.Errorf("portions must be positive")
+	}
+
 	recipe, err := r.FetchRecipe(ctx, id)
 	if err != nil {
 		return nil, err
@@ -592,22 +601,50 @@ func (r *mutationResolver) CookRecipe(ctx context.Context, id string) (*model.Re
 	if err := r.UnlockIngredients(ctx, uid, recipe.ID); err != nil {
 		return nil, err
 	}
+	planned := r.NextPlannedMeal(ctx, uid, recipe.ID)
+	if planned != nil && portions == nil && recipe.PreparationPortions == nil {
+		portions = &planned.Portions
+	}
 
-	if err := r.ApplyCooking(ctx, uid, recipe); err != nil {
+	cooked, err := r.PreparedRecipe(ctx, uid, recipe, portions)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.ApplyCooking(ctx, uid, cooked); err != nil {
 		return nil, err
 	}
+	r.CompletePlannedMeal(ctx, uid, planned)
 
+	recipe.CookedItems = cooked.CookedItems
 	recipe.Status = model.RecipeStatusCooked
+	recipe.PreparationStartedAt = nil
+	recipe.PreparationPortions = nil
 	if err := r.UpsertRecipe(ctx, recipe); err != nil {
 		return nil, err
 	}
+	r.EvaluateBadges(ctx, uid, model.BadgeCriterionRecipesCooked, model.BadgeCriterionZeroWasteDays)
 
 	return recipe, nil
 }
 
 // SaveRecipe is the resolver for the saveRecipe field.
 func (r *mutationResolver) SaveRecipe(ctx context.Context, id string) (*model.Recipe, error) {
-	panic(fmt.Errorf("not implemented: SaveRecipe - saveRecipe"))
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.CloneRecipeFromPost(ctx, uid, id)
+}
+
+// SaveRecipeFromPost is the resolver for the saveRecipeFromPost field.
+func (r *mutationResolver) SaveRecipeFromPost(ctx context.Context, postID string) (*model.Recipe, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.CloneRecipeFromPost(ctx, uid, postID)
 }
 
 // CreatePost is the resolver for the createPost field.
@@ -646,6 +683,7 @@ func (r *mutationResolver) CreatePost(ctx context.Context, input model.CreatePos
 		PrepTimeMinutes: recipe.PrepTimeMinutes,
 		Calories:        recipe.Calories,
 		EcoPointsReward: recipe.EcoPointsReward,
+		Servings:        recipe.Servings,
 	}
 
 	postID := uuid.New().String()
@@ -668,7 +706,7 @@ func (r *mutationResolver) CreatePost(ctx context.Context, input model.CreatePos
 		if len(parts) < 2 {
 			return nil, fmt.Errorf("invalid blob path")
 		}
-		srcBlobName := parts[1] 
+		srcBlobName := parts[1]
 
 		destBlobName := "posts/" + postID + ".jpg"
 		if dot := strings.LastIndex(srcBlobName, "."); dot != -1 {
@@ -696,37 +734,15 @@ func (r *mutationResolver) CreatePost(ctx context.Context, input model.CreatePos
 		Comments:       []*model.Comment{},
 	}
 
-	container, err := r.Cosmos.NewContainer(logic.CosmosDatabase, logic.ContainerSocial)
-	if err != nil {
+	if err := r.UpsertPost(ctx, newPost); err != nil {
 		return nil, err
 	}
 
-
-	postMap := map[string]interface{}{}
-	b, _ := json.Marshal(newPost)
-	json.Unmarshal(b, &postMap)
-	postMap["type"] = "post"
-
-	data, err := json.Marshal(postMap)
-	if err != nil {
-		return nil, err
-	}
-
-	_, err = container.UpsertItem(ctx, azcosmos.NewPartitionKeyString("post"), data, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	// Award points
-	if author.Gamification == nil {
-		author.Gamification = &model.GamificationProfile{TotalEcoPoints: 0, CurrentLevel: 1, NextLevelThreshold: 100}
-	}
-	author.Gamification.TotalEcoPoints += 10
-	r.EvaluateLevelUp(author)
-	if err := r.UpsertUser(ctx, author); err == nil {
-		r.UpsertLeaderboardEntry(ctx, author)
-		r.SetUserCache(ctx, author)
+	// The post stands even if its points cannot be recorded.
+	if err := r.AwardPoints(ctx, uid, model.PointsReasonPostShared, postID, logic.PostSharePoints); err != nil {
+		r.GetLogger().Printf("level=warn op=CreatePost stage=award_points userId=%s postId=%s err=%v", uid, postID, err)
 	}
+	r.EvaluateBadges(ctx, uid, model.BadgeCriterionPostsShared, model.BadgeCriterionZeroWasteDays)
 
 	return newPost, nil
 }
@@ -738,20 +754,11 @@ func (r *mutationResolver) UpdatePost(ctx context.Context, id string, caption st
 		return nil, err
 	}
 
-	container, err := r.Cosmos.NewContainer(logic.CosmosDatabase, logic.ContainerSocial)
-	if err != nil {
-		return nil, err
-	}
-
 	// Read Post
-	var post model.Post
-	resp, err := container.ReadItem(ctx, azcosmos.NewPartitionKeyString("post"), id, nil)
+	post, err := r.FetchPost(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	if err := json.Unmarshal(resp.Value, &post); err != nil {
-		return nil, err
-	}
 
 	// Check Authorization
 	if post.AuthorID != uid {
@@ -760,18 +767,11 @@ func (r *mutationResolver) UpdatePost(ctx context.Context, id string, caption st
 
 	post.Caption = &caption
 
-	postMap := map[string]interface{}{}
-	b, _ := json.Marshal(post)
-	json.Unmarshal(b, &postMap)
-	postMap["type"] = "post"
-
-	finalData, _ := json.Marshal(postMap)
-	_, err = container.UpsertItem(ctx, azcosmos.NewPartitionKeyString("post"), finalData, nil)
-	if err != nil {
+	if err := r.UpsertPost(ctx, post); err != nil {
 		return nil, err
 	}
 
-	return &post, nil
+	return post, nil
 }
 
 // DeletePost is the resolver for the deletePost field.
@@ -781,54 +781,23 @@ func (r *mutationResolver) DeletePost(ctx context.Context, id string) (bool, err
 		return false, err
 	}
 
-	container, err := r.Cosmos.NewContainer(logic.CosmosDatabase, logic.ContainerSocial)
+	post, err := r.FetchPost(ctx, id)
 	if err != nil {
 		return false, err
 	}
 
-	// PK is "post"
-	itemResponse, err := container.ReadItem(ctx, azcosmos.NewPartitionKeyString("post"), id, nil)
-	if err != nil {
-		return false, err
-	}
-
-	var postData map[string]interface{}
-	if err := json.Unmarshal(itemResponse.Value, &postData); err != nil {
-		return false, err
-	}
-
-	authorId, ok := postData["authorId"].(string)
-	if !ok || authorId != uid {
+	if post.AuthorID != uid {
 		return false, fmt.Errorf("unauthorized")
 	}
 
-	_, err = container.DeleteItem(ctx, azcosmos.NewPartitionKeyString("post"), id, nil)
+	err = r.RemovePost(ctx, id)
 	if err == nil {
-		if imgUrl, ok := postData["imageUrl"].(string); ok && imgUrl != "" {
-			_ = r.Logic.DeleteBlob(ctx, imgUrl)
+		if post.ImageURL != nil && *post.ImageURL != "" {
+			_ = r.Logic.DeleteBlob(ctx, *post.ImageURL)
 		}
 
-		author, uErr := r.FetchUser(ctx, uid)
-		if uErr == nil && author != nil {
-			if author.Gamification == nil {
-				author.Gamification = &model.GamificationProfile{
-					TotalEcoPoints:     0,
-					CurrentLevel:       1,
-					NextLevelThreshold: 100,
-				}
-			}
-			if author.Gamification != nil {
-				if author.Gamification.TotalEcoPoints >= 10 {
-					author.Gamification.TotalEcoPoints -= 10
-				} else {
-					author.Gamification.TotalEcoPoints = 0
-				}
-				r.AdjustLevelDownwards(author)
-				if err := r.UpsertUser(ctx, author); err == nil {
-					r.UpsertLeaderboardEntry(ctx, author)
-					r.SetUserCache(ctx, author)
-				}
-			}
+		if err := r.RevokePoints(ctx, uid, model.PointsReasonPostShared, model.PointsReasonPostDeleted, id, logic.PostSharePoints); err != nil {
+			r.GetLogger().Printf("level=warn op=DeletePost stage=revoke_points userId=%s postId=%s err=%v", uid, id, err)
 		}
 	}
 	return err == nil, err
@@ -841,20 +810,11 @@ func (r *mutationResolver) LikePost(ctx context.Context, postID string) (*model.
 		return nil, err
 	}
 
-	container, err := r.Cosmos.NewContainer(logic.CosmosDatabase, logic.ContainerSocial)
+	post, err := r.FetchPost(ctx, postID)
 	if err != nil {
 		return nil, err
 	}
 
-	var post model.Post 
-	resp, err := container.ReadItem(ctx, azcosmos.NewPartitionKeyString("post"), postID, nil)
-	if err != nil {
-		return nil, err
-	}
-	if err := json.Unmarshal(resp.Value, &post); err != nil {
-		return nil, err
-	}
-
 	// Check if already liked
 	alreadyLiked := false
 	for _, id := range post.LikedBy {
@@ -868,19 +828,19 @@ func (r *mutationResolver) LikePost(ctx context.Context, postID string) (*model.
 		post.LikedBy = append(post.LikedBy, uid)
 		post.LikesCount = int32(len(post.LikedBy))
 
-		postMap := map[string]interface{}{}
-		b, _ := json.Marshal(post)
-		json.Unmarshal(b, &postMap)
-		postMap["type"] = "post"
-
-		finalData, _ := json.Marshal(postMap)
-		_, err = container.UpsertItem(ctx, azcosmos.NewPartitionKeyString("post"), finalData, nil)
-		if err != nil {
+		if err := r.UpsertPost(ctx, post); err != nil {
 			return nil, err
 		}
+
+		if post.AuthorID != uid {
+			if liker, err := r.FetchUser(ctx, uid); err == nil {
+				r.Notify(ctx, post.AuthorID, model.NotificationTypePostLiked, post.ID, "%s ha messo mi piace al tuo post", liker.Nickname)
+			}
+			r.EvaluateBadges(ctx, post.AuthorID, model.BadgeCriterionLikesReceived)
+		}
 	}
 
-	return &post, nil
+	return post, nil
 }
 
 // UnlikePost is the resolver for the unlikePost field.
@@ -890,19 +850,10 @@ func (r *mutationResolver) UnlikePost(ctx context.Context, postID string) (*mode
 		return nil, err
 	}
 
-	container, err := r.Cosmos.NewContainer(logic.CosmosDatabase, logic.ContainerSocial)
-	if err != nil {
-		return nil, err
-	}
-
-	var post model.Post
-	resp, err := container.ReadItem(ctx, azcosmos.NewPartitionKeyString("post"), postID, nil)
+	post, err := r.FetchPost(ctx, postID)
 	if err != nil {
 		return nil, err
 	}
-	if err := json.Unmarshal(resp.Value, &post); err != nil {
-		return nil, err
-	}
 
 	newLikedBy := []string{}
 	found := false
@@ -918,19 +869,12 @@ func (r *mutationResolver) UnlikePost(ctx context.Context, postID string) (*mode
 		post.LikedBy = newLikedBy
 		post.LikesCount = int32(len(post.LikedBy))
 
-		postMap := map[string]interface{}{}
-		b, _ := json.Marshal(post)
-		json.Unmarshal(b, &postMap)
-		postMap["type"] = "post"
-
-		finalData, _ := json.Marshal(postMap)
-		_, err = container.UpsertItem(ctx, azcosmos.NewPartitionKeyString("post"), finalData, nil)
-		if err != nil {
+		if err := r.UpsertPost(ctx, post); err != nil {
 			return nil, err
 		}
 	}
 
-	return &post, nil
+	return post, nil
 }
 
 // AddComment is the resolver for the addComment field.
@@ -945,20 +889,11 @@ func (r *mutationResolver) AddComment(ctx context.Context, postID string, text s
 		return nil, err
 	}
 
-	container, err := r.Cosmos.NewContainer(logic.CosmosDatabase, logic.ContainerSocial)
+	post, err := r.FetchPost(ctx, postID)
 	if err != nil {
 		return nil, err
 	}
 
-	var post model.Post
-	resp, err := container.ReadItem(ctx, azcosmos.NewPartitionKeyString("post"), postID, nil)
-	if err != nil {
-		return nil, err
-	}
-	if err := json.Unmarshal(resp.Value, &post); err != nil {
-		return nil, err
-	}
-
 	newComment := &model.Comment{
 		ID:           uuid.New().String(),
 		UserID:       uid,
@@ -970,17 +905,14 @@ func (r *mutationResolver) AddComment(ctx context.Context, postID string, text s
 
 	post.Comments = append(post.Comments, newComment)
 
-	postMap := map[string]interface{}{}
-	b, _ := json.Marshal(post)
-	json.Unmarshal(b, &postMap)
-	postMap["type"] = "post"
-
-	finalData, _ := json.Marshal(postMap)
-	_, err = container.UpsertItem(ctx, azcosmos.NewPartitionKeyString("post"), finalData, nil)
-	if err != nil {
+	if err := r.UpsertPost(ctx, post); err != nil {
 		return nil, err
 	}
 
+	if post.AuthorID != uid {
+		r.Notify(ctx, post.AuthorID, model.NotificationTypePostCommented, post.ID, "%s ha commentato il tuo post", user.Nickname)
+	}
+
 	client, err := r.getEventGridClient(ctx)
 	if err != nil {
 		fmt.Printf("EventGrid client error (notification skipped): %v\n", err)
@@ -1297,13 +1229,7 @@ func (r *mutationResolver) DeleteShoppingHistory(ctx context.Context, id string)
 		return false, fmt.Errorf("unauthorized")
 	}
 
-	container, err := r.Cosmos.NewContainer(logic.CosmosDatabase, logic.ContainerHistory)
-	if err != nil {
-		return false, err
-	}
-
-	_, err = container.DeleteItem(ctx, azcosmos.NewPartitionKeyString(uid), id, nil)
-	if err != nil {
+	if err := r.RemoveShoppingHistory(ctx, entry); err != nil {
 		return false, err
 	}
 
@@ -1315,7 +1241,7 @@ func (r *mutationResolver) DeleteShoppingHistory(ctx context.Context, id string)
 }
 
 // ImportShoppingHistoryToFridge is the resolver for the importShoppingHistoryToFridge field.
-func (r *mutationResolver) ImportShoppingHistoryToFridge(ctx context.Context, id string) (*model.ShoppingHistoryEntry, error) {
+func (r *mutationResolver) ImportShoppingHistoryToFridge(ctx context.Context, id string, operationID *string, fridgeID *string) (*model.ShoppingHistoryEntry, error) {
 	uid, err := r.ResolveUserID(ctx)
 	if err != nil {
 		return nil, err
@@ -1330,104 +1256,178 @@ func (r *mutationResolver) ImportShoppingHistoryToFridge(ctx context.Context, id
 		return nil, fmt.Errorf("unauthorized")
 	}
 
-	if entry.IsImported {
-		return nil, fmt.Errorf("history already imported")
+	return r.ImportShoppingHistory(ctx, uid, entry, fridgeID, operationID)
+}
+
+// AddShoppingListItem is the resolver for the addShoppingListItem field.
+func (r *mutationResolver) AddShoppingListItem(ctx context.Context, input model.ShoppingListItemInput, fridgeID *string) (*model.ShoppingList, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := logic.ValidateShoppingListItem(input.Name, input.Quantity); err != nil {
+		return nil, err
 	}
 
-	fridge, err := r.FetchFridge(ctx, uid)
+	item := &model.ShoppingListItem{
+		ID:       uuid.New().String(),
+		Name:     strings.TrimSpace(input.Name),
+		Quantity: input.Quantity,
+		Unit:     input.Unit,
+		Category: input.Category,
+		AddedBy:  uid,
+		AddedAt:  time.Now().Format(time.RFC3339),
+	}
+	return r.UpdateShoppingList(ctx, uid, fridgeID, func(list *model.ShoppingList) (bool, error) {
+		list.Items = append(list.Items, item)
+		return true, nil
+	})
+}
+
+// UpdateShoppingListItem is the resolver for the updateShoppingListItem field.
+func (r *mutationResolver) UpdateShoppingListItem(ctx context.Context, id string, input model.UpdateShoppingListItemInput, fridgeID *string) (*model.ShoppingList, error) {
+	uid, err := r.ResolveUserID(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	now := time.Now()
-	for _, histItem := range entry.ItemsSnapshot {
-		price := float64(0)
-		if histItem.Price != nil {
-			price = *histItem.Price
+	if input.Name != nil {
+		if err := logic.ValidateShoppingListItem(*input.Name, input.Quantity); err != nil {
+			return nil, err
 		}
+	} else if input.Quantity != nil && *input.Quantity < 0 {
+		return nil, fmt.Errorf("the quantity cannot be negative")
+	}
 
-		var qtyVal float64 = 1.0
-		if histItem.Quantity != nil {
-			qtyVal = *histItem.Quantity
+	return r.UpdateShoppingList(ctx, uid, fridgeID, func(list *model.ShoppingList) (bool, error) {
+		i := logic.ShoppingListItemIndex(list, id)
+		if i < 0 {
+			return false, fmt.Errorf("shopping list item not found")
 		}
 
-		var unitVal model.Unit = model.UnitPz
-		if histItem.Unit != nil {
-			unitVal = *histItem.Unit
+		item := list.Items[i]
+		if input.Name != nil {
+			item.Name = strings.TrimSpace(*input.Name)
 		}
-
-		newItem := &model.InventoryItem{
-			ID:               "User@" + uuid.New().String(),
-			Name:             histItem.Name,
-			Quantity:         &model.Quantity{Value: qtyVal, Unit: unitVal},
-			VirtualAvailable: qtyVal,
-			Price:            &price,
-			Status:           model.ItemStatusAvailable,
-			AddedAt:          now.Format(time.RFC3339),
-			ActiveLocks:      []*model.ProductLock{},
+		if input.Quantity != nil {
+			item.Quantity = input.Quantity
 		}
-
-		if histItem.ExpiryDate != nil {
-			newItem.ExpiryDate = *histItem.ExpiryDate
-		} else {
-			newItem.ExpiryDate = now.Add(7 * 24 * time.Hour).Format(time.RFC3339)
+		if input.Unit != nil {
+			item.Unit = input.Unit
 		}
+		if input.Category != nil {
+			item.Category = input.Category
+		}
+		if input.Checked != nil {
+			item.Checked = *input.Checked
+		}
+		return true, nil
+	})
+}
 
-		if histItem.ExpiryType != nil {
-			newItem.ExpiryType = *histItem.ExpiryType
-		} else {
-			newItem.ExpiryType = model.ExpiryTypeBestBefore
+// RemoveShoppingListItem is the resolver for the removeShoppingListItem field.
+func (r *mutationResolver) RemoveShoppingListItem(ctx context.Context, id string, fridgeID *string) (*model.ShoppingList, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.UpdateShoppingList(ctx, uid, fridgeID, func(list *model.ShoppingList) (bool, error) {
+		i := logic.ShoppingListItemIndex(list, id)
+		if i < 0 {
+			return false, fmt.Errorf("shopping list item not found")
 		}
+		list.Items = append(list.Items[:i], list.Items[i+1:]...)
+		return true, nil
+	})
+}
 
-		if histItem.Category != nil {
-			newItem.Category = histItem.Category
+// ClearShoppingList is the resolver for the clearShoppingList field.
+func (r *mutationResolver) ClearShoppingList(ctx context.Context, checkedOnly *bool, fridgeID *string) (*model.ShoppingList, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	onlyChecked := checkedOnly == nil || *checkedOnly
+	return r.UpdateShoppingList(ctx, uid, fridgeID, func(list *model.ShoppingList) (bool, error) {
+		kept := []*model.ShoppingListItem{}
+		for _, item := range list.Items {
+			if onlyChecked && !item.Checked {
+				kept = append(kept, item)
+			}
 		}
-		if histItem.Brand != nil {
-			newItem.Brand = histItem.Brand
+		if len(kept) == len(list.Items) {
+			return false, nil
 		}
+		list.Items = kept
+		return true, nil
+	})
+}
 
-		fridge.Items = append(fridge.Items, newItem)
+// CheckoutShoppingList is the resolver for the checkoutShoppingList field.
+func (r *mutationResolver) CheckoutShoppingList(ctx context.Context, input *model.CheckoutShoppingListInput, fridgeID *string) (*model.ShoppingHistoryEntry, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := r.UpsertFridge(ctx, fridge); err != nil {
+	return r.PurchaseShoppingList(ctx, uid, fridgeID, input)
+}
+
+// GenerateShoppingList is the resolver for the generateShoppingList field.
+func (r *mutationResolver) GenerateShoppingList(ctx context.Context, recipeIds []string, portions *int32, save *bool) (*model.GeneratedShoppingList, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
 		return nil, err
 	}
 
-	entry.IsImported = true
-	if err := r.UpsertShoppingHistory(ctx, entry); err != nil {
-		fmt.Printf("Error marking history as imported: %v\n", err)
+	return r.PlanShoppingList(ctx, uid, recipeIds, portions, save != nil && *save)
+}
+
+// ScheduleMeal is the resolver for the scheduleMeal field.
+func (r *mutationResolver) ScheduleMeal(ctx context.Context, input model.ScheduleMealInput, fridgeID *string) (*model.MealPlan, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
 		return nil, err
 	}
 
-	return entry, nil
+	return r.PlanMeal(ctx, uid, fridgeID, input)
 }
 
-// GenerateSharedFridgeLink is the resolver for the generateSharedFridgeLink field.
-func (r *mutationResolver) GenerateSharedFridgeLink(ctx context.Context) (*model.SharedFridgeLink, error) {
+// UnscheduleMeal is the resolver for the unscheduleMeal field.
+func (r *mutationResolver) UnscheduleMeal(ctx context.Context, id string, date string, fridgeID *string) (*model.MealPlan, error) {
 	uid, err := r.ResolveUserID(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	usId, err := r.Redis.Get(ctx, "invite:user:"+uid).Result()
-	if err == nil && usId != "" {
-		return &model.SharedFridgeLink{
-			AuthorID:   uid,
-			InviteCode: usId,
-		}, nil
-	}
+	return r.UnplanMeal(ctx, uid, fridgeID, id, date)
+}
 
-	// Create new invite
+// GenerateSharedFridgeLink is the resolver for the generateSharedFridgeLink field.
+func (r *mutationResolver) GenerateSharedFridgeLink(ctx context.Context, fridgeID *string, role *model.FridgeRole, expiresInMinutes *int32) (*model.SharedFridgeLink, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	share := &model.SharedFridgeLink{
-		AuthorID:   uid,
-		InviteCode: uuid.New().String(),
+	inviteRole := model.FridgeRoleEditor
+	if role != nil {
+		inviteRole = *role
 	}
+	return r.CreateInvite(ctx, uid, fridgeID, inviteRole, expiresInMinutes)
+}
 
-	r.Redis.Set(ctx, "invite:code:"+share.InviteCode, uid, time.Minute*60)
-	r.Redis.Set(ctx, "invite:user:"+uid, share.InviteCode, time.Minute*60)
+// RevokeSharedFridgeLink is the resolver for the revokeSharedFridgeLink field.
+func (r *mutationResolver) RevokeSharedFridgeLink(ctx context.Context, inviteCode string) (bool, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return false, err
+	}
 
-	return share, nil
+	return r.RevokeInvite(ctx, uid, inviteCode)
 }
 
 // AddFridgeShared is the resolver for the addFridgeShared field.
@@ -1440,36 +1440,46 @@ func (r *mutationResolver) AddFridgeShared(ctx context.Context, sharedID *string
 		return nil, fmt.Errorf("sharedID is required")
 	}
 
-	uid, err := r.Redis.Get(ctx, "invite:code:"+*sharedID).Result()
-	if err != nil {
+	if _, err := r.JoinFridge(ctx, userId, *sharedID); err != nil {
 		return nil, err
 	}
+	return &userId, nil
+}
+
+// LeaveFridge is the resolver for the leaveFridge field.
+func (r *mutationResolver) LeaveFridge(ctx context.Context, fridgeID string) (bool, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return false, err
+	}
 
-	if uid == userId {
-		return nil, fmt.Errorf("cannot share fridge with yourself")
+	if err := r.QuitFridge(ctx, uid, fridgeID); err != nil {
+		return false, err
 	}
+	return true, nil
+}
 
-	// Avoid PatchItem due to marshalling issues with arrays
-	targetFridge, err := r.FetchFridge(ctx, uid)
+// RemoveFridgeMember is the resolver for the removeFridgeMember field.
+func (r *mutationResolver) RemoveFridgeMember(ctx context.Context, fridgeID string, userID string) (bool, error) {
+	uid, err := r.ResolveUserID(ctx)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
 
-	alreadyOwner := false
-	for _, owner := range targetFridge.OwnerID {
-		if owner == userId {
-			alreadyOwner = true
-			break
-		}
+	if err := r.ExpelMember(ctx, uid, fridgeID, userID); err != nil {
+		return false, err
 	}
+	return true, nil
+}
 
-	if !alreadyOwner {
-		targetFridge.OwnerID = append(targetFridge.OwnerID, userId)
-		if err := r.UpsertFridge(ctx, targetFridge); err != nil {
-			return nil, err
-		}
+// UpdateMemberRole is the resolver for the updateMemberRole field.
+func (r *mutationResolver) UpdateMemberRole(ctx context.Context, fridgeID string, userID string, role model.FridgeRole) (*model.FridgeMember, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return nil, err
 	}
-	return &userId, nil
+
+	return r.SetMemberRole(ctx, uid, fridgeID, userID, role)
 }
 
 // Me is the resolver for the me field.
@@ -1491,29 +1501,51 @@ func (r *queryResolver) MyFridge(ctx context.Context) ([]*model.Fridge, error) {
 	return r.Logic.FetchFridges(ctx, uid)
 }
 
-// ShoppingHistory is the resolver for the shoppingHistory field.
-func (r *queryResolver) ShoppingHistory(ctx context.Context, limit *int32, offset *int32) ([]*model.ShoppingHistoryEntry, error) {
+// FridgeMembers is the resolver for the fridgeMembers field.
+func (r *queryResolver) FridgeMembers(ctx context.Context, fridgeID string) ([]*model.FridgeMember, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.ListFridgeMembers(ctx, uid, fridgeID)
+}
+
+// InventoryEvents is the resolver for the inventoryEvents field.
+func (r *queryResolver) InventoryEvents(ctx context.Context, fridgeID *string, first *int32, after *string) (*model.InventoryEventConnection, error) {
 	uid, err := r.ResolveUserID(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	l := 10
-	if limit != nil {
-		l = int(*limit)
+	return r.FetchInventoryEvents(ctx, uid, fridgeID, first, after)
+}
+
+// InventoryItemEvents is the resolver for the inventoryItemEvents field.
+func (r *queryResolver) InventoryItemEvents(ctx context.Context, itemID string, first *int32, after *string) (*model.InventoryEventConnection, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return nil, err
 	}
-	o := 0
-	if offset != nil {
-		o = int(*offset)
+
+	return r.FetchItemEvents(ctx, uid, itemID, first, after)
+}
+
+// ShoppingHistory is the resolver for the shoppingHistory field.
+func (r *queryResolver) ShoppingHistory(ctx context.Context, first *int32, after *string) (*model.ShoppingHistoryConnection, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	entries, err := r.FetchShoppingHistoryList(ctx, uid, l, o)
+	conn, err := r.FetchShoppingHistoryPage(ctx, uid, first, after)
 	if err != nil {
 		return nil, err
 	}
 
 	// Sign receiptImageUrl for each entry
-	for _, entry := range entries {
+	for _, edge := range conn.Edges {
+		entry := edge.Node
 		if entry.ReceiptImageURL != nil && *entry.ReceiptImageURL != "" {
 			signedURL, signErr := r.signReceiptURL(ctx, *entry.ReceiptImageURL)
 			if signErr == nil {
@@ -1522,7 +1554,7 @@ func (r *queryResolver) ShoppingHistory(ctx context.Context, limit *int32, offse
 		}
 	}
 
-	return entries, nil
+	return conn, nil
 }
 
 // ShoppingHistoryEntry is the resolver for the shoppingHistoryEntry field.
@@ -1544,128 +1576,284 @@ func (r *queryResolver) ShoppingHistoryEntry(ctx context.Context, id string) (*m
 	return entry, nil
 }
 
+// ShoppingList is the resolver for the shoppingList field.
+func (r *queryResolver) ShoppingList(ctx context.Context, fridgeID *string) (*model.ShoppingList, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.FetchShoppingList(ctx, uid, fridgeID)
+}
+
 // MyRecipes is the resolver for the myRecipes field.
-func (r *queryResolver) MyRecipes(ctx context.Context, status *model.RecipeStatus) ([]*model.Recipe, error) {
+func (r *queryResolver) MyRecipes(ctx context.Context, status *model.RecipeStatus, first *int32, after *string) (*model.RecipeConnection, error) {
 	uid, err := r.ResolveUserID(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	query := "SELECT * FROM c WHERE c.authorId = @uid"
-	if status != nil {
-		query += fmt.Sprintf(" AND c.status = '%s'", *status)
+	return r.FetchRecipesPage(ctx, uid, status, first, after)
+}
+
+// Recipe is the resolver for the recipe field.
+func (r *queryResolver) Recipe(ctx context.Context, id string) (*model.Recipe, error) {
+	return r.FetchRecipe(ctx, id)
+}
+
+// ScaledRecipe is the resolver for the scaledRecipe field.
+func (r *queryResolver) ScaledRecipe(ctx context.Context, id string, portions int32) (*model.Recipe, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	container, err := r.Cosmos.NewContainer(logic.CosmosDatabase, logic.ContainerCookbook)
+	recipe, err := r.FetchRecipe(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	return r.ScaleRecipe(ctx, uid, recipe, portions)
+}
 
-	qOpts := azcosmos.QueryOptions{
-		QueryParameters: []azcosmos.QueryParameter{
-			{Name: "@uid", Value: uid},
-		},
+// SuggestIngredientMatches is the resolver for the suggestIngredientMatches field.
+func (r *queryResolver) SuggestIngredientMatches(ctx context.Context, recipeID string) ([]*model.IngredientMatchSuggestion, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	// Use PK = AuthorID = uid
-	pager := container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(uid), &qOpts)
+	return r.FetchIngredientMatches(ctx, uid, recipeID)
+}
 
-	var recipes []*model.Recipe
-	for pager.More() {
-		resp, err := pager.NextPage(ctx)
-		if err != nil {
-			return nil, err
-		}
-		for _, bytes := range resp.Items {
-			var recipe model.Recipe
-			if err := json.Unmarshal(bytes, &recipe); err == nil {
-				recipes = append(recipes, &recipe)
-			}
-		}
+// CookableRecipes is the resolver for the cookableRecipes field.
+func (r *queryResolver) CookableRecipes(ctx context.Context, source *model.RecipeSource, limit *int32, respectDiet *bool) ([]*model.CookableRecipe, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	src := model.RecipeSourceMine
+	if source != nil {
+		src = *source
 	}
-	return recipes, nil
+	return r.RankCookableRecipes(ctx, uid, src, limit, respectDiet == nil || *respectDiet)
 }
 
-// Recipe is the resolver for the recipe field.
-func (r *queryResolver) Recipe(ctx context.Context, id string) (*model.Recipe, error) {
-	return r.FetchRecipe(ctx, id)
+// MealPlan is the resolver for the mealPlan field.
+func (r *queryResolver) MealPlan(ctx context.Context, weekStart *string, fridgeID *string) (*model.MealPlan, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.FetchMealPlan(ctx, uid, fridgeID, weekStart)
+}
+
+// WasteReport is the resolver for the wasteReport field.
+func (r *queryResolver) WasteReport(ctx context.Context, from string, to string) (*model.WasteReport, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.BuildWasteReport(ctx, uid, from, to)
 }
 
 // Feed is the resolver for the feed field.
-func (r *queryResolver) Feed(ctx context.Context, limit *int32, offset *int32) ([]*model.Post, error) {
-	// Cross-partition query on PK /type = 'post'
-	container, err := r.Cosmos.NewContainer(logic.CosmosDatabase, logic.ContainerSocial)
+func (r *queryResolver) Feed(ctx context.Context, first *int32, after *string, respectDiet *bool) (*model.PostConnection, error) {
+	uid, err := r.ResolveUserID(ctx)
 	if err != nil {
 		return nil, err
 	}
+	return r.FetchFeed(ctx, uid, first, after, respectDiet == nil || *respectDiet)
+}
 
-	query := "SELECT * FROM c WHERE c.type = 'post' ORDER BY c.createdAt DESC"
+// Leaderboard is the resolver for the leaderboard field.
+func (r *queryResolver) Leaderboard(ctx context.Context, top *int32, period *model.LeaderboardPeriod, scope *model.LeaderboardScope, fridgeID *string) ([]*model.LeaderboardEntry, error) {
+	limit := 5
+	if top != nil {
+		limit = int(*top)
+	}
+	kind := model.LeaderboardPeriodAllTime
+	if period != nil {
+		kind = *period
+	}
+	if scope == nil || *scope == model.LeaderboardScopeGlobal {
+		return r.FetchLeaderboard(ctx, limit, kind)
+	}
 
-	qOpts := azcosmos.QueryOptions{}
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.FetchScopedLeaderboard(ctx, uid, limit, kind, *scope, fridgeID)
+}
 
-	// Use PK = 'post'
-	pager := container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString("post"), &qOpts)
+// LeaderboardArchive is the resolver for the leaderboardArchive field.
+func (r *queryResolver) LeaderboardArchive(ctx context.Context, period model.LeaderboardPeriod, last *int32) ([]*model.LeaderboardArchive, error) {
+	limit := 4
+	if last != nil {
+		limit = int(*last)
+	}
+	return r.FetchLeaderboardArchives(ctx, period, limit)
+}
 
-	var posts []*model.Post
-	for pager.More() {
-		resp, err := pager.NextPage(ctx)
-		if err != nil {
-			return nil, err
-		}
-		for _, bytes := range resp.Items {
-			var post model.Post
-			if err := json.Unmarshal(bytes, &post); err == nil {
-				posts = append(posts, &post)
-			}
-		}
-		if limit != nil && len(posts) >= int(100) { // Safety break
-			break
-		}
+// MyLeaderboardPosition is the resolver for the myLeaderboardPosition field.
+func (r *queryResolver) MyLeaderboardPosition(ctx context.Context, period *model.LeaderboardPeriod, neighbours *int32) (*model.LeaderboardPosition, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	start := 0
-	if offset != nil {
-		start = int(*offset)
+	kind := model.LeaderboardPeriodAllTime
+	if period != nil {
+		kind = *period
 	}
-	end := len(posts)
-	if limit != nil {
-		l := int(*limit)
-		if start+l < end {
-			end = start + l
-		}
-	} else {
-		if start+20 < end {
-			end = start + 20
-		}
+	around := 2
+	if neighbours != nil {
+		around = int(*neighbours)
 	}
+	return r.FetchLeaderboardPosition(ctx, uid, kind, around)
+}
+
+// BadgeCatalog is the resolver for the badgeCatalog field.
+func (r *queryResolver) BadgeCatalog(ctx context.Context) ([]*model.Badge, error) {
+	return logic.BadgeCatalog(), nil
+}
 
-	if start > len(posts) {
-		return []*model.Post{}, nil
+// MyPointsHistory is the resolver for the myPointsHistory field.
+func (r *queryResolver) MyPointsHistory(ctx context.Context, first *int32, after *string) (*model.PointsEntryConnection, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return nil, err
 	}
-	if end > len(posts) {
-		end = len(posts)
+
+	return r.FetchPointsHistory(ctx, uid, first, after)
+}
+
+// Ingredients is the resolver for the ingredients field.
+func (r *recipeResolver) Ingredients(ctx context.Context, obj *model.Recipe) ([]*model.RecipeIngredient, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil || obj.AuthorID != uid {
+		// Only the author's fridge is matched against.
+		return obj.Ingredients, nil
 	}
-	if start > end {
-		return []*model.Post{}, nil
+
+	ingredients, err := r.ResolveRecipeIngredients(ctx, uid, obj)
+	if err != nil {
+		// Matching is a hint, the recipe is still worth showing without it.
+		return obj.Ingredients, nil
 	}
+	return ingredients, nil
+}
 
-	return posts[start:end], nil
+// TTLSecondsRemaining is the resolver for the ttlSecondsRemaining field.
+func (r *recipeResolver) TTLSecondsRemaining(ctx context.Context, obj *model.Recipe) (*int32, error) {
+	return r.RecipeTTLRemaining(obj, time.Now()), nil
 }
 
-// Leaderboard is the resolver for the leaderboard field.
-func (r *queryResolver) Leaderboard(ctx context.Context, top *int32) ([]*model.LeaderboardEntry, error) {
-	limit := 5
-	if top != nil {
-		limit = int(*top)
+// DietTags is the resolver for the dietTags field.
+func (r *recipeResolver) DietTags(ctx context.Context, obj *model.Recipe) ([]model.DietTag, error) {
+	return logic.RecipeDietTags(obj), nil
+}
+
+// DietWarnings is the resolver for the dietWarnings field.
+func (r *recipeResolver) DietWarnings(ctx context.Context, obj *model.Recipe) ([]string, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.RecipeDietWarnings(ctx, uid, obj), nil
+}
+
+// DietTags is the resolver for the dietTags field.
+func (r *recipeSnapshotResolver) DietTags(ctx context.Context, obj *model.RecipeSnapshot) ([]model.DietTag, error) {
+	return logic.SnapshotDietTags(obj), nil
+}
+
+// FridgeChanged is the resolver for the fridgeChanged field.
+func (r *subscriptionResolver) FridgeChanged(ctx context.Context, fridgeID string) (<-chan *model.Fridge, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.SubscribeFridge(ctx, uid, fridgeID)
+}
+
+// PostUpdated is the resolver for the postUpdated field.
+func (r *subscriptionResolver) PostUpdated(ctx context.Context, postID string) (<-chan *model.Post, error) {
+	if _, err := r.ResolveUserID(ctx); err != nil {
+		return nil, err
+	}
+
+	return r.SubscribePost(ctx, postID)
+}
+
+// NotificationReceived is the resolver for the notificationReceived field.
+func (r *subscriptionResolver) NotificationReceived(ctx context.Context) (<-chan *model.Notification, error) {
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil {
+		return nil, err
 	}
-	return r.FetchLeaderboard(ctx, limit)
+
+	return r.SubscribeNotifications(ctx, uid)
 }
 
+// Gamification is the resolver for the gamification field.
+func (r *userResolver) Gamification(ctx context.Context, obj *model.User) (*model.GamificationProfile, error) {
+	if obj.Gamification == nil {
+		obj.Gamification = &model.GamificationProfile{CurrentLevel: 1, NextLevelThreshold: 100, Badges: []string{}}
+	}
+
+	// What a user wasted is only shown to that user.
+	uid, err := r.ResolveUserID(ctx)
+	if err != nil || uid != obj.ID {
+		return obj.Gamification, nil
+	}
+
+	wasted, err := r.WastedMoneyYTD(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	profile := *obj.Gamification
+	profile.WastedMoneyYtd = &wasted
+	return &profile, nil
+}
+
+// EarnedBadge returns EarnedBadgeResolver implementation.
+func (r *Resolver) EarnedBadge() EarnedBadgeResolver { return &earnedBadgeResolver{r} }
+
+// Fridge returns FridgeResolver implementation.
+func (r *Resolver) Fridge() FridgeResolver { return &fridgeResolver{r} }
+
+// MealSlot returns MealSlotResolver implementation.
+func (r *Resolver) MealSlot() MealSlotResolver { return &mealSlotResolver{r} }
+
 // Mutation returns MutationResolver implementation.
 func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
 
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
+// Recipe returns RecipeResolver implementation.
+func (r *Resolver) Recipe() RecipeResolver { return &recipeResolver{r} }
+
+// RecipeSnapshot returns RecipeSnapshotResolver implementation.
+func (r *Resolver) RecipeSnapshot() RecipeSnapshotResolver { return &recipeSnapshotResolver{r} }
+
+// Subscription returns SubscriptionResolver implementation.
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
+// User returns UserResolver implementation.
+func (r *Resolver) User() UserResolver { return &userResolver{r} }
+
+type earnedBadgeResolver struct{ *Resolver }
+type fridgeResolver struct{ *Resolver }
+type mealSlotResolver struct{ *Resolver }
 type mutationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
+type recipeResolver struct{ *Resolver }
+type recipeSnapshotResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
+type userResolver struct{ *Resolver }
@@ -0,0 +1,155 @@
+package diet
+
+import "github.com/mariocosenza/mocc/graph/model"
+
+var (
+	gluten    = []model.DietTag{model.DietTagGluten}
+	lactose   = []model.DietTag{model.DietTagLactose}
+	eggs      = []model.DietTag{model.DietTagEggs}
+	nuts      = []model.DietTag{model.DietTagNuts}
+	peanuts   = []model.DietTag{model.DietTagPeanuts}
+	soy       = []model.DietTag{model.DietTagSoy}
+	sesame    = []model.DietTag{model.DietTagSesame}
+	fish      = []model.DietTag{model.DietTagFish}
+	shellfish = []model.DietTag{model.DietTagShellfish}
+	meat      = []model.DietTag{model.DietTagMeat}
+	pork      = []model.DietTag{model.DietTagMeat, model.DietTagPork}
+	alcohol   = []model.DietTag{model.DietTagAlcohol}
+	honey     = []model.DietTag{model.DietTagHoney}
+
+	animal     = []model.DietTag{model.DietTagMeat, model.DietTagPork, model.DietTagFish, model.DietTagShellfish}
+	seafood    = []model.DietTag{model.DietTagFish, model.DietTagShellfish}
+	vegan      = []model.DietTag{model.DietTagMeat, model.DietTagPork, model.DietTagFish, model.DietTagShellfish, model.DietTagLactose, model.DietTagEggs, model.DietTagHoney}
+	halal      = []model.DietTag{model.DietTagPork, model.DietTagAlcohol}
+	kosher     = []model.DietTag{model.DietTagPork, model.DietTagShellfish}
+	glutenMilk = []model.DietTag{model.DietTagGluten, model.DietTagLactose}
+	glutenEggs = []model.DietTag{model.DietTagGluten, model.DietTagEggs}
+	nutsMilk   = []model.DietTag{model.DietTagNuts, model.DietTagLactose}
+	soyGluten  = []model.DietTag{model.DietTagSoy, model.DietTagGluten}
+	beer       = []model.DietTag{model.DietTagGluten, model.DietTagAlcohol}
+)
+
+// words tags single words of product names, in Italian and English, with
+// accents folded.
+var words = map[string][]model.DietTag{
+	"farina": gluten, "farine": gluten, "flour": gluten,
+	"pane": gluten, "pan": gluten, "bread": gluten, "pangrattato": gluten, "breadcrumbs": gluten,
+	"pasta": gluten, "spaghetti": gluten, "penne": gluten, "fusilli": gluten, "rigatoni": gluten,
+	"tagliatelle": glutenEggs, "fettuccine": glutenEggs, "lasagne": glutenEggs, "lasagna": glutenEggs,
+	"tortellini": glutenEggs, "ravioli": glutenEggs, "noodles": gluten, "gnocchi": gluten,
+	"couscous": gluten, "orzo": gluten, "farro": gluten, "segale": gluten, "rye": gluten,
+	"barley": gluten, "grano": gluten, "frumento": gluten, "wheat": gluten, "semola": gluten,
+	"semolino": gluten, "semolina": gluten, "seitan": gluten, "malto": gluten, "malt": gluten,
+	"biscotti": gluten, "biscotto": gluten, "biscuits": gluten, "cracker": gluten, "crackers": gluten,
+	"grissini": gluten, "pizza": gluten, "piadina": gluten, "focaccia": gluten,
+
+	"latte": lactose, "milk": lactose, "burro": lactose, "butter": lactose,
+	"panna": lactose, "cream": lactose, "formaggio": lactose, "formaggi": lactose, "cheese": lactose,
+	"mozzarella": lactose, "parmigiano": lactose, "parmesan": lactose, "grana": lactose,
+	"pecorino": lactose, "ricotta": lactose, "mascarpone": lactose, "gorgonzola": lactose,
+	"stracchino": lactose, "scamorza": lactose, "provola": lactose, "fontina": lactose,
+	"emmental": lactose, "burrata": lactose, "stracciatella": lactose, "yogurt": lactose,
+	"besciamella": glutenMilk, "bechamel": glutenMilk,
+
+	"uovo": eggs, "uova": eggs, "egg": eggs, "eggs": eggs, "albume": eggs, "albumi": eggs,
+	"tuorlo": eggs, "tuorli": eggs, "maionese": eggs, "mayonnaise": eggs, "mayo": eggs,
+
+	"noce": nuts, "noci": nuts, "walnut": nuts, "walnuts": nuts, "nocciola": nuts, "nocciole": nuts,
+	"hazelnut": nuts, "hazelnuts": nuts, "mandorla": nuts, "mandorle": nuts, "almond": nuts,
+	"almonds": nuts, "pistacchio": nuts, "pistacchi": nuts, "pistachio": nuts, "pistachios": nuts,
+	"anacardo": nuts, "anacardi": nuts, "cashew": nuts, "cashews": nuts, "pinolo": nuts,
+	"pinoli": nuts, "nut": nuts, "nuts": nuts, "pecan": nuts, "pecans": nuts, "macadamia": nuts,
+	"pesto": nutsMilk, "nutella": nutsMilk,
+
+	"arachide": peanuts, "arachidi": peanuts, "peanut": peanuts, "peanuts": peanuts, "noccioline": peanuts,
+
+	"soia": soy, "soy": soy, "soya": soy, "tofu": soy, "edamame": soy, "tempeh": soy, "miso": soy,
+
+	"sesamo": sesame, "sesame": sesame, "tahini": sesame, "tahina": sesame,
+
+	"pesce": fish, "fish": fish, "tonno": fish, "tuna": fish, "salmone": fish, "salmon": fish,
+	"merluzzo": fish, "cod": fish, "baccala": fish, "acciuga": fish, "acciughe": fish, "alici": fish,
+	"anchovy": fish, "anchovies": fish, "sardina": fish, "sardine": fish, "sardines": fish,
+	"orata": fish, "branzino": fish, "spigola": fish, "trota": fish, "trout": fish, "sgombro": fish,
+	"mackerel": fish, "swordfish": fish, "bottarga": fish,
+
+	"gambero": shellfish, "gamberi": shellfish, "gamberetti": shellfish, "shrimp": shellfish,
+	"shrimps": shellfish, "prawn": shellfish, "prawns": shellfish, "cozza": shellfish,
+	"cozze": shellfish, "mussels": shellfish, "vongola": shellfish, "vongole": shellfish,
+	"clams": shellfish, "calamaro": shellfish, "calamari": shellfish, "squid": shellfish,
+	"polpo": shellfish, "octopus": shellfish, "seppia": shellfish, "seppie": shellfish,
+	"cuttlefish": shellfish, "aragosta": shellfish, "lobster": shellfish, "astice": shellfish,
+	"granchio": shellfish, "crab": shellfish, "scampi": shellfish, "ostriche": shellfish,
+	"oysters": shellfish, "capesante": shellfish, "scallops": shellfish,
+
+	"carne": meat, "meat": meat, "manzo": meat, "beef": meat, "vitello": meat, "veal": meat,
+	"pollo": meat, "chicken": meat, "tacchino": meat, "turkey": meat, "agnello": meat,
+	"lamb": meat, "coniglio": meat, "rabbit": meat, "anatra": meat, "duck": meat,
+	"macinato": meat, "bresaola": meat, "ragu": meat, "hamburger": meat, "polpette": meat,
+	"meatballs": meat, "cinghiale": meat, "bistecca": meat, "steak": meat,
+
+	"maiale": pork, "pork": pork, "prosciutto": pork, "ham": pork, "pancetta": pork,
+	"guanciale": pork, "bacon": pork, "lardo": pork, "strutto": pork, "lard": pork,
+	"salame": pork, "salami": pork, "mortadella": pork, "speck": pork, "cotechino": pork,
+	"zampone": pork, "nduja": pork, "salsiccia": pork, "salsicce": pork, "sausage": pork,
+	"sausages": pork, "wurstel": pork, "chorizo": pork,
+
+	"vino": alcohol, "wine": alcohol, "birra": beer, "beer": beer, "rum": alcohol,
+	"vodka": alcohol, "whisky": alcohol, "brandy": alcohol, "cognac": alcohol, "grappa": alcohol,
+	"liquore": alcohol, "liquor": alcohol, "marsala": alcohol, "limoncello": alcohol,
+	"sake": alcohol, "amaretto": alcohol, "prosecco": alcohol, "champagne": alcohol,
+	"spumante": alcohol,
+
+	"miele": honey, "honey": honey,
+}
+
+// phrases overrides the words they are made of: a nil entry marks products
+// that only sound like something they do not contain.
+var phrases = map[string][]model.DietTag{
+	"farina di riso": nil, "farina di mais": nil, "farina di ceci": nil, "farina di castagne": nil,
+	"rice flour": nil, "corn flour": nil, "chickpea flour": nil,
+	"farina di mandorle": nuts, "almond flour": nuts,
+	"pasta di riso": nil, "rice noodles": nil, "pane senza glutine": nil,
+	"senza glutine": nil, "gluten free": nil, "senza lattosio": nil, "lactose free": nil,
+	"latte di cocco": nil, "coconut milk": nil, "latte di riso": nil, "rice milk": nil,
+	"latte di avena": gluten, "oat milk": gluten,
+	"latte di soia": soy, "soy milk": soy, "panna di soia": soy,
+	"latte di mandorla": nuts, "almond milk": nuts,
+	"burro di arachidi": peanuts, "peanut butter": peanuts, "burro di cacao": nil, "cocoa butter": nil,
+	"noce moscata": nil, "nutmeg": nil, "noce di cocco": nil, "coconut": nil,
+	"salsa di soia": soyGluten, "soy sauce": soyGluten,
+	"aceto di vino": nil, "wine vinegar": nil,
+	"brodo di carne": meat, "brodo vegetale": nil, "vegetable broth": nil,
+	"pasta sfoglia": glutenMilk, "pasta frolla": []model.DietTag{model.DietTagGluten, model.DietTagLactose, model.DietTagEggs},
+	"pasta all uovo": glutenEggs, "egg pasta": glutenEggs,
+	"pesce spada": fish, "frutti di mare": shellfish, "seafood": seafood,
+}
+
+// diets maps the names of diets and intolerances, as users write them, to
+// the tags they rule out.
+var diets = map[string][]model.DietTag{
+	"vegetariano": animal, "vegetariana": animal, "vegetariani": animal, "vegetarian": animal, "veggie": animal,
+	"vegano": vegan, "vegana": vegan, "vegani": vegan, "vegan": vegan,
+	"pescetariano": meat, "pescetariana": meat, "pescatarian": meat,
+	"celiaco": gluten, "celiaca": gluten, "celiachia": gluten, "celiac": gluten, "glutine": gluten,
+	"lattosio": lactose, "latticini": lactose, "dairy": lactose,
+	"crostacei": shellfish, "molluschi": shellfish, "shellfish": shellfish,
+	"alcol": alcohol, "alcool": alcohol, "alcolici": alcohol, "astemio": alcohol, "astemia": alcohol,
+	"halal": halal, "kosher": kosher,
+}
+
+var labels = map[model.DietTag]string{
+	model.DietTagGluten:    "glutine",
+	model.DietTagLactose:   "lattosio",
+	model.DietTagEggs:      "uova",
+	model.DietTagNuts:      "frutta a guscio",
+	model.DietTagPeanuts:   "arachidi",
+	model.DietTagSoy:       "soia",
+	model.DietTagSesame:    "sesamo",
+	model.DietTagFish:      "pesce",
+	model.DietTagShellfish: "crostacei e molluschi",
+	model.DietTagMeat:      "carne",
+	model.DietTagPork:      "maiale",
+	model.DietTagAlcohol:   "alcol",
+	model.DietTagHoney:     "miele",
+}
@@ -0,0 +1,114 @@
+// Package diet tags ingredients with the allergens and food groups they
+// contain, and works out which tags a user's dietary restrictions rule out.
+package diet
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mariocosenza/mocc/graph/model"
+)
+
+// accents folds the accented letters used in Italian, so "ragù" and "ragu"
+// read the same.
+var accents = strings.NewReplacer("à", "a", "è", "e", "é", "e", "ì", "i", "ò", "o", "ó", "o", "ù", "u")
+
+// byLength lists the phrases longest first, so "farina di riso" is taken
+// before any shorter phrase inside it.
+var byLength = func() []string {
+	out := make([]string, 0, len(phrases))
+	for p := range phrases {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if len(out[i]) != len(out[j]) {
+			return len(out[i]) > len(out[j])
+		}
+		return out[i] < out[j]
+	})
+	return out
+}()
+
+func normalize(text string) string {
+	text = accents.Replace(strings.ToLower(text))
+	words := strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) })
+	return " " + strings.Join(words, " ") + " "
+}
+
+// Tags returns what the named products contain, in the order of
+// model.AllDietTag. Known phrases are read first and their words are not
+// looked at again, so "latte di cocco" is not taken for milk.
+func Tags(names ...string) []model.DietTag {
+	found := map[model.DietTag]bool{}
+	for _, name := range names {
+		addTags(found, normalize(name), words)
+	}
+	return ordered(found)
+}
+
+func addTags(found map[model.DietTag]bool, text string, dict map[string][]model.DietTag) {
+	for _, p := range byLength {
+		if strings.Contains(text, " "+p+" ") {
+			for _, t := range phrases[p] {
+				found[t] = true
+			}
+			text = strings.ReplaceAll(text, " "+p+" ", "  ")
+		}
+	}
+	for _, w := range strings.Fields(text) {
+		for _, t := range dict[w] {
+			found[t] = true
+		}
+	}
+}
+
+// Forbidden returns the tags ruled out by free-text restrictions such as
+// "vegetariano", "celiachia" or "allergia alle noci". Diets and intolerances
+// are recognised by name, and any product named in a restriction rules out
+// what it contains. Restrictions that mean nothing here are ignored.
+func Forbidden(restrictions []string) map[model.DietTag]bool {
+	forbidden := map[model.DietTag]bool{}
+	for _, r := range restrictions {
+		text := normalize(r)
+		for _, w := range strings.Fields(text) {
+			for _, t := range diets[w] {
+				forbidden[t] = true
+			}
+			if t := model.DietTag(strings.ToUpper(w)); t.IsValid() {
+				forbidden[t] = true
+			}
+		}
+		addTags(forbidden, text, words)
+	}
+	return forbidden
+}
+
+// Conflicts returns the tags that are forbidden.
+func Conflicts(tags []model.DietTag, forbidden map[model.DietTag]bool) []model.DietTag {
+	out := []model.DietTag{}
+	for _, t := range tags {
+		if forbidden[t] {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// Label names a tag in Italian, the way warnings show it.
+func Label(t model.DietTag) string {
+	if l, ok := labels[t]; ok {
+		return l
+	}
+	return strings.ToLower(t.String())
+}
+
+func ordered(found map[model.DietTag]bool) []model.DietTag {
+	out := []model.DietTag{}
+	for _, t := range model.AllDietTag {
+		if found[t] {
+			out = append(out, t)
+		}
+	}
+	return out
+}
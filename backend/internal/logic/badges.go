@@ -0,0 +1,182 @@
+package logic
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/mariocosenza/mocc/graph/model"
+)
+
+// badgeCatalog lists every badge that can be earned. A badge is earned once
+// the user's progress on its criterion reaches the threshold, and is kept
+// even if the progress later drops. Ids are stored on the profile, so they
+// must never change.
+var badgeCatalog = []*model.Badge{
+	{ID: "first-recipe", Title: "Primo piatto", Description: "Hai cucinato la tua prima ricetta", Criterion: model.BadgeCriterionRecipesCooked, Threshold: 1},
+	{ID: "cooked-10", Title: "Cuoco di casa", Description: "Hai cucinato 10 ricette", Criterion: model.BadgeCriterionRecipesCooked, Threshold: 10},
+	{ID: "cooked-50", Title: "Chef", Description: "Hai cucinato 50 ricette", Criterion: model.BadgeCriterionRecipesCooked, Threshold: 50},
+	{ID: "first-post", Title: "Prima condivisione", Description: "Hai condiviso la tua prima ricetta", Criterion: model.BadgeCriterionPostsShared, Threshold: 1},
+	{ID: "posts-10", Title: "Ispiratore", Description: "Hai condiviso 10 ricette", Criterion: model.BadgeCriterionPostsShared, Threshold: 10},
+	{ID: "likes-10", Title: "Apprezzato", Description: "Hai ricevuto 10 mi piace", Criterion: model.BadgeCriterionLikesReceived, Threshold: 10},
+	{ID: "likes-100", Title: "Amato dalla community", Description: "Hai ricevuto 100 mi piace", Criterion: model.BadgeCriterionLikesReceived, Threshold: 100},
+	{ID: "zero-waste-7", Title: "Settimana senza sprechi", Description: "Non hai sprecato nulla per 7 giorni", Criterion: model.BadgeCriterionZeroWasteDays, Threshold: 7},
+	{ID: "zero-waste-30", Title: "Mese senza sprechi", Description: "Non hai sprecato nulla per 30 giorni", Criterion: model.BadgeCriterionZeroWasteDays, Threshold: 30},
+	{ID: "first-shared-fridge", Title: "Frigo condiviso", Description: "Condividi un frigo con qualcun altro", Criterion: model.BadgeCriterionFridgesShared, Threshold: 1},
+}
+
+// BadgeCatalog returns every badge that can be earned.
+func BadgeCatalog() []*model.Badge {
+	return badgeCatalog
+}
+
+// BadgeByID looks a badge up in the catalog, nil when there is none.
+func BadgeByID(id string) *model.Badge {
+	for _, badge := range badgeCatalog {
+		if badge.ID == id {
+			return badge
+		}
+	}
+	return nil
+}
+
+// EvaluateBadges awards the user every badge on the given criteria they have
+// reached and not earned yet, and notifies them of each. It runs after the
+// event that may have moved the progress, so failures are logged and never
+// undo that event.
+func (l *Logic) EvaluateBadges(ctx context.Context, userID string, criteria ...model.BadgeCriterion) []*model.EarnedBadge {
+	logger := l.GetLogger()
+
+	user, err := l.FetchUser(ctx, userID)
+	if err != nil {
+		logger.Printf("level=warn op=EvaluateBadges stage=get_user userId=%s err=%v", userID, err)
+		return nil
+	}
+	normalizeGamification(user)
+	profile := user.Gamification
+	now := time.Now().UTC()
+
+	changed := false
+	if profile.BadgesTrackedSince == nil {
+		profile.BadgesTrackedSince = toPtr(now.Format(time.RFC3339))
+		changed = true
+	}
+
+	progress := map[model.BadgeCriterion]int{}
+	awarded := []*model.EarnedBadge{}
+	for _, badge := range badgeCatalog {
+		if slices.Contains(profile.Badges, badge.ID) || !slices.Contains(criteria, badge.Criterion) {
+			continue
+		}
+		value, ok := progress[badge.Criterion]
+		if !ok {
+			value, err = l.badgeProgress(ctx, user, badge.Criterion, now)
+			if err != nil {
+				logger.Printf("level=warn op=EvaluateBadges stage=progress userId=%s criterion=%s err=%v", userID, badge.Criterion, err)
+				value = -1
+			}
+			progress[badge.Criterion] = value
+		}
+		if value < int(badge.Threshold) || !l.claimBadge(ctx, userID, badge.ID) {
+			continue
+		}
+
+		earned := &model.EarnedBadge{BadgeID: badge.ID, EarnedAt: now.Format(time.RFC3339)}
+		profile.Badges = append(profile.Badges, badge.ID)
+		profile.EarnedBadges = append(profile.EarnedBadges, earned)
+		awarded = append(awarded, earned)
+		changed = true
+	}
+	if !changed {
+		return awarded
+	}
+
+	if err := l.UpsertUser(ctx, user); err != nil {
+		logger.Printf("level=error op=EvaluateBadges stage=save_user userId=%s err=%v", userID, err)
+		for _, earned := range awarded {
+			l.Redis.Del(ctx, BadgeClaimPrefix+userID+":"+earned.BadgeID)
+		}
+		return nil
+	}
+	l.SetUserCache(ctx, user)
+
+	for _, earned := range awarded {
+		logger.Printf("level=info op=EvaluateBadges stage=awarded userId=%s badgeId=%s", userID, earned.BadgeID)
+		l.Notify(ctx, userID, model.NotificationTypeBadgeUnlocked, earned.BadgeID, "Hai sbloccato il badge %s", BadgeByID(earned.BadgeID).Title)
+	}
+	return awarded
+}
+
+// claimBadge keeps two evaluations running side by side from both awarding,
+// and notifying, the same badge. The claim only has to outlive them: once
+// the badge is saved on the profile it is not looked at again. A claim that
+// cannot be checked is taken as granted.
+func (l *Logic) claimBadge(ctx context.Context, userID, badgeID string) bool {
+	ok, err := l.Redis.SetNX(ctx, BadgeClaimPrefix+userID+":"+badgeID, 1, BadgeClaimTTL).Result()
+	if err != nil {
+		l.GetLogger().Printf("level=warn op=EvaluateBadges stage=redis_claim userId=%s badgeId=%s err=%v", userID, badgeID, err)
+		return true
+	}
+	return ok
+}
+
+// badgeProgress measures how far the user got on a criterion.
+func (l *Logic) badgeProgress(ctx context.Context, user *model.User, criterion model.BadgeCriterion, now time.Time) (int, error) {
+	switch criterion {
+	case model.BadgeCriterionRecipesCooked:
+		cooked := model.RecipeStatusCooked
+		recipes, err := l.Repos.Recipes.ListByAuthor(ctx, user.ID, &cooked)
+		return len(recipes), err
+
+	case model.BadgeCriterionPostsShared:
+		posts, err := l.Repos.Posts.ListByAuthor(ctx, user.ID)
+		return len(posts), err
+
+	case model.BadgeCriterionLikesReceived:
+		posts, err := l.Repos.Posts.ListByAuthor(ctx, user.ID)
+		likes := 0
+		for _, post := range posts {
+			for _, id := range post.LikedBy {
+				if id != user.ID {
+					likes++
+				}
+			}
+		}
+		return likes, err
+
+	case model.BadgeCriterionZeroWasteDays:
+		return l.zeroWasteDays(ctx, user, now)
+
+	case model.BadgeCriterionFridgesShared:
+		fridges, err := l.Repos.Fridges.ListForUser(ctx, user.ID)
+		shared := 0
+		for _, fridge := range fridges {
+			if len(fridge.OwnerID) > 1 {
+				shared++
+			}
+		}
+		return shared, err
+	}
+	return 0, nil
+}
+
+// zeroWasteDays counts the whole days since the user last wasted something,
+// or since badges started being tracked when they never did: older activity
+// is not known well enough to count.
+func (l *Logic) zeroWasteDays(ctx context.Context, user *model.User, now time.Time) (int, error) {
+	since, err := time.Parse(time.RFC3339, *user.Gamification.BadgesTrackedSince)
+	if err != nil {
+		return 0, err
+	}
+	events, err := l.Repos.Waste.ListByUser(ctx, user.ID, since.Format(time.RFC3339), now.Add(time.Second).Format(time.RFC3339))
+	if err != nil {
+		return 0, err
+	}
+	if len(events) > 0 {
+		last, err := time.Parse(time.RFC3339, events[len(events)-1].WastedAt)
+		if err == nil && last.After(since) {
+			since = last
+		}
+	}
+	return int(now.Sub(since) / (24 * time.Hour)), nil
+}
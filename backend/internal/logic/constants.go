@@ -3,18 +3,79 @@ package logic
 import "time"
 
 const (
-	UserCacheDuration    = 24 * time.Hour
-	CosmosDatabase       = "mocc-db"
-	ContainerUsers       = "Users"
-	ContainerInventory   = "Inventory"   // PK: /fridgeId
-	ContainerCookbook    = "Cookbook"    // PK: /authorId
-	ContainerSocial      = "Social"      // PK: /type
-	ContainerHistory     = "History"     // PK: /authorId
-	ContainerStaging     = "Staging"     // PK: /id
-	ContainerLeaderboard = "Leaderboard" // PK: /period
+	UserCacheDuration = 24 * time.Hour
+
+	// FridgeWriteAttempts bounds the read-modify-write cycles run against a
+	// fridge or inventory item before giving up on a document that keeps
+	// changing underneath us.
+	FridgeWriteAttempts = 5
+	FridgeWriteBackoff  = 20 * time.Millisecond
 
 	ErrItemNotFound = "item not found"
 
+	// DefaultRecipeLockTTL is how long a recipe may stay IN_PREPARATION,
+	// holding its ingredients, before the reaper releases them.
+	DefaultRecipeLockTTL = 2 * time.Hour
+	LockReaperInterval   = time.Minute
+	LockReaperLeaseKey   = "locks:reaper"
+
+	// DefaultUndoWindow is how long an inventory operation can be undone.
+	DefaultUndoWindow   = 10 * time.Minute
+	UndoOperationPrefix = "undo:"
+
+	// DefaultInviteTTL is how long a shared fridge invite code stays valid
+	// when the inviter does not say; MaxInviteTTL is the longest they can ask.
+	DefaultInviteTTL   = time.Hour
+	MaxInviteTTL       = 7 * 24 * time.Hour
+	FridgeInvitePrefix = "invite:code:"
+
+	// MaxPageSize caps the first argument of connection fields.
+	MaxPageSize = 100
+	// FeedDietScanPages caps the pages of posts one diet-filtered feed
+	// request reads while looking for posts the user can eat.
+	FeedDietScanPages = 5
+
+	// BadgeClaimPrefix keys the short-lived claims that keep a badge from
+	// being awarded twice by concurrent evaluations.
+	BadgeClaimPrefix = "badge:claim:"
+	BadgeClaimTTL    = time.Minute
+
+	// MaxFriends caps the friends list, which the friends leaderboard reads
+	// in full, along with each friend's own list.
+	MaxFriends = 200
+
+	// PostSharePoints are the eco-points earned by sharing a recipe.
+	PostSharePoints = 10
+
 	StagingUserPrefix = "staging:user:"
 	LeaderboardGlobal = "leaderboard:global"
+
+	// Leaderboard scores are partitioned by period: all time, and one
+	// partition per ISO week and per month, named after the prefix and key.
+	LeaderboardKeyPrefix         = "leaderboard:"
+	LeaderboardPeriodGlobal      = "global"
+	LeaderboardPeriodWeekPrefix  = "week:"
+	LeaderboardPeriodMonthPrefix = "month:"
+	// LeaderboardNicknames is the Redis hash of the nickname of every ranked
+	// user, read alongside the scores.
+	LeaderboardNicknames = "leaderboard:nicknames"
+
+	// LeaderboardRolloverInterval is how often closed weeks and months are
+	// looked for and archived, keeping the top LeaderboardArchiveSize
+	// standings and, as winners, everyone ranked within LeaderboardWinners.
+	LeaderboardRolloverInterval = 10 * time.Minute
+	LeaderboardRolloverLeaseKey = "leaderboard:rollover"
+	LeaderboardArchiveSize      = 100
+	LeaderboardWinners          = 3
+	// MaxLeaderboardNeighbours caps how many entries myLeaderboardPosition
+	// returns on either side of the user.
+	MaxLeaderboardNeighbours = 10
+	// MaxLeaderboardArchives caps how many closed periods one request reads,
+	// a year of weeks.
+	MaxLeaderboardArchives = 52
+
+	// Redis pub/sub channels fanning subscription events out to every replica.
+	EventsFridgePrefix       = "events:fridge:"
+	EventsPostPrefix         = "events:post:"
+	EventsNotificationPrefix = "events:notification:"
 )
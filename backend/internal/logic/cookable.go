@@ -0,0 +1,228 @@
+package logic
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/mariocosenza/mocc/graph/model"
+	"github.com/mariocosenza/mocc/internal/units"
+)
+
+const (
+	// feedCookableWindow is how many of the latest posts are considered when
+	// ranking the feed.
+	feedCookableWindow = 200
+	// urgencyDays is how close to expiry an item starts boosting the recipes
+	// that would use it.
+	urgencyDays = 7
+	// expiringSoonDays marks the items counted in expiringItemsUsed.
+	expiringSoonDays = 3
+)
+
+// RankCookableRecipes scores the user's recipes, or the recipes shared in the
+// feed, by how much of them the fridge covers right now, favouring recipes
+// that use up items close to expiry. With respectDiet set, recipes that go
+// against the user's dietary restrictions are left out.
+func (l *Logic) RankCookableRecipes(ctx context.Context, userID string, source model.RecipeSource, limit *int32, respectDiet bool) ([]*model.CookableRecipe, error) {
+	logger := l.GetLogger()
+
+	items, err := l.userFridgeItems(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	var forbidden map[model.DietTag]bool
+	if respectDiet {
+		forbidden = l.forbiddenDietTags(ctx, userID)
+	}
+
+	ranked := []*model.CookableRecipe{}
+	switch source {
+	case model.RecipeSourceFeed:
+		page, err := l.Repos.Posts.ListPage(ctx, feedCookableWindow, "")
+		if err != nil {
+			logger.Printf("level=error op=CookableRecipes stage=query_posts err=%v", err)
+			return nil, err
+		}
+		for _, edge := range page.Edges {
+			post := edge.Node
+			if post.RecipeSnapshot == nil || !fitsDiet(SnapshotDietTags(post.RecipeSnapshot), forbidden) {
+				continue
+			}
+			entry := scoreCookable(snapshotIngredients(post.RecipeSnapshot), "", items, now)
+			entry.Post = post
+			entry.Title = post.RecipeSnapshot.Title
+			ranked = append(ranked, entry)
+		}
+	default:
+		recipes, err := l.FetchRecipes(ctx, userID, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, recipe := range recipes {
+			if recipe.Status == model.RecipeStatusCooked || !fitsDiet(RecipeDietTags(recipe), forbidden) {
+				continue
+			}
+			entry := scoreCookable(recipe.Ingredients, recipe.ID, items, now)
+			entry.Recipe = recipe
+			entry.Title = recipe.Title
+			ranked = append(ranked, entry)
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Score > ranked[j].Score })
+	if n := pageSize(limit, 10); len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	return ranked, nil
+}
+
+func snapshotIngredients(snapshot *model.RecipeSnapshot) []*model.RecipeIngredient {
+	ingredients := make([]*model.RecipeIngredient, 0, len(snapshot.Ingredients))
+	for _, ing := range snapshot.Ingredients {
+		ingredients = append(ingredients, &model.RecipeIngredient{Name: ing.Name, Quantity: ing.Quantity, Unit: ing.Unit})
+	}
+	return ingredients
+}
+
+// scoreCookable works out how much of each ingredient the fridge can supply.
+// Several ingredients may draw on the same item, so what each one takes is
+// kept off the others. The score is the share of the recipe covered, out of
+// 100, plus up to 10 points for every item used that expires within a week.
+func scoreCookable(ingredients []*model.RecipeIngredient, recipeID string, items []*model.InventoryItem, now time.Time) *model.CookableRecipe {
+	left := map[string]float64{}
+	remaining := func(item *model.InventoryItem) float64 {
+		if v, ok := left[item.ID]; ok {
+			return v
+		}
+		v := item.VirtualAvailable
+		for _, lock := range item.ActiveLocks {
+			if recipeID != "" && lock.RecipeID == recipeID {
+				v += lock.Amount
+			}
+		}
+		return v
+	}
+
+	entry := &model.CookableRecipe{MissingIngredients: []*model.MissingIngredient{}}
+	counted, covered, urgency := 0, 0.0, 0.0
+	used := map[string]bool{}
+
+	for _, ing := range ingredients {
+		candidates := matchIngredient(ing, items, recipeID)
+		if ing.InventoryItemID != nil {
+			candidates = linkedFirst(candidates, items, *ing.InventoryItemID)
+		}
+
+		if units.IsNonDepleting(ing.Unit) {
+			// QB ingredients never limit a recipe, as long as there is some.
+			if len(candidates) == 0 {
+				entry.MissingIngredients = append(entry.MissingIngredients, &model.MissingIngredient{Name: ing.Name, Quantity: ing.Quantity, Unit: ing.Unit})
+			} else {
+				used[candidates[0].Item.ID] = true
+			}
+			continue
+		}
+
+		counted++
+		need := drawStock(ing.Quantity, ing.Unit, candidates, remaining, left, used)
+
+		if ing.Quantity > 0 {
+			covered += math.Min(1, (ing.Quantity-math.Max(need, 0))/ing.Quantity)
+		} else {
+			covered++
+		}
+		if need > 0.001 {
+			entry.MissingIngredients = append(entry.MissingIngredients, &model.MissingIngredient{Name: ing.Name, Quantity: need, Unit: ing.Unit})
+		}
+	}
+
+	for _, item := range items {
+		if !used[item.ID] {
+			continue
+		}
+		days, ok := daysToExpiry(item, now)
+		if !ok {
+			continue
+		}
+		if days <= expiringSoonDays {
+			entry.ExpiringItemsUsed++
+		}
+		if days < urgencyDays {
+			urgency += float64(urgencyDays-max(days, 0)) / urgencyDays
+		}
+	}
+
+	entry.Coverage = 1
+	if counted > 0 {
+		entry.Coverage = covered / float64(counted)
+	}
+	entry.Score = 100*entry.Coverage + 10*urgency
+	return entry
+}
+
+// drawStock takes need, given in unit, from the candidates in turn and
+// returns what none of them could supply. What each item gives is kept off
+// left, so later ingredients drawing on it see less, and the item is marked
+// in used.
+func drawStock(need float64, unit model.Unit, candidates []*model.IngredientMatchCandidate, remaining func(*model.InventoryItem) float64, left map[string]float64, used map[string]bool) float64 {
+	for _, c := range candidates {
+		if need <= 0.001 {
+			break
+		}
+		if c.Item.Quantity == nil {
+			continue
+		}
+		product := units.ProductOf(c.Item)
+		have, err := units.Required(need, unit, c.Item.Quantity.Unit, product)
+		if err != nil {
+			continue
+		}
+		avail := remaining(c.Item)
+		if avail <= 0.001 {
+			continue
+		}
+		take := math.Min(have, avail)
+		left[c.Item.ID] = avail - take
+		used[c.Item.ID] = true
+
+		// Back to the ingredient's unit to know what is still needed.
+		taken, err := units.Convert(take, c.Item.Quantity.Unit, unit, product)
+		if err != nil {
+			continue
+		}
+		need -= taken
+	}
+	return need
+}
+
+// linkedFirst puts the item the user linked to the ingredient ahead of the
+// name matches, even when its name would not match.
+func linkedFirst(candidates []*model.IngredientMatchCandidate, items []*model.InventoryItem, itemID string) []*model.IngredientMatchCandidate {
+	for i, c := range candidates {
+		if c.Item.ID == itemID {
+			out := append([]*model.IngredientMatchCandidate{c}, candidates[:i]...)
+			return append(out, candidates[i+1:]...)
+		}
+	}
+	for _, item := range items {
+		if item.ID == itemID && item.Status == model.ItemStatusAvailable {
+			return append([]*model.IngredientMatchCandidate{{Item: item, Score: 1}}, candidates...)
+		}
+	}
+	return candidates
+}
+
+// daysToExpiry counts whole days from today to the item's expiry date.
+func daysToExpiry(item *model.InventoryItem, now time.Time) (int, bool) {
+	exp, err := time.Parse(time.RFC3339, item.ExpiryDate)
+	if err != nil {
+		return 0, false
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	day := time.Date(exp.Year(), exp.Month(), exp.Day(), 0, 0, 0, 0, time.UTC)
+	return int(day.Sub(today).Hours() / 24), true
+}
@@ -0,0 +1,69 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mariocosenza/mocc/graph/model"
+	"github.com/mariocosenza/mocc/internal/diet"
+)
+
+// RecipeDietTags returns what the recipe's ingredients contain.
+func RecipeDietTags(recipe *model.Recipe) []model.DietTag {
+	names := make([]string, 0, len(recipe.Ingredients))
+	for _, ing := range recipe.Ingredients {
+		names = append(names, ing.Name)
+	}
+	return diet.Tags(names...)
+}
+
+// SnapshotDietTags is RecipeDietTags for a recipe shared in a post.
+func SnapshotDietTags(snapshot *model.RecipeSnapshot) []model.DietTag {
+	names := make([]string, 0, len(snapshot.Ingredients))
+	for _, ing := range snapshot.Ingredients {
+		names = append(names, ing.Name)
+	}
+	return diet.Tags(names...)
+}
+
+// forbiddenDietTags returns the tags ruled out by the user's dietary
+// restrictions, nil when there are none.
+func (l *Logic) forbiddenDietTags(ctx context.Context, userID string) map[model.DietTag]bool {
+	user, err := l.FetchUser(ctx, userID)
+	if err != nil || user.Preferences == nil || len(user.Preferences.DietaryRestrictions) == 0 {
+		return nil
+	}
+	forbidden := diet.Forbidden(user.Preferences.DietaryRestrictions)
+	if len(forbidden) == 0 {
+		return nil
+	}
+	return forbidden
+}
+
+// RecipeDietWarnings lists, ingredient by ingredient, what in the recipe goes
+// against the user's dietary restrictions.
+func (l *Logic) RecipeDietWarnings(ctx context.Context, userID string, recipe *model.Recipe) []string {
+	warnings := []string{}
+	forbidden := l.forbiddenDietTags(ctx, userID)
+	if forbidden == nil {
+		return warnings
+	}
+	for _, ing := range recipe.Ingredients {
+		conflicts := diet.Conflicts(diet.Tags(ing.Name), forbidden)
+		if len(conflicts) == 0 {
+			continue
+		}
+		labels := make([]string, 0, len(conflicts))
+		for _, t := range conflicts {
+			labels = append(labels, diet.Label(t))
+		}
+		warnings = append(warnings, fmt.Sprintf("%s contiene %s, escluso dalle tue restrizioni alimentari", ing.Name, strings.Join(labels, ", ")))
+	}
+	return warnings
+}
+
+// fitsDiet reports whether nothing in tags is forbidden.
+func fitsDiet(tags []model.DietTag, forbidden map[model.DietTag]bool) bool {
+	return len(diet.Conflicts(tags, forbidden)) == 0
+}
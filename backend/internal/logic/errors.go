@@ -0,0 +1,40 @@
+package logic
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mariocosenza/mocc/internal/units"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Error codes exposed to clients in the "code" extension of GraphQL errors.
+const (
+	ErrCodeConflict          = "CONFLICT"
+	ErrCodeBadCursor         = "BAD_CURSOR"
+	ErrCodeIncompatibleUnits = "INCOMPATIBLE_UNITS"
+	ErrCodeForbidden         = "FORBIDDEN"
+)
+
+// ErrNoSuchItem is returned when an inventory item does not exist in the
+// caller's fridge.
+var ErrNoSuchItem = errors.New(ErrItemNotFound)
+
+// ErrBlobStorageDisabled is returned by blob operations when the server runs
+// without blob storage, as it may with in-memory repositories.
+var ErrBlobStorageDisabled = errors.New("blob storage is not configured")
+
+func codedError(code, format string, args ...any) *gqlerror.Error {
+	return &gqlerror.Error{
+		Message:    fmt.Sprintf(format, args...),
+		Extensions: map[string]interface{}{"code": code},
+	}
+}
+
+// unitError reports a failed conversion for the named item to the client.
+func unitError(name string, err error) error {
+	if !errors.Is(err, units.ErrIncompatible) {
+		return err
+	}
+	return codedError(ErrCodeIncompatibleUnits, "%s: %v", name, err)
+}
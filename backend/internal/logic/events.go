@@ -0,0 +1,126 @@
+package logic
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mariocosenza/mocc/graph/model"
+	"github.com/mariocosenza/mocc/internal/repository"
+)
+
+// eventTimeLayout keeps a fixed number of fractional digits so event times
+// sort as strings, as the log is ordered by them.
+const eventTimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// itemEvent starts an inventory event of the given type, done by actorID.
+// The item fields are filled in once the change has been written.
+func itemEvent(eventType model.InventoryEventType, actorID string) *model.InventoryEvent {
+	return &model.InventoryEvent{Type: eventType, ActorID: actorID}
+}
+
+// recipeEvent starts an inventory event caused by a recipe.
+func recipeEvent(eventType model.InventoryEventType, actorID, recipeID string) *model.InventoryEvent {
+	event := itemEvent(eventType, actorID)
+	event.RecipeID = &recipeID
+	return event
+}
+
+// completeEvent fills in the event from the item before and after the
+// change. The amount is what the quantity moved by or, when it did not move,
+// what the locks did.
+func completeEvent(event *model.InventoryEvent, fridgeID string, before *model.InventoryItem, after *model.InventoryItem, write ItemWrite) {
+	event.FridgeID = fridgeID
+	event.ItemID = after.ID
+	event.ItemName = after.Name
+	if after.Quantity == nil {
+		return
+	}
+
+	unit := after.Quantity.Unit
+	event.Unit = &unit
+	event.QuantityAfter = toPtr(after.Quantity.Value)
+	if write == ItemDelete {
+		event.QuantityAfter = toPtr(0.0)
+	}
+	if before == nil {
+		event.QuantityBefore = toPtr(0.0)
+		event.Amount = toPtr(after.Quantity.Value)
+		return
+	}
+	if before.Quantity == nil || before.Quantity.Unit != unit {
+		return
+	}
+
+	event.QuantityBefore = toPtr(before.Quantity.Value)
+	moved := math.Abs(*event.QuantityBefore - *event.QuantityAfter)
+	if moved < 0.001 {
+		moved = math.Abs(before.VirtualAvailable - after.VirtualAvailable)
+	}
+	event.Amount = toPtr(math.Round(moved*1000) / 1000)
+}
+
+// appendEvent writes the event to the fridge's log. The change it describes
+// has already happened, so a failure is logged rather than returned.
+func (l *Logic) appendEvent(ctx context.Context, event *model.InventoryEvent) {
+	event.ID = uuid.New().String()
+	event.OccurredAt = time.Now().UTC().Format(eventTimeLayout)
+	if user, err := l.FetchUser(ctx, event.ActorID); err == nil {
+		event.ActorNickname = &user.Nickname
+	}
+
+	if err := l.Repos.Events.Append(ctx, event); err != nil {
+		l.GetLogger().Printf("level=error op=AppendInventoryEvent stage=write fridgeId=%s itemId=%s type=%s err=%v", event.FridgeID, event.ItemID, event.Type, err)
+	}
+}
+
+// FetchInventoryEvents returns a page of the log of the given fridge, or the
+// user's active one, newest first.
+func (l *Logic) FetchInventoryEvents(ctx context.Context, userID string, fridgeID *string, first *int32, after *string) (*model.InventoryEventConnection, error) {
+	logger := l.GetLogger()
+
+	target, err := l.ResolveFridgeID(ctx, userID, fridgeID)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := l.Repos.Events.ListByFridgePage(ctx, target, pageSize(first, 20), cursorValue(after))
+	if err != nil {
+		logger.Printf("level=error op=GetInventoryEvents stage=query fridgeId=%s err=%v", target, err)
+		return nil, pageError(err)
+	}
+	return eventConnection(page), nil
+}
+
+// FetchItemEvents returns a page of the log of one item, newest first, across
+// every fridge the user has access to so moves do not cut its history short.
+// Items that were deleted keep their history.
+func (l *Logic) FetchItemEvents(ctx context.Context, userID, itemID string, first *int32, after *string) (*model.InventoryEventConnection, error) {
+	logger := l.GetLogger()
+
+	fridges, err := l.FetchFridges(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	fridgeIDs := make([]string, 0, len(fridges))
+	for _, fridge := range fridges {
+		fridgeIDs = append(fridgeIDs, fridge.ID)
+	}
+
+	page, err := l.Repos.Events.ListByItemPage(ctx, fridgeIDs, itemID, pageSize(first, 20), cursorValue(after))
+	if err != nil {
+		logger.Printf("level=error op=GetInventoryEvents stage=query userId=%s itemId=%s err=%v", userID, itemID, err)
+		return nil, pageError(err)
+	}
+	return eventConnection(page), nil
+}
+
+func eventConnection(page *repository.Page[model.InventoryEvent]) *model.InventoryEventConnection {
+	return &model.InventoryEventConnection{
+		Edges: edges(page, func(cursor string, node *model.InventoryEvent) *model.InventoryEventEdge {
+			return &model.InventoryEventEdge{Cursor: cursor, Node: node}
+		}),
+		PageInfo: pageInfo(page),
+	}
+}
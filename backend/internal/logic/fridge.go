@@ -2,52 +2,32 @@ package logic
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
+	"strings"
 	"time"
+	"unicode/utf8"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
 	"github.com/google/uuid"
 	"github.com/mariocosenza/mocc/graph/model"
+	"github.com/mariocosenza/mocc/internal/repository"
+	"github.com/mariocosenza/mocc/internal/units"
 )
 
 func (l *Logic) CreateFridgeForUser(ctx context.Context, userID string) error {
 	logger := l.GetLogger()
 
-	container, err := l.Cosmos.NewContainer(CosmosDatabase, ContainerInventory)
-	if err != nil {
-		logger.Printf("level=error op=CreateFridgeForUser stage=new_container db=%s container=%s userId=%s err=%v",
-			CosmosDatabase, ContainerInventory, userID, err)
-		return err
-	}
-
 	fridge := &model.Fridge{
 		ID:      userID,
 		Name:    "Il mio Frigo",
+		Kind:    model.StorageKindFridge,
 		OwnerID: []string{userID},
+		Members: []*model.FridgeMember{ownerMember(userID)},
 		Items:   []*model.InventoryItem{},
 	}
 
-	dataMap := map[string]interface{}{}
-	tempJSON, mErr := json.Marshal(fridge)
-	if mErr != nil {
-		logger.Printf("level=error op=CreateFridgeForUser stage=json_marshal_fridge userId=%s err=%v", userID, mErr)
-		return mErr
-	}
-	if uErr := json.Unmarshal(tempJSON, &dataMap); uErr != nil {
-		logger.Printf("level=error op=CreateFridgeForUser stage=json_unmarshal_map userId=%s err=%v", userID, uErr)
-		return uErr
-	}
-	dataMap["fridgeId"] = fridge.ID
-
-	data, err := json.Marshal(dataMap)
-	if err != nil {
-		logger.Printf("level=error op=CreateFridgeForUser stage=json_marshal_map userId=%s err=%v", userID, err)
-		return err
-	}
-
-	_, err = container.UpsertItem(ctx, azcosmos.NewPartitionKeyString(userID), data, nil)
+	err := l.Repos.Fridges.Upsert(ctx, fridge)
 	if err != nil {
 		logger.Printf("level=error op=CreateFridgeForUser stage=upsert userId=%s pk=%s err=%v", userID, userID, err)
 	}
@@ -57,240 +37,719 @@ func (l *Logic) CreateFridgeForUser(ctx context.Context, userID string) error {
 func (l *Logic) FetchFridges(ctx context.Context, userID string) ([]*model.Fridge, error) {
 	logger := l.GetLogger()
 
-	container, err := l.Cosmos.NewContainer(CosmosDatabase, ContainerInventory)
+	fridges, err := l.Repos.Fridges.ListForUser(ctx, userID)
 	if err != nil {
-		logger.Printf("level=error op=GetFridges stage=new_container db=%s container=%s userId=%s err=%v",
-			CosmosDatabase, ContainerInventory, userID, err)
+		logger.Printf("level=error op=GetFridges stage=query userId=%s err=%v", userID, err)
 		return nil, err
 	}
 
-	query := "SELECT * FROM c WHERE c.id = @id or ARRAY_CONTAINS(c.ownerId, @id)"
-	qOpts := azcosmos.QueryOptions{
-		QueryParameters: []azcosmos.QueryParameter{
-			{Name: "@id", Value: userID},
-		},
+	return fridges, nil
+}
+
+// FridgeIDForUser returns the id of the user's own fridge, or of the first one
+// shared with them. Fridges still storing their items inline are migrated on
+// the way, so item documents can be relied upon afterwards.
+func (l *Logic) FridgeIDForUser(ctx context.Context, userID string) (string, error) {
+	fridges, err := l.FetchFridges(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if len(fridges) == 0 {
+		return "", fmt.Errorf("not found")
+	}
+	fridge := fridges[0]
+	for _, f := range fridges {
+		if f.ID == userID {
+			fridge = f
+			break
+		}
+	}
+
+	if len(fridge.Items) > 0 {
+		if err := l.explodeFridge(ctx, fridge.ID); err != nil {
+			return "", err
+		}
 	}
-	pager := container.NewQueryItemsPager(query, azcosmos.PartitionKey{}, &qOpts)
+	return fridge.ID, nil
+}
 
-	var fridges []*model.Fridge
-	for pager.More() {
-		resp, err := pager.NextPage(ctx)
-		if err != nil {
-			logger.Printf("level=error op=GetFridges stage=query_next_page userId=%s err=%v", userID, err)
-			return nil, err
-		}
-		for _, item := range resp.Items {
-			var fridge model.Fridge
-			if err := json.Unmarshal(item, &fridge); err != nil {
-				logger.Printf("level=warn op=GetFridges stage=json_unmarshal userId=%s err=%v", userID, err)
-				continue
+// ResolveFridgeID picks the fridge an inventory operation works on: the one
+// asked for, which the user must have access to, else the user's active
+// fridge, else their own one.
+func (l *Logic) ResolveFridgeID(ctx context.Context, userID string, fridgeID *string) (string, error) {
+	if fridgeID != nil && *fridgeID != "" {
+		if _, _, err := l.accessibleFridge(ctx, userID, *fridgeID); err != nil {
+			return "", err
+		}
+		return *fridgeID, nil
+	}
+	if active := l.activeFridgeID(ctx, userID); active != "" {
+		return active, nil
+	}
+	return l.FridgeIDForUser(ctx, userID)
+}
+
+// EditableFridgeID is ResolveFridgeID for changes to the inventory, which
+// viewers cannot make.
+func (l *Logic) EditableFridgeID(ctx context.Context, userID string, fridgeID *string) (string, error) {
+	target, err := l.ResolveFridgeID(ctx, userID, fridgeID)
+	if err != nil {
+		return "", err
+	}
+	if _, err := l.requireRole(ctx, userID, target, model.FridgeRoleEditor); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// FridgeIDForItem finds the fridge holding an item the user is about to
+// change: the one given, or else whichever of the user's fridges has it, the
+// active one first. When no fridge has it the active one is returned, so the
+// caller reports the item as missing. Viewers of the fridge are refused.
+func (l *Logic) FridgeIDForItem(ctx context.Context, userID string, fridgeID *string, itemID string) (string, error) {
+	target, err := l.findItemFridge(ctx, userID, fridgeID, itemID)
+	if err != nil {
+		return "", err
+	}
+	if _, err := l.requireRole(ctx, userID, target, model.FridgeRoleEditor); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+func (l *Logic) findItemFridge(ctx context.Context, userID string, fridgeID *string, itemID string) (string, error) {
+	active, err := l.ResolveFridgeID(ctx, userID, fridgeID)
+	if err != nil || fridgeID != nil && *fridgeID != "" {
+		return active, err
+	}
+	if _, _, err := l.Repos.Inventory.Get(ctx, active, itemID); err == nil {
+		return active, nil
+	}
+
+	fridges, err := l.FetchFridges(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	for _, fridge := range fridges {
+		if fridge.ID == active {
+			continue
+		}
+		if len(fridge.Items) > 0 {
+			if err := l.explodeFridge(ctx, fridge.ID); err != nil {
+				return "", err
 			}
-			fridges = append(fridges, &fridge)
+		}
+		if _, _, err := l.Repos.Inventory.Get(ctx, fridge.ID, itemID); err == nil {
+			return fridge.ID, nil
 		}
 	}
+	return active, nil
+}
 
-	return fridges, nil
+// activeFridgeID returns the fridge the user picked as active, or "" when
+// none is set or they lost access to it.
+func (l *Logic) activeFridgeID(ctx context.Context, userID string) string {
+	user, err := l.FetchUser(ctx, userID)
+	if err != nil || user.Preferences == nil || user.Preferences.ActiveFridgeID == nil {
+		return ""
+	}
+	id := *user.Preferences.ActiveFridgeID
+	if _, _, err := l.accessibleFridge(ctx, userID, id); err != nil {
+		return ""
+	}
+	return id
 }
 
-func (l *Logic) FetchFridge(ctx context.Context, userID string) (*model.Fridge, error) {
+// accessibleFridge reads a fridge the user has access to, migrating its items
+// if the fridge document still embeds them.
+func (l *Logic) accessibleFridge(ctx context.Context, userID, fridgeID string) (*model.Fridge, string, error) {
+	fridge, etag, err := l.Repos.Fridges.Get(ctx, fridgeID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, "", fmt.Errorf("fridge not found")
+	}
+	if err != nil {
+		l.GetLogger().Printf("level=error op=GetFridge stage=read_item userId=%s fridgeId=%s err=%v", userID, fridgeID, err)
+		return nil, "", err
+	}
+	if !canAccessFridge(fridge, userID) {
+		return nil, "", fmt.Errorf("unauthorized")
+	}
+	if len(fridge.Items) > 0 {
+		if err := l.explodeFridge(ctx, fridge.ID); err != nil {
+			return nil, "", err
+		}
+		return l.Repos.Fridges.Get(ctx, fridgeID)
+	}
+	return fridge, etag, nil
+}
+
+// fridgeItems lists the items of every fridge the user has at least the
+// given role in, and which fridge holds each of them.
+func (l *Logic) fridgeItems(ctx context.Context, userID string, min model.FridgeRole) ([]*model.InventoryItem, map[string]string, error) {
 	fridges, err := l.FetchFridges(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	items := []*model.InventoryItem{}
+	where := map[string]string{}
+	for _, fridge := range fridges {
+		if roleRank(FridgeRoleOf(fridge, userID)) < roleRank(min) {
+			continue
+		}
+		fridgeItems, err := l.FetchFridgeItems(ctx, fridge)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, item := range fridgeItems {
+			where[item.ID] = fridge.ID
+		}
+		items = append(items, fridgeItems...)
+	}
+	return items, where, nil
+}
+
+// itemsOf maps the ids of the items in one fridge the user can edit to that
+// fridge, in the shape fridgeItems returns them.
+func (l *Logic) itemsOf(ctx context.Context, userID, fridgeID string) (map[string]string, error) {
+	fridge, err := l.requireRole(ctx, userID, fridgeID, model.FridgeRoleEditor)
 	if err != nil {
 		return nil, err
 	}
-	if len(fridges) == 0 {
-		return nil, fmt.Errorf("not found")
+	items, err := l.FetchFridgeItems(ctx, fridge)
+	if err != nil {
+		return nil, err
 	}
-	for _, f := range fridges {
-		if f.ID == userID {
-			return f, nil
-		}
+	where := map[string]string{}
+	for _, item := range items {
+		where[item.ID] = fridge.ID
 	}
-	return fridges[0], nil
+	return where, nil
 }
 
-func (l *Logic) UpsertFridge(ctx context.Context, fridge *model.Fridge) error {
+// maxFridgeNameLength bounds the names users give their storage places.
+const maxFridgeNameLength = 50
+
+func fridgeName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxFridgeNameLength {
+		return "", fmt.Errorf("name must be between 1 and %d characters", maxFridgeNameLength)
+	}
+	return name, nil
+}
+
+// CreateStorage adds a storage place of the given kind owned by the user,
+// next to the fridge every user gets when signing up.
+func (l *Logic) CreateStorage(ctx context.Context, userID, name string, kind model.StorageKind) (*model.Fridge, error) {
+	name, err := fridgeName(name)
+	if err != nil {
+		return nil, err
+	}
+	if !kind.IsValid() {
+		return nil, fmt.Errorf("invalid storage kind")
+	}
+
+	fridge := &model.Fridge{
+		ID:      uuid.New().String(),
+		Name:    name,
+		Kind:    kind,
+		OwnerID: []string{userID},
+		Members: []*model.FridgeMember{ownerMember(userID)},
+		Items:   []*model.InventoryItem{},
+	}
+	if err := l.Repos.Fridges.Upsert(ctx, fridge); err != nil {
+		l.GetLogger().Printf("level=error op=CreateFridge stage=upsert userId=%s fridgeId=%s err=%v", userID, fridge.ID, err)
+		return nil, err
+	}
+	return fridge, nil
+}
+
+// RenameStorage renames one of the fridges the user owns.
+func (l *Logic) RenameStorage(ctx context.Context, userID, fridgeID, name string) (*model.Fridge, error) {
+	name, err := fridgeName(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := l.requireRole(ctx, userID, fridgeID, model.FridgeRoleOwner); err != nil {
+		return nil, err
+	}
+
+	return l.UpdateFridge(ctx, fridgeID, func(fridge *model.Fridge) (bool, error) {
+		if fridge.Name == name {
+			return false, nil
+		}
+		fridge.Name = name
+		return true, nil
+	})
+}
+
+// RemoveStorage deletes a fridge the user owns. Only empty fridges can go,
+// and never the one the user got when signing up.
+func (l *Logic) RemoveStorage(ctx context.Context, userID, fridgeID string) error {
 	logger := l.GetLogger()
 
-	container, err := l.Cosmos.NewContainer(CosmosDatabase, ContainerInventory)
+	fridge, err := l.requireRole(ctx, userID, fridgeID, model.FridgeRoleOwner)
 	if err != nil {
-		logger.Printf("level=error op=SaveFridge stage=new_container db=%s container=%s fridgeId=%s err=%v",
-			CosmosDatabase, ContainerInventory, fridge.ID, err)
 		return err
 	}
+	if fridge.ID == userID {
+		return fmt.Errorf("the default fridge cannot be deleted")
+	}
 
-	dataMap := map[string]interface{}{}
-	tempJSON, mErr := json.Marshal(fridge)
-	if mErr != nil {
-		logger.Printf("level=error op=SaveFridge stage=json_marshal_fridge fridgeId=%s err=%v", fridge.ID, mErr)
-		return mErr
+	items, err := l.FetchInventoryItems(ctx, fridgeID)
+	if err != nil {
+		return err
 	}
-	if uErr := json.Unmarshal(tempJSON, &dataMap); uErr != nil {
-		logger.Printf("level=error op=SaveFridge stage=json_unmarshal_map fridgeId=%s err=%v", fridge.ID, uErr)
-		return uErr
+	if len(items) > 0 {
+		return codedError(ErrCodeConflict, "only empty fridges can be deleted")
 	}
-	dataMap["fridgeId"] = fridge.ID
 
-	data, err := json.Marshal(dataMap)
-	if err != nil {
-		logger.Printf("level=error op=SaveFridge stage=json_marshal_map fridgeId=%s err=%v", fridge.ID, err)
+	if err := l.Repos.Fridges.Delete(ctx, fridgeID); err != nil {
+		logger.Printf("level=error op=DeleteFridge stage=delete userId=%s fridgeId=%s err=%v", userID, fridgeID, err)
 		return err
 	}
+	if err := l.Repos.Shopping.Delete(ctx, fridgeID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		logger.Printf("level=warn op=DeleteFridge stage=delete_shopping_list fridgeId=%s err=%v", fridgeID, err)
+	}
+	if err := l.Repos.MealPlans.DeleteByFridge(ctx, fridgeID); err != nil {
+		logger.Printf("level=warn op=DeleteFridge stage=delete_meal_plans fridgeId=%s err=%v", fridgeID, err)
+	}
+	l.PublishFridgeChanged(ctx, fridgeID)
+	return nil
+}
+
+// RelocateInventoryItem moves an item to another of the user's fridges,
+// keeping its id and history. Items reserved for a recipe stay where they
+// are, as the recipe would lose track of them.
+func (l *Logic) RelocateInventoryItem(ctx context.Context, userID, itemID string, fromFridgeID *string, toFridgeID string) (*model.InventoryItem, error) {
+	logger := l.GetLogger()
+
+	from, err := l.FridgeIDForItem(ctx, userID, fromFridgeID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := l.requireRole(ctx, userID, toFridgeID, model.FridgeRoleEditor); err != nil {
+		return nil, err
+	}
+	if from == toFridgeID {
+		return nil, fmt.Errorf("the item is already in that fridge")
+	}
+
+	var moved *model.InventoryItem
+	item, err := l.mutateItem(ctx, from, itemID, itemEvent(model.InventoryEventTypeMoved, userID), func(item *model.InventoryItem) (ItemWrite, error) {
+		if len(item.ActiveLocks) > 0 {
+			return ItemKeep, codedError(ErrCodeConflict, "%s is reserved for a recipe and cannot be moved", item.Name)
+		}
+		copied := cloneItem(item)
+		if err := l.Repos.Inventory.Upsert(ctx, toFridgeID, copied); err != nil {
+			logger.Printf("level=error op=MoveInventoryItem stage=upsert fridgeId=%s itemId=%s err=%v", toFridgeID, itemID, err)
+			return ItemKeep, err
+		}
+		moved = copied
+		return ItemDelete, nil
+	})
+	if err != nil {
+		if moved != nil {
+			// The copy landed but the original could not be removed: take
+			// the copy back out so the item is not in both fridges.
+			if rbErr := l.Repos.Inventory.Delete(ctx, toFridgeID, itemID, ""); rbErr != nil {
+				logger.Printf("level=error op=MoveInventoryItem stage=rollback fridgeId=%s itemId=%s err=%v", toFridgeID, itemID, rbErr)
+			}
+		}
+		return nil, err
+	}
+
+	event := itemEvent(model.InventoryEventTypeMoved, userID)
+	completeEvent(event, toFridgeID, nil, item, ItemReplace)
+	l.appendEvent(ctx, event)
+	l.PublishFridgeChanged(ctx, toFridgeID)
+	return moved, nil
+}
+
+func (l *Logic) UpsertFridge(ctx context.Context, fridge *model.Fridge) error {
+	logger := l.GetLogger()
 
-	_, err = container.UpsertItem(ctx, azcosmos.NewPartitionKeyString(fridge.ID), data, nil)
+	err := l.Repos.Fridges.Upsert(ctx, fridge)
 	if err != nil {
 		logger.Printf("level=error op=SaveFridge stage=upsert fridgeId=%s pk=%s err=%v", fridge.ID, fridge.ID, err)
 	}
 	return err
 }
 
-func (l *Logic) LockIngredients(ctx context.Context, uid string, recipe *model.Recipe) error {
-	fridge, err := l.FetchFridge(ctx, uid)
-	if err != nil {
+// UpdateFridge runs mutate against the fridge document and writes the result
+// back only if nobody else wrote it in the meantime. On an ETag mismatch the
+// fridge is re-read and mutate applied again, so mutate must derive everything
+// from the fridge it is given. It reports whether it changed anything;
+// untouched fridges are not written. Items are not part of the fridge
+// document: use MutateInventoryItem for them.
+func (l *Logic) UpdateFridge(ctx context.Context, fridgeID string, mutate func(fridge *model.Fridge) (bool, error)) (*model.Fridge, error) {
+	logger := l.GetLogger()
+
+	var fridge *model.Fridge
+	err := l.withWriteRetry(ctx, "SaveFridge", fridgeID, "fridge", func() error {
+		current, etag, err := l.Repos.Fridges.Get(ctx, fridgeID)
+		if err != nil {
+			logger.Printf("level=error op=GetFridge stage=read_item fridgeId=%s err=%v", fridgeID, err)
+			return err
+		}
+
+		changed, err := mutate(current)
+		if err != nil {
+			return err
+		}
+		fridge = current
+		if !changed {
+			return nil
+		}
+
+		err = l.Repos.Fridges.Replace(ctx, current, etag)
+		if err == nil {
+			l.PublishFridgeChanged(ctx, current.ID)
+		} else if !errors.Is(err, repository.ErrConflict) {
+			logger.Printf("level=error op=SaveFridge stage=replace fridgeId=%s pk=%s err=%v", current.ID, current.ID, err)
+		}
 		return err
+	})
+	if err != nil {
+		return nil, err
 	}
+	return fridge, nil
+}
+
+// canAccessFridge mirrors the visibility rule of FetchFridges.
+func canAccessFridge(fridge *model.Fridge, userID string) bool {
+	if fridge.ID == userID {
+		return true
+	}
+	for _, owner := range fridge.OwnerID {
+		if owner == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// withWriteRetry runs write again while it keeps losing against concurrent
+// writers, backing off a little more after every attempt.
+func (l *Logic) withWriteRetry(ctx context.Context, op, id, what string, write func() error) error {
+	logger := l.GetLogger()
+
+	for attempt := 1; attempt <= FridgeWriteAttempts; attempt++ {
+		err := write()
+		if !errors.Is(err, repository.ErrConflict) {
+			return err
+		}
+
+		logger.Printf("level=info op=%s stage=etag_conflict id=%s attempt=%d", op, id, attempt)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt) * FridgeWriteBackoff):
+		}
+	}
+
+	logger.Printf("level=warn op=%s stage=retries_exhausted id=%s attempts=%d", op, id, FridgeWriteAttempts)
+	return codedError(ErrCodeConflict, "the %s was modified concurrently, please retry", what)
+}
 
-	changed := false
+func (l *Logic) LockIngredients(ctx context.Context, uid string, recipe *model.Recipe) error {
+	return l.lockIngredients(ctx, uid, "", recipe)
+}
+
+// LockFridgeIngredients is LockIngredients restricted to the items of one
+// fridge the user can edit; ingredients linked to items elsewhere are left
+// unreserved.
+func (l *Logic) LockFridgeIngredients(ctx context.Context, uid, fridgeID string, recipe *model.Recipe) error {
+	return l.lockIngredients(ctx, uid, fridgeID, recipe)
+}
+
+// lockIngredients reserves the linked ingredients of the recipe among the
+// items of fridgeID, or of every fridge the user can edit when it is empty.
+func (l *Logic) lockIngredients(ctx context.Context, uid, fridgeID string, recipe *model.Recipe) error {
+	logger := l.GetLogger()
 	now := time.Now().Format(time.RFC3339)
 
-	requirements := make(map[string]float64)
+	// Ingredients are kept in their own units until the item is read, since
+	// only then is it known what unit the lock has to be expressed in.
+	requirements := make(map[string][]*model.RecipeIngredient)
+	order := []string{}
 	for _, ing := range recipe.Ingredients {
-		if ing.InventoryItemID != nil && *ing.InventoryItemID != "" {
-			requirements[*ing.InventoryItemID] += ing.Quantity
+		if ing.InventoryItemID == nil || *ing.InventoryItemID == "" || units.IsNonDepleting(ing.Unit) {
+			continue
 		}
+		if _, ok := requirements[*ing.InventoryItemID]; !ok {
+			order = append(order, *ing.InventoryItemID)
+		}
+		requirements[*ing.InventoryItemID] = append(requirements[*ing.InventoryItemID], ing)
 	}
 
 	if len(requirements) == 0 {
 		return nil
 	}
 
-	for itemID, reqQty := range requirements {
-		for _, item := range fridge.Items {
-			if item.ID == itemID {
-				var existingLock *model.ProductLock
-				for _, lock := range item.ActiveLocks {
-					if lock.RecipeID == recipe.ID {
-						existingLock = lock
-						break
-					}
-				}
+	var where map[string]string
+	var err error
+	if fridgeID == "" {
+		_, where, err = l.fridgeItems(ctx, uid, model.FridgeRoleEditor)
+	} else {
+		where, err = l.itemsOf(ctx, uid, fridgeID)
+	}
+	if err != nil {
+		return err
+	}
 
-				if existingLock != nil {
-					if existingLock.Amount != reqQty {
-						existingLock.Amount = reqQty
-						existingLock.StartedAt = now
-						changed = true
-					}
-				} else {
-					item.ActiveLocks = append(item.ActiveLocks, &model.ProductLock{
-						RecipeID:  recipe.ID,
-						Amount:    reqQty,
-						StartedAt: now,
-					})
-					changed = true
+	// previous holds the amount each written item had locked for this recipe
+	// before, or a negative value when it had no lock, so a failure halfway
+	// can put the already locked items back as they were.
+	previous := map[string]float64{}
+	for _, itemID := range order {
+		itemFridge, ok := where[itemID]
+		if !ok {
+			continue
+		}
+		var before float64
+		event := recipeEvent(model.InventoryEventTypeLocked, uid, recipe.ID)
+		_, err := l.mutateItem(ctx, itemFridge, itemID, event, func(item *model.InventoryItem) (ItemWrite, error) {
+			before = -1
+			reqQty, err := requiredAmount(item, requirements[itemID])
+			if err != nil {
+				return ItemKeep, err
+			}
+			if reqQty == 0 {
+				return ItemKeep, nil
+			}
+
+			var existingLock *model.ProductLock
+			for _, lock := range item.ActiveLocks {
+				if lock.RecipeID == recipe.ID {
+					existingLock = lock
+					break
 				}
+			}
 
-				item.VirtualAvailable = item.Quantity.Value
-				for _, lock := range item.ActiveLocks {
-					item.VirtualAvailable -= lock.Amount
+			if existingLock != nil {
+				before = existingLock.Amount
+				if existingLock.Amount == reqQty {
+					return ItemKeep, nil
 				}
+				existingLock.Amount = reqQty
+				existingLock.StartedAt = now
+			} else {
+				item.ActiveLocks = append(item.ActiveLocks, &model.ProductLock{
+					RecipeID:  recipe.ID,
+					Amount:    reqQty,
+					StartedAt: now,
+				})
+			}
 
-				if item.VirtualAvailable < -0.001 {
-					return fmt.Errorf("insufficient quantity for item %s", item.Name)
+			refreshVirtualAvailable(item)
+			if item.VirtualAvailable < -0.001 {
+				return ItemKeep, fmt.Errorf("insufficient quantity for item %s", item.Name)
+			}
+			return ItemReplace, nil
+		})
+		if errors.Is(err, ErrNoSuchItem) {
+			continue
+		}
+		if err != nil {
+			for lockedID, amount := range previous {
+				if rbErr := l.restoreLock(ctx, uid, where[lockedID], lockedID, recipe.ID, amount); rbErr != nil {
+					logger.Printf("level=error op=LockIngredients stage=rollback fridgeId=%s itemId=%s recipeId=%s err=%v", where[lockedID], lockedID, recipe.ID, rbErr)
 				}
 			}
+			return err
 		}
+		previous[itemID] = before
 	}
+	return nil
+}
 
-	if changed {
-		return l.UpsertFridge(ctx, fridge)
+// restoreLock sets the recipe's lock on an item back to amount, removing it
+// when amount is negative.
+func (l *Logic) restoreLock(ctx context.Context, uid, fridgeID, itemID, recipeID string, amount float64) error {
+	event := recipeEvent(model.InventoryEventTypeUnlocked, uid, recipeID)
+	_, err := l.mutateItem(ctx, fridgeID, itemID, event, func(item *model.InventoryItem) (ItemWrite, error) {
+		locks := []*model.ProductLock{}
+		for _, lock := range item.ActiveLocks {
+			if lock.RecipeID == recipeID {
+				if amount < 0 {
+					continue
+				}
+				lock.Amount = amount
+			}
+			locks = append(locks, lock)
+		}
+		item.ActiveLocks = locks
+		refreshVirtualAvailable(item)
+		return ItemReplace, nil
+	})
+	if errors.Is(err, ErrNoSuchItem) {
+		return nil
 	}
-	return nil
+	return err
+}
+
+// cookedWrite is an item ApplyCooking took stock from, kept so a failure
+// further on can give it back.
+type cookedWrite struct {
+	fridgeID string
+	before   *model.InventoryItem
+	used     float64
 }
 
 func (l *Logic) ApplyCooking(ctx context.Context, uid string, recipe *model.Recipe) error {
-	fridge, err := l.FetchFridge(ctx, uid)
+	logger := l.GetLogger()
+
+	_, where, err := l.fridgeItems(ctx, uid, model.FridgeRoleEditor)
 	if err != nil {
 		return err
 	}
 
-	if recipe.CookedItems == nil {
-		recipe.CookedItems = []*model.RecipeCookedItem{}
-	}
-	changed := false
-
+	cooked := append([]*model.RecipeCookedItem{}, recipe.CookedItems...)
+	written := []cookedWrite{}
 	for _, ing := range recipe.Ingredients {
-		if ing.InventoryItemID != nil && *ing.InventoryItemID != "" {
-			itemsToRemove := []int{}
-			for idx, item := range fridge.Items {
-				if item.ID == *ing.InventoryItemID {
-					item.Quantity.Value -= ing.Quantity
-
-					if item.Quantity.Value <= 0.001 {
-						itemsToRemove = append(itemsToRemove, idx)
-					} else {
-						item.VirtualAvailable = item.Quantity.Value
-						for _, lock := range item.ActiveLocks {
-							item.VirtualAvailable -= lock.Amount
-						}
-					}
-
-					recipe.CookedItems = append(recipe.CookedItems, &model.RecipeCookedItem{
-						ID:                  uuid.New().String(),
-						Name:                item.Name,
-						Brand:               item.Brand,
-						Category:            item.Category,
-						Quantity:            item.Quantity,
-						Price:               item.Price,
-						UsedQuantity:        ing.Quantity,
-						OriginalInventoryID: &item.ID,
-					})
-					changed = true
-				}
+		if ing.InventoryItemID == nil || *ing.InventoryItemID == "" {
+			continue
+		}
+		fridgeID, ok := where[*ing.InventoryItemID]
+		if !ok {
+			continue
+		}
+
+		var entry *model.RecipeCookedItem
+		var write cookedWrite
+		event := recipeEvent(model.InventoryEventTypeCooked, uid, recipe.ID)
+		_, err := l.mutateItem(ctx, fridgeID, *ing.InventoryItemID, event, func(item *model.InventoryItem) (ItemWrite, error) {
+			entry = nil
+			used, err := requiredAmount(item, []*model.RecipeIngredient{ing})
+			if err != nil {
+				return ItemKeep, err
+			}
+			if used == 0 {
+				return ItemKeep, nil
+			}
+
+			write = cookedWrite{fridgeID: fridgeID, before: cloneItem(item), used: used}
+			before := item.Quantity.Value
+			item.Quantity.Value -= used
+			entry = &model.RecipeCookedItem{
+				ID:                  uuid.New().String(),
+				Name:                item.Name,
+				Brand:               item.Brand,
+				Category:            item.Category,
+				Quantity:            item.Quantity,
+				Price:               item.Price,
+				UsedQuantity:        used,
+				OriginalInventoryID: &item.ID,
 			}
+			shrinkPrice(item, before)
 
-			for i := len(itemsToRemove) - 1; i >= 0; i-- {
-				idx := itemsToRemove[i]
-				fridge.Items = append(fridge.Items[:idx], fridge.Items[idx+1:]...)
+			if item.Quantity.Value <= 0.001 {
+				return ItemDelete, nil
 			}
+			refreshVirtualAvailable(item)
+			return ItemReplace, nil
+		})
+		if errors.Is(err, ErrNoSuchItem) {
+			continue
+		}
+		if err != nil {
+			for _, done := range written {
+				if rbErr := l.restoreCooked(ctx, uid, recipe.ID, done); rbErr != nil {
+					logger.Printf("level=error op=ApplyCooking stage=rollback fridgeId=%s itemId=%s recipeId=%s err=%v", done.fridgeID, done.before.ID, recipe.ID, rbErr)
+				}
+			}
+			return err
+		}
+		if entry != nil {
+			cooked = append(cooked, entry)
+			written = append(written, write)
 		}
 	}
 
-	if changed {
-		return l.UpsertFridge(ctx, fridge)
+	recipe.CookedItems = cooked
+	return nil
+}
+
+// restoreCooked gives an item back what ApplyCooking took from it, writing
+// it back whole when cooking used it up.
+func (l *Logic) restoreCooked(ctx context.Context, uid, recipeID string, done cookedWrite) error {
+	event := recipeEvent(model.InventoryEventTypeRestored, uid, recipeID)
+	_, err := l.mutateItem(ctx, done.fridgeID, done.before.ID, event, func(item *model.InventoryItem) (ItemWrite, error) {
+		left := item.Quantity.Value
+		item.Quantity.Value += done.used
+		if item.Price != nil && left > 0 {
+			item.Price = toPtr(*item.Price * item.Quantity.Value / left)
+		}
+		refreshVirtualAvailable(item)
+		return ItemReplace, nil
+	})
+	if !errors.Is(err, ErrNoSuchItem) {
+		return err
 	}
+
+	if err := l.Repos.Inventory.Upsert(ctx, done.fridgeID, done.before); err != nil {
+		return err
+	}
+	completeEvent(event, done.fridgeID, nil, done.before, ItemReplace)
+	l.appendEvent(ctx, event)
+	l.PublishFridgeChanged(ctx, done.fridgeID)
 	return nil
 }
 
 func (l *Logic) UnlockIngredients(ctx context.Context, uid string, recipeID string) error {
-	fridge, err := l.FetchFridge(ctx, uid)
+	items, where, err := l.fridgeItems(ctx, uid, model.FridgeRoleViewer)
 	if err != nil {
 		return err
 	}
 
-	changed := false
-	for _, item := range fridge.Items {
-		newLocks := []*model.ProductLock{}
-		itemChanged := false
-		for _, lock := range item.ActiveLocks {
-			if lock.RecipeID == recipeID {
-				itemChanged = true
-				continue
-			}
-			newLocks = append(newLocks, lock)
+	for _, candidate := range items {
+		if !hasLockFor(candidate, recipeID) {
+			continue
 		}
-		if itemChanged {
-			item.ActiveLocks = newLocks
-			item.VirtualAvailable = item.Quantity.Value
+		event := recipeEvent(model.InventoryEventTypeUnlocked, uid, recipeID)
+		_, err := l.mutateItem(ctx, where[candidate.ID], candidate.ID, event, func(item *model.InventoryItem) (ItemWrite, error) {
+			if !hasLockFor(item, recipeID) {
+				return ItemKeep, nil
+			}
+			newLocks := []*model.ProductLock{}
 			for _, lock := range item.ActiveLocks {
-				item.VirtualAvailable -= lock.Amount
+				if lock.RecipeID != recipeID {
+					newLocks = append(newLocks, lock)
+				}
 			}
+			item.ActiveLocks = newLocks
+			refreshVirtualAvailable(item)
 			item.VirtualAvailable = math.Round(item.VirtualAvailable*1000) / 1000
-			changed = true
+			return ItemReplace, nil
+		})
+		if err != nil && !errors.Is(err, ErrNoSuchItem) {
+			return err
+		}
+	}
+	return nil
+}
+
+// requiredAmount adds up what the ingredients need of the item, in the item's
+// unit.
+func requiredAmount(item *model.InventoryItem, ingredients []*model.RecipeIngredient) (float64, error) {
+	product := units.ProductOf(item)
+	total := 0.0
+	for _, ing := range ingredients {
+		amount, err := units.Required(ing.Quantity, ing.Unit, item.Quantity.Unit, product)
+		if err != nil {
+			return 0, unitError(item.Name, err)
 		}
+		total += amount
 	}
+	return total, nil
+}
 
-	if changed {
-		return l.UpsertFridge(ctx, fridge)
+func hasLockFor(item *model.InventoryItem, recipeID string) bool {
+	for _, lock := range item.ActiveLocks {
+		if lock.RecipeID == recipeID {
+			return true
+		}
 	}
-	return nil
+	return false
 }
@@ -0,0 +1,68 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/mariocosenza/mocc/graph/model"
+	"github.com/mariocosenza/mocc/internal/repository"
+)
+
+// AddFriendByNickname adds whoever goes by nickname to the user's friends.
+// The other user's list does not change, and the two only show up on each
+// other's friends leaderboard once both have added the other.
+func (l *Logic) AddFriendByNickname(ctx context.Context, userID, nickname string) (*model.User, error) {
+	logger := l.GetLogger()
+
+	user, err := l.FetchUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	friend, err := l.Repos.Users.FindByNickname(ctx, nickname)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("no user goes by %s", nickname)
+	}
+	if err != nil {
+		logger.Printf("level=error op=AddFriend stage=find userId=%s nickname=%s err=%v", userID, nickname, err)
+		return nil, err
+	}
+	if friend.ID == userID {
+		return nil, fmt.Errorf("you cannot add yourself as a friend")
+	}
+	if slices.Contains(user.FriendIds, friend.ID) {
+		return user, nil
+	}
+	if len(user.FriendIds) >= MaxFriends {
+		return nil, codedError(ErrCodeConflict, "you can have at most %d friends", MaxFriends)
+	}
+
+	user.FriendIds = append(user.FriendIds, friend.ID)
+	if err := l.UpsertUser(ctx, user); err != nil {
+		return nil, err
+	}
+	l.SetUserCache(ctx, user)
+	logger.Printf("level=info op=AddFriend stage=added userId=%s friendId=%s", userID, friend.ID)
+	return user, nil
+}
+
+// RemoveFriendByID drops a user from the user's friends.
+func (l *Logic) RemoveFriendByID(ctx context.Context, userID, friendID string) (*model.User, error) {
+	user, err := l.FetchUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(user.FriendIds, friendID) {
+		return user, nil
+	}
+
+	user.FriendIds = slices.DeleteFunc(user.FriendIds, func(id string) bool { return id == friendID })
+	if err := l.UpsertUser(ctx, user); err != nil {
+		return nil, err
+	}
+	l.SetUserCache(ctx, user)
+	l.GetLogger().Printf("level=info op=RemoveFriend stage=removed userId=%s friendId=%s", userID, friendID)
+	return user, nil
+}
@@ -2,17 +2,23 @@ package logic
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
+	"slices"
 	"sort"
+	"strconv"
+	"time"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
 	"github.com/mariocosenza/mocc/graph/model"
+	"github.com/mariocosenza/mocc/internal/repository"
 	"github.com/redis/go-redis/v9"
 )
 
-func (l *Logic) UpsertLeaderboardEntry(ctx context.Context, user *model.User) {
+// UpsertLeaderboardEntry records the user's all-time score and adds delta,
+// the points just earned or lost, to their score for the current week and
+// month. A zero delta only refreshes the nickname.
+func (l *Logic) UpsertLeaderboardEntry(ctx context.Context, user *model.User, delta int) {
 	logger := l.GetLogger()
 
 	score := 0
@@ -20,6 +26,9 @@ func (l *Logic) UpsertLeaderboardEntry(ctx context.Context, user *model.User) {
 		score = int(user.Gamification.TotalEcoPoints)
 	}
 
+	if err := l.Redis.HSet(ctx, LeaderboardNicknames, user.ID, user.Nickname).Err(); err != nil {
+		logger.Printf("level=warn op=UpdateLeaderboard stage=redis_nickname userId=%s err=%v", user.ID, err)
+	}
 	if err := l.Redis.ZAdd(ctx, LeaderboardGlobal, redis.Z{
 		Score:  float64(score),
 		Member: user.ID,
@@ -27,192 +36,307 @@ func (l *Logic) UpsertLeaderboardEntry(ctx context.Context, user *model.User) {
 		logger.Printf("level=warn op=UpdateLeaderboard stage=redis userId=%s score=%d err=%v", user.ID, score, err)
 	}
 
-	lbItem := map[string]interface{}{
-		"id":       user.ID,
-		"period":   "global",
-		"nickname": user.Nickname,
-		"score":    score,
+	record := &repository.LeaderboardRecord{
+		ID:       user.ID,
+		Period:   LeaderboardPeriodGlobal,
+		Nickname: user.Nickname,
+		Score:    score,
 	}
 
-	data, err := json.Marshal(lbItem)
-	if err != nil {
-		logger.Printf("level=error op=UpdateLeaderboard stage=json_marshal userId=%s err=%v", user.ID, err)
-		return
+	if err := l.Repos.Leaderboard.Upsert(ctx, record); err != nil {
+		logger.Printf("level=error op=UpdateLeaderboard stage=store_upsert userId=%s err=%v", user.ID, err)
 	}
 
-	container, err := l.Cosmos.NewContainer(CosmosDatabase, ContainerLeaderboard)
+	now := time.Now().UTC()
+	for _, kind := range []model.LeaderboardPeriod{model.LeaderboardPeriodWeek, model.LeaderboardPeriodMonth} {
+		l.addPeriodScore(ctx, user, kind, leaderboardPeriod(kind, now), delta)
+	}
+}
+
+// addPeriodScore adds delta to the user's score for a week or month. The
+// store holds the running total, written conditionally so concurrent awards
+// add up, and Redis a copy that expires once the period has long been
+// archived.
+func (l *Logic) addPeriodScore(ctx context.Context, user *model.User, kind model.LeaderboardPeriod, period string, delta int) {
+	logger := l.GetLogger()
+
+	var record *repository.LeaderboardRecord
+	err := l.withWriteRetry(ctx, "UpdateLeaderboard", user.ID, "leaderboard score", func() error {
+		stored, etag, err := l.Repos.Leaderboard.GetWithETag(ctx, period, user.ID)
+		if errors.Is(err, repository.ErrNotFound) {
+			if delta == 0 {
+				record = nil
+				return nil
+			}
+			record = &repository.LeaderboardRecord{ID: user.ID, Period: period, Nickname: user.Nickname, Score: max(delta, 0)}
+			return l.Repos.Leaderboard.Create(ctx, record)
+		}
+		if err != nil {
+			return err
+		}
+		stored.Nickname = user.Nickname
+		stored.Score = max(stored.Score+delta, 0)
+		record = stored
+		return l.Repos.Leaderboard.Replace(ctx, stored, etag)
+	})
 	if err != nil {
-		logger.Printf("level=error op=UpdateLeaderboard stage=new_container db=%s container=%s err=%v",
-			CosmosDatabase, ContainerLeaderboard, err)
+		logger.Printf("level=error op=UpdateLeaderboard stage=store_write userId=%s period=%s err=%v", user.ID, period, err)
 		return
 	}
+	if record != nil {
+		l.cachePeriodScore(ctx, kind, period, record)
+	}
+}
 
-	if _, err := container.UpsertItem(ctx, azcosmos.NewPartitionKeyString("global"), data, nil); err != nil {
-		logger.Printf("level=error op=UpdateLeaderboard stage=cosmos_upsert userId=%s err=%v", user.ID, err)
+// cachePeriodScore copies a stored score, and the nickname that goes with
+// it, to Redis.
+func (l *Logic) cachePeriodScore(ctx context.Context, kind model.LeaderboardPeriod, period string, record *repository.LeaderboardRecord) {
+	key := LeaderboardKeyPrefix + period
+	if err := l.Redis.ZAdd(ctx, key, redis.Z{Score: float64(record.Score), Member: record.ID}).Err(); err != nil {
+		l.GetLogger().Printf("level=warn op=UpdateLeaderboard stage=redis key=%s userId=%s err=%v", key, record.ID, err)
+		return
+	}
+	if record.Nickname != "" {
+		l.Redis.HSet(ctx, LeaderboardNicknames, record.ID, record.Nickname)
+	}
+	if kind != model.LeaderboardPeriodAllTime {
+		l.Redis.Expire(ctx, key, leaderboardRetention(kind))
 	}
 }
 
-func (l *Logic) FetchLeaderboard(ctx context.Context, top int) ([]*model.LeaderboardEntry, error) {
+// FetchLeaderboard returns the top scores of the current week, month or of
+// all time.
+func (l *Logic) FetchLeaderboard(ctx context.Context, top int, kind model.LeaderboardPeriod) ([]*model.LeaderboardEntry, error) {
 	logger := l.GetLogger()
 
 	if top <= 0 {
 		return []*model.LeaderboardEntry{}, nil
 	}
+	period := leaderboardPeriod(kind, time.Now().UTC())
 
-	entries, used, err := l.fetchLeaderboardFromRedis(ctx, top)
-	if used {
+	entries, _, err := l.rankedRange(ctx, LeaderboardKeyPrefix+period, 0, int64(top-1))
+	if err == nil && len(entries) > 0 {
 		return entries, nil
 	}
 	if err != nil {
-		logger.Printf("level=info op=GetLeaderboard stage=redis_zrevrange err=%v", err)
+		logger.Printf("level=info op=GetLeaderboard stage=redis_zrevrange period=%s err=%v", period, err)
+	}
+
+	records, err := l.fetchLeaderboardFromStore(ctx, kind, period)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 && kind == model.LeaderboardPeriodAllTime {
+		records = l.rebuildLeaderboardFromUsers(ctx)
 	}
 
-	allRows, err := l.fetchLeaderboardFromCosmos(ctx)
+	return rankLeaderboard(records, top), nil
+}
+
+// FetchLeaderboardPosition returns the user's rank and score in the current
+// week, month or of all time, with up to neighbours entries on either side,
+// neighbours being kept between 0 and MaxLeaderboardNeighbours.
+func (l *Logic) FetchLeaderboardPosition(ctx context.Context, userID string, kind model.LeaderboardPeriod, neighbours int) (*model.LeaderboardPosition, error) {
+	logger := l.GetLogger()
+
+	neighbours = max(min(neighbours, MaxLeaderboardNeighbours), 0)
+	period := leaderboardPeriod(kind, time.Now().UTC())
+	key := LeaderboardKeyPrefix + period
+
+	pos, err := l.Redis.ZRevRank(ctx, key, userID).Result()
+	if errors.Is(err, redis.Nil) && l.Redis.Exists(ctx, key).Val() == 0 {
+		// Nothing cached for the period yet: warm it from the store.
+		if _, err = l.fetchLeaderboardFromStore(ctx, kind, period); err == nil {
+			pos, err = l.Redis.ZRevRank(ctx, key, userID).Result()
+		}
+	}
+	if errors.Is(err, redis.Nil) {
+		return &model.LeaderboardPosition{Above: []*model.LeaderboardEntry{}, Below: []*model.LeaderboardEntry{}}, nil
+	}
+	if err == nil {
+		start := max(pos-int64(neighbours), 0)
+		entries, ids, rErr := l.rankedRange(ctx, key, start, pos+int64(neighbours))
+		if rErr == nil {
+			return leaderboardPosition(entries, ids, userID), nil
+		}
+		err = rErr
+	}
+	logger.Printf("level=info op=GetLeaderboardPosition stage=redis userId=%s period=%s err=%v", userID, period, err)
+
+	records, err := l.fetchLeaderboardFromStore(ctx, kind, period)
 	if err != nil {
 		return nil, err
 	}
-	if len(allRows) == 0 {
-		allRows = l.rebuildLeaderboardFromUsers(ctx)
+	entries := rankLeaderboard(records, len(records))
+	ids := make([]string, len(records))
+	for i, rec := range records {
+		ids[i] = rec.ID
+	}
+	position := leaderboardPosition(entries, ids, userID)
+	if len(position.Above) > neighbours {
+		position.Above = position.Above[len(position.Above)-neighbours:]
 	}
+	if len(position.Below) > neighbours {
+		position.Below = position.Below[:neighbours]
+	}
+	return position, nil
+}
 
-	return rankLeaderboard(allRows, top), nil
+// leaderboardPosition splits ranked entries, whose members are ids, around
+// the user's own.
+func leaderboardPosition(entries []*model.LeaderboardEntry, ids []string, userID string) *model.LeaderboardPosition {
+	position := &model.LeaderboardPosition{Above: []*model.LeaderboardEntry{}, Below: []*model.LeaderboardEntry{}}
+	at := slices.Index(ids, userID)
+	if at < 0 {
+		return position
+	}
+	position.Rank = &entries[at].Rank
+	position.Score = entries[at].Score
+	position.Above = entries[:at]
+	position.Below = entries[at+1:]
+	return position
 }
 
-func (l *Logic) fetchLeaderboardFromRedis(ctx context.Context, top int) ([]*model.LeaderboardEntry, bool, error) {
-	vals, err := l.Redis.ZRevRangeWithScores(ctx, LeaderboardGlobal, 0, int64(top-1)).Result()
+// rankedRange reads the members of the sorted set at key from start to stop,
+// highest score first, ranked and named without a lookup per member. It also
+// returns the member ids, in the same order.
+func (l *Logic) rankedRange(ctx context.Context, key string, start, stop int64) ([]*model.LeaderboardEntry, []string, error) {
+	vals, err := l.Redis.ZRevRangeWithScores(ctx, key, start, stop).Result()
+	if err != nil || len(vals) == 0 {
+		return nil, nil, err
+	}
+
+	ids := make([]string, 0, len(vals))
+	for _, z := range vals {
+		uid, _ := z.Member.(string)
+		ids = append(ids, uid)
+	}
+	nicknames, err := l.Redis.HMGet(ctx, LeaderboardNicknames, ids...).Result()
 	if err != nil {
-		return nil, false, err
+		return nil, nil, err
 	}
-	if len(vals) == 0 {
-		return nil, false, nil
+
+	// Tied scores share the rank of the first of them, which needs counting
+	// the higher scores when the range starts among ties.
+	rank := int32(start + 1)
+	if start > 0 {
+		higher, err := l.Redis.ZCount(ctx, key, "("+strconv.FormatFloat(vals[0].Score, 'f', -1, 64), "+inf").Result()
+		if err != nil {
+			return nil, nil, err
+		}
+		rank = int32(higher + 1)
 	}
 
 	entries := make([]*model.LeaderboardEntry, 0, len(vals))
 	for i, z := range vals {
-		uid, ok := z.Member.(string)
-		if !ok {
-			continue
+		if i > 0 && z.Score != vals[i-1].Score {
+			rank = int32(start) + int32(i) + 1
 		}
-		score := int(z.Score)
-
-		nickname := "Unknown"
-		if user, uErr := l.FetchUser(ctx, uid); uErr == nil && user != nil {
-			nickname = user.Nickname
+		nickname, _ := nicknames[i].(string)
+		if nickname == "" {
+			nickname = l.leaderboardNickname(ctx, ids[i])
 		}
-
 		entries = append(entries, &model.LeaderboardEntry{
-			Rank:     int32(i + 1),
+			Rank:     rank,
 			Nickname: nickname,
-			Score:    int32(score),
+			Score:    int32(z.Score),
 		})
 	}
 
-	return entries, true, nil
+	return entries, ids, nil
+}
+
+// leaderboardNickname finds the nickname of a user that is missing from
+// Redis, in their all-time record or else their profile for users who never
+// scored, and puts it back.
+func (l *Logic) leaderboardNickname(ctx context.Context, userID string) string {
+	nickname := "Unknown"
+	if record, err := l.Repos.Leaderboard.Get(ctx, LeaderboardPeriodGlobal, userID); err == nil && record.Nickname != "" {
+		nickname = record.Nickname
+	} else if user, err := l.FetchUser(ctx, userID); err == nil {
+		nickname = user.Nickname
+	} else {
+		return nickname
+	}
+	l.Redis.HSet(ctx, LeaderboardNicknames, userID, nickname)
+	return nickname
 }
 
-func (l *Logic) fetchLeaderboardFromCosmos(ctx context.Context) ([]*model.LeaderboardEntry, error) {
+// fetchLeaderboardFromStore returns the period's records in ranking order and
+// caches them in Redis.
+func (l *Logic) fetchLeaderboardFromStore(ctx context.Context, kind model.LeaderboardPeriod, period string) ([]*repository.LeaderboardRecord, error) {
 	logger := l.GetLogger()
 
-	container, err := l.Cosmos.NewContainer(CosmosDatabase, ContainerLeaderboard)
+	records, err := l.Repos.Leaderboard.ListByPeriod(ctx, period)
 	if err != nil {
-		logger.Printf("level=error op=GetLeaderboard stage=new_container db=%s container=%s err=%v",
-			CosmosDatabase, ContainerLeaderboard, err)
-		return nil, err
+		logger.Printf("level=warn op=GetLeaderboard stage=store_query period=%s err=%v", period, err)
+		return []*repository.LeaderboardRecord{}, nil
 	}
 
-	query := "SELECT * FROM c"
-	qOpts := azcosmos.QueryOptions{}
-
-	pager := container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString("global"), &qOpts)
-
-	type lbDoc struct {
-		ID       string `json:"id"`
-		Nickname string `json:"nickname"`
-		Score    int    `json:"score"`
+	for _, rec := range records {
+		l.cachePeriodScore(ctx, kind, period, rec)
 	}
+	sortLeaderboard(records)
 
-	var allRows []*model.LeaderboardEntry
-
-	for pager.More() {
-		resp, err := pager.NextPage(ctx)
-		if err != nil {
-			logger.Printf("level=warn op=GetLeaderboard stage=query_next_page err=%v", err)
-			break
-		}
-
-		for _, itemBytes := range resp.Items {
-			var doc lbDoc
-			if err := json.Unmarshal(itemBytes, &doc); err == nil {
-				if err := l.Redis.ZAdd(ctx, LeaderboardGlobal, redis.Z{
-					Score:  float64(doc.Score),
-					Member: doc.ID,
-				}).Err(); err != nil {
-					logger.Printf("level=warn op=GetLeaderboard stage=redis_heal key=%s err=%v", LeaderboardGlobal, err)
-				}
-
-				allRows = append(allRows, &model.LeaderboardEntry{
-					Nickname: doc.Nickname,
-					Score:    int32(doc.Score),
-				})
-			}
-		}
-	}
-
-	sort.Slice(allRows, func(i, j int) bool {
-		return allRows[i].Score > allRows[j].Score
-	})
-
-	return allRows, nil
+	return records, nil
 }
 
-func (l *Logic) rebuildLeaderboardFromUsers(ctx context.Context) []*model.LeaderboardEntry {
+func (l *Logic) rebuildLeaderboardFromUsers(ctx context.Context) []*repository.LeaderboardRecord {
 	logger := l.GetLogger()
 	logger.Printf("level=info op=GetLeaderboard stage=fallback_migration msg=leaderboard_empty_scanning_users")
 
-	userContainer, err := l.Cosmos.NewContainer(CosmosDatabase, ContainerUsers)
+	users, err := l.Repos.Users.ListWithEcoPoints(ctx)
 	if err != nil {
-		return []*model.LeaderboardEntry{}
+		return []*repository.LeaderboardRecord{}
 	}
 
-	uQuery := "SELECT * FROM c WHERE c.gamification.totalEcoPoints > 0"
-	uPager := userContainer.NewQueryItemsPager(uQuery, azcosmos.PartitionKey{}, &azcosmos.QueryOptions{})
-
-	allRows := []*model.LeaderboardEntry{}
-	for uPager.More() {
-		resp, err := uPager.NextPage(ctx)
-		if err != nil {
-			break
+	records := []*repository.LeaderboardRecord{}
+	for _, user := range users {
+		if user.Gamification == nil {
+			continue
 		}
-		for _, itemBytes := range resp.Items {
-			var user model.User
-			if err := json.Unmarshal(itemBytes, &user); err == nil && user.Gamification != nil {
-				score := int(user.Gamification.TotalEcoPoints)
-
-				allRows = append(allRows, &model.LeaderboardEntry{
-					Nickname: user.Nickname,
-					Score:    int32(score),
-				})
+		records = append(records, &repository.LeaderboardRecord{
+			ID:       user.ID,
+			Period:   LeaderboardPeriodGlobal,
+			Nickname: user.Nickname,
+			Score:    int(user.Gamification.TotalEcoPoints),
+		})
 
-				l.UpsertLeaderboardEntry(ctx, &user)
-			}
-		}
+		l.UpsertLeaderboardEntry(ctx, user, 0)
 	}
+	sortLeaderboard(records)
 
-	sort.Slice(allRows, func(i, j int) bool {
-		return allRows[i].Score > allRows[j].Score
-	})
+	return records
+}
 
-	return allRows
+// sortLeaderboard orders records as Redis ranks them: highest score first,
+// ties by id in reverse.
+func sortLeaderboard(records []*repository.LeaderboardRecord) {
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Score != records[j].Score {
+			return records[i].Score > records[j].Score
+		}
+		return records[i].ID > records[j].ID
+	})
 }
 
-func rankLeaderboard(allRows []*model.LeaderboardEntry, top int) []*model.LeaderboardEntry {
+// rankLeaderboard ranks the first top of the sorted records. Tied scores
+// share the rank of the first of them, as in 1, 2, 2, 4.
+func rankLeaderboard(records []*repository.LeaderboardRecord, top int) []*model.LeaderboardEntry {
 	entries := []*model.LeaderboardEntry{}
-	for i, row := range allRows {
+	for i, rec := range records {
 		if i >= top {
 			break
 		}
-		row.Rank = int32(i + 1)
-		entries = append(entries, row)
+		rank := int32(i + 1)
+		if i > 0 && rec.Score == records[i-1].Score {
+			rank = entries[i-1].Rank
+		}
+		entries = append(entries, &model.LeaderboardEntry{
+			Rank:     rank,
+			Nickname: rec.Nickname,
+			Score:    int32(rec.Score),
+		})
 	}
 	return entries
 }
@@ -230,30 +354,14 @@ func (l *Logic) SyncNickname(ctx context.Context, userID, nickname string) error
 		return nil
 	}
 
-	query := "SELECT * FROM c WHERE c.nickname = @nickname"
-	qOpts := azcosmos.QueryOptions{
-		QueryParameters: []azcosmos.QueryParameter{
-			{Name: "@nickname", Value: nickname},
-		},
-	}
-	container, err := l.Cosmos.NewContainer(CosmosDatabase, ContainerUsers)
+	exists, err := l.Repos.Users.NicknameExists(ctx, nickname)
 	if err != nil {
-		logger.Printf("level=error op=UpdateNickname stage=new_container db=%s container=%s userId=%s err=%v",
-			CosmosDatabase, ContainerUsers, userID, err)
-		return err
+		logger.Printf("level=error op=UpdateNickname stage=query userId=%s err=%v", userID, err)
+		return nil
 	}
-
-	pager := container.NewQueryItemsPager(query, azcosmos.PartitionKey{}, &qOpts)
-	for pager.More() {
-		resp, err := pager.NextPage(ctx)
-		if err != nil {
-			logger.Printf("level=error op=UpdateNickname stage=query_next_page userId=%s err=%v", userID, err)
-			return nil
-		}
-		if len(resp.Items) > 0 {
-			logger.Printf("level=error op=UpdateNickname stage=nickname_exists userId=%s nickname=%s", userID, nickname)
-			return fmt.Errorf("nickname already in use")
-		}
+	if exists {
+		logger.Printf("level=error op=UpdateNickname stage=nickname_exists userId=%s nickname=%s", userID, nickname)
+		return fmt.Errorf("nickname already in use")
 	}
 
 	user.Nickname = nickname
@@ -263,7 +371,7 @@ func (l *Logic) SyncNickname(ctx context.Context, userID, nickname string) error
 	}
 	l.SetUserCache(ctx, user)
 
-	l.UpsertLeaderboardEntry(ctx, user)
+	l.UpsertLeaderboardEntry(ctx, user, 0)
 
 	return nil
 }
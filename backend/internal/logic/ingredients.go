@@ -0,0 +1,132 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/mariocosenza/mocc/graph/model"
+)
+
+type requestCacheKey struct{}
+
+// requestCache keeps the fridge contents read while resolving one operation,
+// so listing many recipes reads each fridge once.
+type requestCache struct {
+	mu    sync.Mutex
+	items map[string][]*model.InventoryItem
+}
+
+// WithRequestCache returns a context whose fridge reads are shared by every
+// resolver of the operation. It is meant for queries only: resolvers of a
+// mutation would see the items as they were before its writes.
+func WithRequestCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requestCacheKey{}, &requestCache{items: map[string][]*model.InventoryItem{}})
+}
+
+// userFridgeItems returns the items of every fridge the user has access to,
+// from the request cache when there is one.
+func (l *Logic) userFridgeItems(ctx context.Context, userID string) ([]*model.InventoryItem, error) {
+	cache, _ := ctx.Value(requestCacheKey{}).(*requestCache)
+	if cache != nil {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		if items, ok := cache.items[userID]; ok {
+			return items, nil
+		}
+	}
+
+	items, _, err := l.fridgeItems(ctx, userID, model.FridgeRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	if cache != nil {
+		cache.items[userID] = items
+	}
+	return items, nil
+}
+
+// ResolveRecipeIngredients returns the recipe's ingredients with the fridge
+// item each one would use and whether there is enough of it. Linked
+// ingredients use their item while it is still in the fridge; the others get
+// the best match, which is shown but not stored until the user confirms it.
+func (l *Logic) ResolveRecipeIngredients(ctx context.Context, userID string, recipe *model.Recipe) ([]*model.RecipeIngredient, error) {
+	if len(recipe.Ingredients) == 0 {
+		return recipe.Ingredients, nil
+	}
+
+	items, err := l.userFridgeItems(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*model.InventoryItem, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+
+	resolved := make([]*model.RecipeIngredient, 0, len(recipe.Ingredients))
+	for _, ing := range recipe.Ingredients {
+		out := *ing
+		out.InventoryItem = nil
+		out.IsAvailableInFridge = false
+
+		if ing.InventoryItemID != nil && byID[*ing.InventoryItemID] != nil {
+			out.InventoryItem = byID[*ing.InventoryItemID]
+			out.IsAvailableInFridge = out.InventoryItem.Status == model.ItemStatusAvailable && hasEnough(out.InventoryItem, ing, recipe.ID)
+		} else if candidates := matchIngredient(ing, items, recipe.ID); len(candidates) > 0 {
+			out.InventoryItem = candidates[0].Item
+			out.IsAvailableInFridge = candidates[0].Sufficient
+		}
+		resolved = append(resolved, &out)
+	}
+	return resolved, nil
+}
+
+// FetchIngredientMatches lists, for every ingredient of one of the user's
+// recipes, the fridge items it could be linked to, best first.
+func (l *Logic) FetchIngredientMatches(ctx context.Context, userID, recipeID string) ([]*model.IngredientMatchSuggestion, error) {
+	recipe, err := l.FetchRecipe(ctx, recipeID)
+	if err != nil {
+		return nil, err
+	}
+	if recipe.AuthorID != userID {
+		return nil, fmt.Errorf("unauthorized")
+	}
+
+	items, err := l.userFridgeItems(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := make([]*model.IngredientMatchSuggestion, 0, len(recipe.Ingredients))
+	for i, ing := range recipe.Ingredients {
+		suggestions = append(suggestions, &model.IngredientMatchSuggestion{
+			IngredientIndex:        int32(i),
+			IngredientName:         ing.Name,
+			CurrentInventoryItemID: ing.InventoryItemID,
+			Candidates:             matchIngredient(ing, items, recipe.ID),
+		})
+	}
+	return suggestions, nil
+}
+
+// MatchFridgeIngredients links each ingredient to its best match in the
+// user's fridge and flags whether there is enough of it.
+func (l *Logic) MatchFridgeIngredients(ctx context.Context, userID string, ingredients []*model.RecipeIngredient) error {
+	items, err := l.userFridgeItems(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, ing := range ingredients {
+		ing.InventoryItemID = nil
+		ing.IsAvailableInFridge = false
+		if candidates := matchIngredient(ing, items, ""); len(candidates) > 0 {
+			ing.InventoryItemID = toPtr(candidates[0].Item.ID)
+			ing.IsAvailableInFridge = candidates[0].Sufficient
+		}
+	}
+	return nil
+}
@@ -0,0 +1,325 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mariocosenza/mocc/graph/model"
+	"github.com/mariocosenza/mocc/internal/repository"
+	"github.com/mariocosenza/mocc/internal/units"
+)
+
+// ItemWrite tells mutateItem what to do with an item once the mutation ran.
+type ItemWrite int
+
+const (
+	// ItemKeep leaves the stored item untouched.
+	ItemKeep ItemWrite = iota
+	// ItemReplace writes the mutated item back.
+	ItemReplace
+	// ItemDelete removes the item from the fridge.
+	ItemDelete
+)
+
+func (l *Logic) FetchInventoryItems(ctx context.Context, fridgeID string) ([]*model.InventoryItem, error) {
+	logger := l.GetLogger()
+
+	items, err := l.Repos.Inventory.ListByFridge(ctx, fridgeID)
+	if err != nil {
+		logger.Printf("level=error op=GetInventoryItems stage=query fridgeId=%s err=%v", fridgeID, err)
+		return nil, err
+	}
+	if items == nil {
+		items = []*model.InventoryItem{}
+	}
+	return items, nil
+}
+
+// FetchFridgeItems resolves the items of a fridge read from the store,
+// migrating them first if the fridge document still embeds them.
+func (l *Logic) FetchFridgeItems(ctx context.Context, fridge *model.Fridge) ([]*model.InventoryItem, error) {
+	if len(fridge.Items) > 0 {
+		if err := l.explodeFridge(ctx, fridge.ID); err != nil {
+			return nil, err
+		}
+	}
+	return l.FetchInventoryItems(ctx, fridge.ID)
+}
+
+// AddInventoryItems stores new items in the given fridge, or the user's active
+// one, logging each one as an event of the given type.
+func (l *Logic) AddInventoryItems(ctx context.Context, userID string, fridgeID *string, eventType model.InventoryEventType, items ...*model.InventoryItem) error {
+	target, err := l.EditableFridgeID(ctx, userID, fridgeID)
+	if err != nil {
+		return err
+	}
+	return l.storeItems(ctx, target, itemEvent(eventType, userID), items)
+}
+
+// ImportInventoryItems stores the items bought on a shopping trip in the
+// given fridge, or the user's active one, as one operation, which undoing
+// removes again.
+func (l *Logic) ImportInventoryItems(ctx context.Context, userID string, fridgeID *string, entryID string, operationID *string, items ...*model.InventoryItem) error {
+	target, err := l.EditableFridgeID(ctx, userID, fridgeID)
+	if err != nil {
+		return err
+	}
+	op, err := l.beginOperation(ctx, userID, target, operationID, model.InventoryEventTypeImported)
+	if err != nil {
+		return err
+	}
+
+	if err := l.storeItems(ctx, target, op.event(userID), items); err != nil {
+		return err
+	}
+	for _, item := range items {
+		op.Items = append(op.Items, &operationItem{ItemID: item.ID, After: cloneItem(item)})
+	}
+	op.HistoryEntryID = entryID
+	l.saveOperation(ctx, userID, op)
+	return nil
+}
+
+// storeItems writes the items to the fridge, logging a copy of template for
+// each of them.
+func (l *Logic) storeItems(ctx context.Context, fridgeID string, template *model.InventoryEvent, items []*model.InventoryItem) error {
+	logger := l.GetLogger()
+
+	for _, item := range items {
+		if err := l.Repos.Inventory.Upsert(ctx, fridgeID, item); err != nil {
+			logger.Printf("level=error op=SaveInventoryItem stage=upsert fridgeId=%s itemId=%s err=%v", fridgeID, item.ID, err)
+			return err
+		}
+		event := *template
+		completeEvent(&event, fridgeID, nil, item, ItemReplace)
+		l.appendEvent(ctx, &event)
+	}
+	l.PublishFridgeChanged(ctx, fridgeID)
+	return nil
+}
+
+// MutateInventoryItem runs mutate against one item of the user's fridges, the
+// one given or else whichever holds it, and
+// applies the returned ItemWrite only if nobody else wrote the item in the
+// meantime, retrying from a fresh read otherwise. It returns the item as
+// mutate left it, or ErrNoSuchItem. A change that gets written is logged as
+// an event of the given type done by the user.
+func (l *Logic) MutateInventoryItem(ctx context.Context, userID string, fridgeID *string, itemID string, eventType model.InventoryEventType, mutate func(item *model.InventoryItem) (ItemWrite, error)) (*model.InventoryItem, error) {
+	target, err := l.FridgeIDForItem(ctx, userID, fridgeID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	return l.mutateItem(ctx, target, itemID, itemEvent(eventType, userID), mutate)
+}
+
+// mutateItem is MutateInventoryItem for a known fridge. event, when not nil,
+// is completed from the item and appended to the fridge's log once the write
+// lands.
+func (l *Logic) mutateItem(ctx context.Context, fridgeID, itemID string, event *model.InventoryEvent, mutate func(item *model.InventoryItem) (ItemWrite, error)) (*model.InventoryItem, error) {
+	logger := l.GetLogger()
+
+	var item, before *model.InventoryItem
+	var written ItemWrite
+	err := l.withWriteRetry(ctx, "SaveInventoryItem", itemID, "item", func() error {
+		current, etag, err := l.Repos.Inventory.Get(ctx, fridgeID, itemID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrNoSuchItem
+		}
+		if err != nil {
+			logger.Printf("level=error op=GetInventoryItem stage=read_item fridgeId=%s itemId=%s err=%v", fridgeID, itemID, err)
+			return err
+		}
+
+		before = cloneItem(current)
+		write, err := mutate(current)
+		if err != nil {
+			return err
+		}
+		item, written = current, write
+
+		switch write {
+		case ItemReplace:
+			err = l.Repos.Inventory.Replace(ctx, fridgeID, current, etag)
+		case ItemDelete:
+			err = l.Repos.Inventory.Delete(ctx, fridgeID, itemID, etag)
+		default:
+			return nil
+		}
+		if err == nil {
+			l.PublishFridgeChanged(ctx, fridgeID)
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrNoSuchItem
+		}
+		if err != nil && !errors.Is(err, repository.ErrConflict) {
+			logger.Printf("level=error op=SaveInventoryItem stage=write fridgeId=%s itemId=%s err=%v", fridgeID, itemID, err)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if event != nil && written != ItemKeep {
+		completeEvent(event, fridgeID, before, item, written)
+		l.appendEvent(ctx, event)
+	}
+	return item, nil
+}
+
+// cloneItem deep-copies an item, locks included.
+func cloneItem(item *model.InventoryItem) *model.InventoryItem {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil
+	}
+	var clone model.InventoryItem
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return nil
+	}
+	return &clone
+}
+
+// DepleteItem takes amount, in unit or in the item's own unit when unit is
+// nil, out of the item and tells whether to keep or delete it. Stock counted
+// as QB is never used up. The price shrinks with the quantity, so it always
+// tells what is left is worth.
+func DepleteItem(item *model.InventoryItem, amount float64, unit *model.Unit) (ItemWrite, error) {
+	from := item.Quantity.Unit
+	if unit != nil {
+		from = *unit
+	}
+	taken, err := units.Required(amount, from, item.Quantity.Unit, units.ProductOf(item))
+	if err != nil {
+		return ItemKeep, unitError(item.Name, err)
+	}
+	if taken == 0 {
+		return ItemKeep, nil
+	}
+
+	before := item.Quantity.Value
+	newVal := before - taken
+	if newVal < -0.001 {
+		return ItemKeep, fmt.Errorf("insufficient quantity")
+	}
+	if newVal < 0.001 {
+		if len(item.ActiveLocks) > 0 {
+			return ItemKeep, fmt.Errorf("cannot consume item %s completely because it is used in active recipes", item.Name)
+		}
+		item.Quantity.Value = 0
+		shrinkPrice(item, before)
+		refreshVirtualAvailable(item)
+		return ItemDelete, nil
+	}
+
+	item.Quantity.Value = newVal
+	shrinkPrice(item, before)
+	refreshVirtualAvailable(item)
+	if item.VirtualAvailable < -0.001 {
+		return ItemKeep, fmt.Errorf("cannot consume item %s below the amount locked by active recipes", item.Name)
+	}
+	return ItemReplace, nil
+}
+
+// ChangeItemUnit switches the item to a new quantity, converting the amounts
+// its recipes have locked so they keep meaning the same thing.
+func ChangeItemUnit(item *model.InventoryItem, quantity *model.Quantity) error {
+	if quantity.Unit != item.Quantity.Unit {
+		product := units.ProductOf(item)
+		for _, lock := range item.ActiveLocks {
+			amount, err := units.Required(lock.Amount, item.Quantity.Unit, quantity.Unit, product)
+			if err != nil {
+				return unitError(item.Name, err)
+			}
+			lock.Amount = amount
+		}
+	}
+
+	item.Quantity = quantity
+	refreshVirtualAvailable(item)
+	if item.VirtualAvailable < -0.001 {
+		return fmt.Errorf("cannot reduce quantity below locked amount (%f)", item.Quantity.Value-item.VirtualAvailable)
+	}
+	return nil
+}
+
+// shrinkPrice scales the item's price to the quantity left after part of the
+// before quantity was taken out.
+func shrinkPrice(item *model.InventoryItem, before float64) {
+	if item.Price == nil || before <= 0 {
+		return
+	}
+	item.Price = toPtr(*item.Price * item.Quantity.Value / before)
+}
+
+// refreshVirtualAvailable recomputes what is left of the item once every
+// active lock is taken out.
+func refreshVirtualAvailable(item *model.InventoryItem) {
+	item.VirtualAvailable = item.Quantity.Value
+	for _, lock := range item.ActiveLocks {
+		item.VirtualAvailable -= lock.Amount
+	}
+}
+
+// MigrateEmbeddedInventory moves the items of every fridge written before
+// items became documents of their own. Fridges are also migrated lazily on
+// first access, so this only speeds things up and is safe to run repeatedly.
+func (l *Logic) MigrateEmbeddedInventory(ctx context.Context) error {
+	logger := l.GetLogger()
+
+	fridges, err := l.Repos.Fridges.ListWithEmbeddedItems(ctx)
+	if err != nil {
+		logger.Printf("level=error op=MigrateEmbeddedInventory stage=query err=%v", err)
+		return err
+	}
+
+	failed := 0
+	for _, fridge := range fridges {
+		if err := l.explodeFridge(ctx, fridge.ID); err != nil {
+			failed++
+		}
+	}
+
+	logger.Printf("level=info op=MigrateEmbeddedInventory stage=done fridges=%d failed=%d", len(fridges), failed)
+	return nil
+}
+
+// explodeFridge writes every item embedded in the fridge document as its own
+// document, then drops them from the fridge. Items already exploded by an
+// earlier, interrupted run are left alone so later changes are not undone.
+func (l *Logic) explodeFridge(ctx context.Context, fridgeID string) error {
+	logger := l.GetLogger()
+
+	err := l.withWriteRetry(ctx, "ExplodeFridge", fridgeID, "fridge", func() error {
+		fridge, etag, err := l.Repos.Fridges.Get(ctx, fridgeID)
+		if err != nil {
+			return err
+		}
+		if len(fridge.Items) == 0 {
+			return nil
+		}
+
+		for _, item := range fridge.Items {
+			_, _, err := l.Repos.Inventory.Get(ctx, fridgeID, item.ID)
+			if err == nil {
+				continue
+			}
+			if !errors.Is(err, repository.ErrNotFound) {
+				return err
+			}
+			if err := l.Repos.Inventory.Upsert(ctx, fridgeID, item); err != nil {
+				return err
+			}
+		}
+
+		fridge.Items = nil
+		return l.Repos.Fridges.Replace(ctx, fridge, etag)
+	})
+	if err != nil {
+		logger.Printf("level=error op=ExplodeFridge stage=migrate fridgeId=%s err=%v", fridgeID, err)
+		return err
+	}
+	logger.Printf("level=info op=ExplodeFridge stage=done fridgeId=%s", fridgeID)
+	return nil
+}
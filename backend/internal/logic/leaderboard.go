@@ -0,0 +1,298 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/mariocosenza/mocc/graph/model"
+	"github.com/mariocosenza/mocc/internal/repository"
+)
+
+// leaderboardKey names the week or month t falls in, such as 2026-W42 or
+// 2026-10. Weeks are ISO weeks, starting on Monday.
+func leaderboardKey(kind model.LeaderboardPeriod, t time.Time) string {
+	switch kind {
+	case model.LeaderboardPeriodWeek:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case model.LeaderboardPeriodMonth:
+		return t.Format("2006-01")
+	}
+	return ""
+}
+
+// leaderboardPeriod is the store partition, and the Redis key suffix, that
+// holds the scores of the period t falls in.
+func leaderboardPeriod(kind model.LeaderboardPeriod, t time.Time) string {
+	switch kind {
+	case model.LeaderboardPeriodWeek:
+		return LeaderboardPeriodWeekPrefix + leaderboardKey(kind, t)
+	case model.LeaderboardPeriodMonth:
+		return LeaderboardPeriodMonthPrefix + leaderboardKey(kind, t)
+	}
+	return LeaderboardPeriodGlobal
+}
+
+// previousPeriod returns a time within the week or month before the one t
+// falls in.
+func previousPeriod(kind model.LeaderboardPeriod, t time.Time) time.Time {
+	if kind == model.LeaderboardPeriodMonth {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+	}
+	return t.AddDate(0, 0, -7)
+}
+
+// leaderboardRetention is how long the Redis copy of a period outlives its
+// last write, long enough for the period to be over and archived.
+func leaderboardRetention(kind model.LeaderboardPeriod) time.Duration {
+	if kind == model.LeaderboardPeriodMonth {
+		return 62 * 24 * time.Hour
+	}
+	return 15 * 24 * time.Hour
+}
+
+// RunLeaderboardRollover archives the standings of every week and month once
+// it is over, checking every interval until ctx is done. As with the lock
+// reaper, only the replica holding the Redis lease works on a given tick.
+func (l *Logic) RunLeaderboardRollover(ctx context.Context, interval time.Duration) {
+	logger := l.GetLogger()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if l.Redis != nil {
+			leased, err := l.Redis.SetNX(ctx, LeaderboardRolloverLeaseKey, "1", interval/2).Result()
+			if err != nil {
+				logger.Printf("level=warn op=LeaderboardRollover stage=lease err=%v", err)
+				continue
+			}
+			if !leased {
+				continue
+			}
+		}
+
+		if err := l.CloseLeaderboardPeriods(ctx, time.Now().UTC()); err != nil {
+			logger.Printf("level=error op=LeaderboardRollover stage=close err=%v", err)
+		}
+	}
+}
+
+// CloseLeaderboardPeriods archives the final standings and winners of every
+// week and month that is over and not archived yet, oldest first, so periods
+// that closed while no replica was running are caught up on. Without any
+// archive of a kind only the period just before now is archived, and no more
+// than MaxLeaderboardArchives periods are caught up on at once. Scores of
+// the new periods start from zero as they are kept apart, so nothing needs
+// resetting.
+func (l *Logic) CloseLeaderboardPeriods(ctx context.Context, now time.Time) error {
+	for _, kind := range []model.LeaderboardPeriod{model.LeaderboardPeriodWeek, model.LeaderboardPeriodMonth} {
+		latest, err := l.Repos.Leaderboard.ListArchives(ctx, kind, 1)
+		if err != nil {
+			return err
+		}
+
+		pending := []time.Time{}
+		for closed := previousPeriod(kind, now); len(pending) < MaxLeaderboardArchives; closed = previousPeriod(kind, closed) {
+			if len(latest) > 0 && latest[0].Key >= leaderboardKey(kind, closed) {
+				break
+			}
+			pending = append(pending, closed)
+			if len(latest) == 0 {
+				break
+			}
+		}
+
+		for _, closed := range slices.Backward(pending) {
+			if err := l.archivePeriod(ctx, kind, closed, now); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// archivePeriod keeps the final standings of the week or month closed falls
+// in. A period archived already by another replica is left as it is.
+func (l *Logic) archivePeriod(ctx context.Context, kind model.LeaderboardPeriod, closed, now time.Time) error {
+	period := leaderboardPeriod(kind, closed)
+	records, err := l.Repos.Leaderboard.ListByPeriod(ctx, period)
+	if err != nil {
+		return err
+	}
+	scored := slices.DeleteFunc(records, func(rec *repository.LeaderboardRecord) bool { return rec.Score <= 0 })
+	sortLeaderboard(scored)
+	standings := rankLeaderboard(scored, LeaderboardArchiveSize)
+	winners := []*model.LeaderboardEntry{}
+	for _, entry := range standings {
+		if entry.Rank <= LeaderboardWinners {
+			winners = append(winners, entry)
+		}
+	}
+
+	archive := &model.LeaderboardArchive{
+		Period:    kind,
+		Key:       leaderboardKey(kind, closed),
+		ClosedAt:  now.Format(time.RFC3339),
+		Standings: standings,
+		Winners:   winners,
+	}
+	if err := l.Repos.Leaderboard.CreateArchive(ctx, archive); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return nil
+		}
+		return err
+	}
+	l.GetLogger().Printf("level=info op=LeaderboardRollover stage=archived period=%s entries=%d", period, len(standings))
+	return nil
+}
+
+// FetchLeaderboardArchives returns the final standings of the last closed
+// weeks or months, newest first, between 1 and MaxLeaderboardArchives of
+// them. All-time scores never close.
+func (l *Logic) FetchLeaderboardArchives(ctx context.Context, kind model.LeaderboardPeriod, last int) ([]*model.LeaderboardArchive, error) {
+	if kind == model.LeaderboardPeriodAllTime {
+		return []*model.LeaderboardArchive{}, nil
+	}
+	last = max(min(last, MaxLeaderboardArchives), 1)
+	archives, err := l.Repos.Leaderboard.ListArchives(ctx, kind, last)
+	if err != nil {
+		l.GetLogger().Printf("level=error op=GetLeaderboardArchive stage=query period=%s err=%v", kind, err)
+		return nil, err
+	}
+	return archives, nil
+}
+
+// FetchScopedLeaderboard ranks the members of a household, the fridge given
+// or else the user's active one, or the user together with their friends.
+// Scores are the same as on the global leaderboard; members who have none
+// yet are ranked with zero.
+func (l *Logic) FetchScopedLeaderboard(ctx context.Context, userID string, top int, kind model.LeaderboardPeriod, scope model.LeaderboardScope, fridgeID *string) ([]*model.LeaderboardEntry, error) {
+	if scope == model.LeaderboardScopeGlobal {
+		return l.FetchLeaderboard(ctx, top, kind)
+	}
+	if top <= 0 {
+		return []*model.LeaderboardEntry{}, nil
+	}
+
+	ids, err := l.scopeMembers(ctx, userID, scope, fridgeID)
+	if err != nil {
+		return nil, err
+	}
+	period := leaderboardPeriod(kind, time.Now().UTC())
+	scores := l.memberScores(ctx, kind, period, ids)
+
+	nicknames, err := l.Redis.HMGet(ctx, LeaderboardNicknames, ids...).Result()
+	if err != nil {
+		l.GetLogger().Printf("level=info op=GetLeaderboard stage=redis_nicknames scope=%s err=%v", scope, err)
+		nicknames = make([]any, len(ids))
+	}
+
+	records := make([]*repository.LeaderboardRecord, 0, len(ids))
+	for i, id := range ids {
+		nickname, _ := nicknames[i].(string)
+		if nickname == "" {
+			nickname = l.leaderboardNickname(ctx, id)
+		}
+		records = append(records, &repository.LeaderboardRecord{ID: id, Period: period, Nickname: nickname, Score: scores[i]})
+	}
+	sortLeaderboard(records)
+	return rankLeaderboard(records, top), nil
+}
+
+// scopeMembers lists the ids of the users a scoped leaderboard ranks. The
+// friends scope only takes in friends who added the user back, so nobody is
+// ranked against people they never agreed to be compared with.
+func (l *Logic) scopeMembers(ctx context.Context, userID string, scope model.LeaderboardScope, fridgeID *string) ([]string, error) {
+	if scope == model.LeaderboardScopeFriends {
+		user, err := l.FetchUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		return append([]string{userID}, l.mutualFriends(ctx, userID, user.FriendIds)...), nil
+	}
+
+	target, err := l.ResolveFridgeID(ctx, userID, fridgeID)
+	if err != nil {
+		return nil, err
+	}
+	fridge, _, err := l.accessibleFridge(ctx, userID, target)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(fridge.OwnerID), nil
+}
+
+// mutualFriends keeps the friends whose own friends list has userID on it,
+// reading them from the Redis user cache where possible.
+func (l *Logic) mutualFriends(ctx context.Context, userID string, friendIDs []string) []string {
+	if len(friendIDs) == 0 {
+		return []string{}
+	}
+
+	keys := make([]string, len(friendIDs))
+	for i, id := range friendIDs {
+		keys[i] = "user:" + id
+	}
+	cached, err := l.Redis.MGet(ctx, keys...).Result()
+	if err != nil || len(cached) != len(friendIDs) {
+		l.GetLogger().Printf("level=info op=GetLeaderboard stage=redis_friends userId=%s err=%v", userID, err)
+		cached = make([]any, len(friendIDs))
+	}
+
+	mutual := []string{}
+	for i, id := range friendIDs {
+		var friend *model.User
+		if val, ok := cached[i].(string); ok {
+			var decoded model.User
+			if json.Unmarshal([]byte(val), &decoded) == nil {
+				friend = &decoded
+			}
+		}
+		if friend == nil {
+			if friend, err = l.Repos.Users.Get(ctx, id); err != nil {
+				continue
+			}
+		}
+		if slices.Contains(friend.FriendIds, userID) {
+			mutual = append(mutual, id)
+		}
+	}
+	return mutual
+}
+
+// memberScores reads the period scores of the given users from Redis, once
+// the period is cached there, or else from the store.
+func (l *Logic) memberScores(ctx context.Context, kind model.LeaderboardPeriod, period string, ids []string) []int {
+	key := LeaderboardKeyPrefix + period
+	if l.Redis.Exists(ctx, key).Val() == 0 {
+		_, _ = l.fetchLeaderboardFromStore(ctx, kind, period)
+	}
+
+	scores := make([]int, len(ids))
+	vals, err := l.Redis.ZMScore(ctx, key, ids...).Result()
+	if err == nil && len(vals) == len(ids) {
+		for i, v := range vals {
+			scores[i] = int(v)
+		}
+		return scores
+	}
+	l.GetLogger().Printf("level=info op=GetLeaderboard stage=redis_zmscore period=%s err=%v", period, err)
+
+	for i, id := range ids {
+		if record, err := l.Repos.Leaderboard.Get(ctx, period, id); err == nil {
+			scores[i] = record.Score
+		}
+	}
+	return scores
+}
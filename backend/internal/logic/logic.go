@@ -2,25 +2,32 @@ package logic
 
 import (
 	"log"
+	"time"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/mariocosenza/mocc/internal/repository"
 	msgraphsdk "github.com/microsoftgraph/msgraph-sdk-go"
 	"github.com/redis/go-redis/v9"
 )
 
 type Logic struct {
 	Redis       *redis.Client
-	Cosmos      *azcosmos.Client
+	Repos       *repository.Repositories
 	GraphClient *msgraphsdk.GraphServiceClient
 	BlobClient  *azblob.Client
 	Logger      *log.Logger
+	// LockTTL overrides DefaultRecipeLockTTL when positive.
+	LockTTL time.Duration
+	// UndoWindow overrides DefaultUndoWindow when positive.
+	UndoWindow time.Duration
+	// InviteTTL overrides DefaultInviteTTL when positive.
+	InviteTTL time.Duration
 }
 
-func NewLogic(redis *redis.Client, cosmos *azcosmos.Client, graph *msgraphsdk.GraphServiceClient, blob *azblob.Client, logger *log.Logger) *Logic {
+func NewLogic(redis *redis.Client, repos *repository.Repositories, graph *msgraphsdk.GraphServiceClient, blob *azblob.Client, logger *log.Logger) *Logic {
 	return &Logic{
 		Redis:       redis,
-		Cosmos:      cosmos,
+		Repos:       repos,
 		GraphClient: graph,
 		BlobClient:  blob,
 		Logger:      logger,
@@ -0,0 +1,225 @@
+package logic
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/mariocosenza/mocc/graph/model"
+	"github.com/mariocosenza/mocc/internal/units"
+)
+
+// accents folds the accented letters found in Italian product names.
+var accents = strings.NewReplacer(
+	"à", "a", "á", "a", "è", "e", "é", "e", "ì", "i", "í", "i",
+	"ò", "o", "ó", "o", "ù", "u", "ú", "u",
+)
+
+// stopwords carry no meaning about which product is meant.
+var stopwords = map[string]bool{
+	"di": true, "del": true, "della": true, "dello": true, "dei": true, "degli": true, "delle": true,
+	"da": true, "con": true, "e": true, "al": true, "alla": true, "il": true, "lo": true, "la": true,
+	"i": true, "gli": true, "le": true, "un": true, "una": true, "uno": true, "in": true, "per": true,
+	"fresco": true, "fresca": true, "freschi": true, "fresche": true,
+	"of": true, "the": true, "a": true, "an": true, "and": true, "with": true, "fresh": true,
+}
+
+// synonyms maps English words and irregular Italian plurals to the Italian
+// word the stemmer works on, so "eggs" and "uova" both end up as "uovo".
+var synonyms = map[string]string{
+	"uova": "uovo", "egg": "uovo", "eggs": "uovo",
+	"milk": "latte", "butter": "burro", "flour": "farina", "sugar": "zucchero",
+	"salt": "sale", "pepper": "pepe", "oil": "olio", "water": "acqua", "cream": "panna",
+	"tomato": "pomodoro", "tomatoes": "pomodoro", "potato": "patata", "potatoes": "patata",
+	"onion": "cipolla", "onions": "cipolla", "garlic": "aglio", "carrot": "carota", "carrots": "carota",
+	"apple": "mela", "apples": "mela", "pear": "pera", "pears": "pera", "lemon": "limone", "lemons": "limone",
+	"orange": "arancia", "oranges": "arancia", "strawberry": "fragola", "strawberries": "fragola",
+	"mushroom": "fungo", "mushrooms": "fungo", "zucchini": "zucchina", "courgette": "zucchina", "courgettes": "zucchina",
+	"spinach": "spinaci", "lettuce": "lattuga", "basil": "basilico", "parsley": "prezzemolo",
+	"beans": "fagioli", "peas": "piselli", "chickpeas": "ceci", "lentils": "lenticchie",
+	"cheese": "formaggio", "ham": "prosciutto", "chicken": "pollo", "beef": "manzo", "pork": "maiale",
+	"fish": "pesce", "tuna": "tonno", "salmon": "salmone", "rice": "riso", "bread": "pane",
+	"wine": "vino", "vinegar": "aceto", "honey": "miele",
+}
+
+// matchKey turns a product name into the set of stems used for comparison.
+func matchKey(name string) []string {
+	name = accents.Replace(strings.ToLower(name))
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	key := []string{}
+	seen := map[string]bool{}
+	for _, w := range words {
+		if len(w) < 2 || stopwords[w] || strings.IndexFunc(w, unicode.IsLetter) < 0 {
+			continue
+		}
+		s := stem(w)
+		if !seen[s] {
+			seen[s] = true
+			key = append(key, s)
+		}
+	}
+	return key
+}
+
+// stem reduces singular and plural forms, Italian or English, to one stem.
+func stem(w string) string {
+	if syn, ok := synonyms[w]; ok {
+		w = syn
+	} else if len(w) > 4 {
+		switch {
+		case strings.HasSuffix(w, "ies"):
+			w = w[:len(w)-3] + "y"
+		case strings.HasSuffix(w, "oes"), strings.HasSuffix(w, "ches"), strings.HasSuffix(w, "shes"),
+			strings.HasSuffix(w, "xes"), strings.HasSuffix(w, "sses"):
+			w = w[:len(w)-2]
+		case strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss"):
+			w = w[:len(w)-1]
+		}
+	}
+
+	// Italian nouns inflect on the last vowel: mela/mele, limone/limoni,
+	// fungo/funghi. English words lose a trailing e or y the same way.
+	if len(w) > 3 && strings.ContainsRune("aeiouy", rune(w[len(w)-1])) {
+		w = w[:len(w)-1]
+		if len(w) > 3 && w[len(w)-1] == 'h' && (w[len(w)-2] == 'c' || w[len(w)-2] == 'g') {
+			w = w[:len(w)-1]
+		}
+	}
+	return w
+}
+
+// sameStem tolerates one typo in longer words.
+func sameStem(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if len(a) < 5 || len(b) < 5 {
+		return false
+	}
+	return withinOneEdit(a, b)
+}
+
+func withinOneEdit(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(b)-len(a) > 1 {
+		return false
+	}
+	i, j, edits := 0, 0, 0
+	for i < len(a) && j < len(b) {
+		if a[i] == b[j] {
+			i++
+			j++
+			continue
+		}
+		edits++
+		if edits > 1 {
+			return false
+		}
+		if len(a) == len(b) {
+			i++
+		}
+		j++
+	}
+	return edits+(len(b)-j)-(len(a)-i) <= 1
+}
+
+// matchScore rates how well an item name fits an ingredient name, from 0 (no
+// match) to 1 (same product). One name must contain the other, so "latte"
+// matches "latte intero" but "latte di cocco" does not match "cocco rapè".
+func matchScore(ingredient, item []string) float64 {
+	if len(ingredient) == 0 || len(item) == 0 {
+		return 0
+	}
+
+	common := 0
+	for _, a := range ingredient {
+		for _, b := range item {
+			if sameStem(a, b) {
+				common++
+				break
+			}
+		}
+	}
+	if common < len(ingredient) && common < len(item) {
+		return 0
+	}
+	return float64(common) / float64(max(len(ingredient), len(item)))
+}
+
+// matchIngredient ranks the fridge items that could supply the ingredient:
+// best name match first, then items holding enough of it, then the ones
+// expiring soonest. recipeID lets the recipe's own locks count as available.
+func matchIngredient(ing *model.RecipeIngredient, items []*model.InventoryItem, recipeID string) []*model.IngredientMatchCandidate {
+	key := matchKey(ing.Name)
+
+	candidates := []*model.IngredientMatchCandidate{}
+	for _, item := range items {
+		if item.Status != model.ItemStatusAvailable {
+			continue
+		}
+		score := matchScore(key, matchKey(item.Name))
+		if score == 0 {
+			continue
+		}
+		candidates = append(candidates, &model.IngredientMatchCandidate{
+			Item:       item,
+			Score:      score,
+			Sufficient: hasEnough(item, ing, recipeID),
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Sufficient != b.Sufficient {
+			return a.Sufficient
+		}
+		return expiresBefore(a.Item, b.Item)
+	})
+	return candidates
+}
+
+// hasEnough reports whether the item covers the ingredient once other
+// recipes' locks are taken out.
+func hasEnough(item *model.InventoryItem, ing *model.RecipeIngredient, recipeID string) bool {
+	if item.Quantity == nil {
+		return false
+	}
+	need, err := units.Required(ing.Quantity, ing.Unit, item.Quantity.Unit, units.ProductOf(item))
+	if err != nil {
+		return false
+	}
+
+	available := item.VirtualAvailable
+	for _, lock := range item.ActiveLocks {
+		if recipeID != "" && lock.RecipeID == recipeID {
+			available += lock.Amount
+		}
+	}
+	return available >= need-0.001
+}
+
+func expiresBefore(a, b *model.InventoryItem) bool {
+	ta, errA := time.Parse(time.RFC3339, a.ExpiryDate)
+	tb, errB := time.Parse(time.RFC3339, b.ExpiryDate)
+	switch {
+	case errA != nil && errB != nil:
+		return a.AddedAt < b.AddedAt
+	case errA != nil:
+		return false
+	case errB != nil:
+		return true
+	case !ta.Equal(tb):
+		return ta.Before(tb)
+	default:
+		return a.AddedAt < b.AddedAt
+	}
+}
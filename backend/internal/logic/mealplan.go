@@ -0,0 +1,437 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mariocosenza/mocc/graph/model"
+	"github.com/mariocosenza/mocc/internal/repository"
+	"github.com/mariocosenza/mocc/internal/units"
+)
+
+// planDayLayout is how meal plan days and weeks are written.
+const planDayLayout = "2006-01-02"
+
+// mealLockKey is what the ingredient locks of a planned meal are filed under
+// in ProductLock.RecipeID. Each slot has its own, so the same recipe can be
+// planned twice in a week, and cooking it outside the plan leaves the plan's
+// reservations alone.
+func mealLockKey(slotID string) string {
+	return "meal:" + slotID
+}
+
+// parsePlanDay reads a YYYY-MM-DD day, also accepting a full timestamp.
+func parsePlanDay(day string) (time.Time, error) {
+	if t, err := time.Parse(planDayLayout, day); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, day)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", day)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+}
+
+// weekStartOf returns the Monday of the week t falls in.
+func weekStartOf(t time.Time) string {
+	offset := (int(t.Weekday()) + 6) % 7
+	return t.AddDate(0, 0, -offset).Format(planDayLayout)
+}
+
+// FetchMealPlan returns the plan of a fridge the user can see for the week
+// holding day, the current week when day is nil.
+func (l *Logic) FetchMealPlan(ctx context.Context, userID string, fridgeID *string, day *string) (*model.MealPlan, error) {
+	logger := l.GetLogger()
+
+	target, err := l.ResolveFridgeID(ctx, userID, fridgeID)
+	if err != nil {
+		return nil, err
+	}
+
+	week := weekStartOf(time.Now().UTC())
+	if day != nil && *day != "" {
+		t, err := parsePlanDay(*day)
+		if err != nil {
+			return nil, err
+		}
+		week = weekStartOf(t)
+	}
+
+	plan, _, err := l.Repos.MealPlans.Get(ctx, target, week)
+	if errors.Is(err, repository.ErrNotFound) {
+		return emptyMealPlan(target, week), nil
+	}
+	if err != nil {
+		logger.Printf("level=error op=GetMealPlan stage=read_item fridgeId=%s week=%s err=%v", target, week, err)
+		return nil, err
+	}
+	return plan, nil
+}
+
+// PlanMeal plans a recipe of the user on a day of a fridge they can
+// edit, reserving its linked ingredients in that fridge for the portions
+// asked for. The meal is not planned when the fridge cannot cover it.
+func (l *Logic) PlanMeal(ctx context.Context, userID string, fridgeID *string, input model.ScheduleMealInput) (*model.MealPlan, error) {
+	logger := l.GetLogger()
+
+	target, err := l.EditableFridgeID(ctx, userID, fridgeID)
+	if err != nil {
+		return nil, err
+	}
+	day, err := parsePlanDay(input.Date)
+	if err != nil {
+		return nil, err
+	}
+	if !input.MealType.IsValid() {
+		return nil, fmt.Errorf("invalid meal type %q", input.MealType)
+	}
+
+	recipe, err := l.FetchRecipe(ctx, input.RecipeID)
+	if err != nil {
+		return nil, err
+	}
+	if recipe.AuthorID != userID {
+		return nil, fmt.Errorf("unauthorized")
+	}
+	if recipe.Status == model.RecipeStatusCooked {
+		return nil, fmt.Errorf("cooked recipes cannot be planned")
+	}
+
+	portions := l.defaultPortions(ctx, userID)
+	if input.Portions != nil {
+		if *input.Portions <= 0 {
+			return nil, fmt.Errorf("portions must be positive")
+		}
+		portions = *input.Portions
+	}
+
+	slot := &model.MealSlot{
+		ID:          uuid.New().String(),
+		Date:        day.Format(planDayLayout),
+		MealType:    input.MealType,
+		RecipeID:    recipe.ID,
+		Portions:    portions,
+		ScheduledBy: userID,
+		Reserved:    true,
+	}
+	if err := l.LockFridgeIngredients(ctx, userID, target, l.mealRecipe(ctx, recipe, slot)); err != nil {
+		return nil, err
+	}
+
+	plan, err := l.updateMealPlan(ctx, target, weekStartOf(day), func(plan *model.MealPlan) (bool, error) {
+		plan.Slots = append(plan.Slots, slot)
+		return true, nil
+	})
+	if err != nil {
+		if rbErr := l.UnlockIngredients(ctx, userID, mealLockKey(slot.ID)); rbErr != nil {
+			logger.Printf("level=error op=ScheduleMeal stage=rollback fridgeId=%s slotId=%s err=%v", target, slot.ID, rbErr)
+		}
+		return nil, err
+	}
+	return plan, nil
+}
+
+// UnplanMeal takes a meal off the plan and gives back what it reserved.
+func (l *Logic) UnplanMeal(ctx context.Context, userID string, fridgeID *string, slotID, date string) (*model.MealPlan, error) {
+	logger := l.GetLogger()
+
+	target, err := l.EditableFridgeID(ctx, userID, fridgeID)
+	if err != nil {
+		return nil, err
+	}
+	day, err := parsePlanDay(date)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed *model.MealSlot
+	plan, err := l.updateMealPlan(ctx, target, weekStartOf(day), func(plan *model.MealPlan) (bool, error) {
+		removed = nil
+		for i, slot := range plan.Slots {
+			if slot.ID == slotID {
+				removed = slot
+				plan.Slots = append(plan.Slots[:i], plan.Slots[i+1:]...)
+				return true, nil
+			}
+		}
+		return false, fmt.Errorf("meal not found")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if removed.Reserved {
+		if err := l.UnlockIngredients(ctx, removed.ScheduledBy, mealLockKey(removed.ID)); err != nil {
+			logger.Printf("level=error op=UnscheduleMeal stage=unlock fridgeId=%s slotId=%s err=%v", target, removed.ID, err)
+		}
+	}
+	return plan, nil
+}
+
+// PlannedMeal is a meal slot together with the plan holding it.
+type PlannedMeal struct {
+	FridgeID string
+	Week     string
+	SlotID   string
+	Portions int32
+}
+
+// NextPlannedMeal finds the meal the user planned for recipeID closest to
+// today and not cooked yet, nil when there is none. Nothing is changed, so
+// the meal can be completed once cooking went through.
+func (l *Logic) NextPlannedMeal(ctx context.Context, userID, recipeID string) *PlannedMeal {
+	fridges, err := l.FetchFridges(ctx, userID)
+	if err != nil {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	today := now.Format(planDayLayout)
+	weeks := []string{weekStartOf(now), weekStartOf(now.AddDate(0, 0, -7))}
+
+	var found *PlannedMeal
+	best := -1
+	for _, fridge := range fridges {
+		if roleRank(FridgeRoleOf(fridge, userID)) < roleRank(model.FridgeRoleEditor) {
+			continue
+		}
+		for _, w := range weeks {
+			plan, _, err := l.Repos.MealPlans.Get(ctx, fridge.ID, w)
+			if err != nil {
+				continue
+			}
+			for _, slot := range plan.Slots {
+				if slot.RecipeID != recipeID || slot.ScheduledBy != userID || slot.CookedAt != nil {
+					continue
+				}
+				if d := dayDistance(slot.Date, today); best < 0 || d < best {
+					found = &PlannedMeal{FridgeID: fridge.ID, Week: w, SlotID: slot.ID, Portions: slot.Portions}
+					best = d
+				}
+			}
+		}
+	}
+	return found
+}
+
+// CompletePlannedMeal marks the meal as cooked and releases its
+// reservations, now that cooking drew on the stock they held. It is best
+// effort, as the recipe was cooked either way, and does nothing without a
+// meal.
+func (l *Logic) CompletePlannedMeal(ctx context.Context, userID string, meal *PlannedMeal) {
+	if meal == nil {
+		return
+	}
+	logger := l.GetLogger()
+
+	cookedAt := time.Now().UTC().Format(time.RFC3339)
+	var released bool
+	_, err := l.updateMealPlan(ctx, meal.FridgeID, meal.Week, func(plan *model.MealPlan) (bool, error) {
+		released = false
+		for _, slot := range plan.Slots {
+			if slot.ID == meal.SlotID {
+				if slot.CookedAt != nil {
+					return false, nil
+				}
+				released = slot.Reserved
+				slot.Reserved = false
+				slot.CookedAt = &cookedAt
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		logger.Printf("level=warn op=CompletePlannedMeal stage=update fridgeId=%s slotId=%s err=%v", meal.FridgeID, meal.SlotID, err)
+		return
+	}
+	if released {
+		if err := l.UnlockIngredients(ctx, userID, mealLockKey(meal.SlotID)); err != nil {
+			logger.Printf("level=error op=CompletePlannedMeal stage=unlock fridgeId=%s slotId=%s err=%v", meal.FridgeID, meal.SlotID, err)
+		}
+	}
+}
+
+// PlannedRecipe returns the recipe of a slot, nil once it was deleted.
+func (l *Logic) PlannedRecipe(ctx context.Context, slot *model.MealSlot) (*model.Recipe, error) {
+	recipe, err := l.FetchRecipe(ctx, slot.RecipeID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	return recipe, err
+}
+
+// MealSlotWarnings says why the fridge can no longer cover a planned meal:
+// reserved items that were used up, thrown away or expire before the day.
+func (l *Logic) MealSlotWarnings(ctx context.Context, slot *model.MealSlot) ([]string, error) {
+	warnings := []string{}
+	if slot.CookedAt != nil {
+		return warnings, nil
+	}
+
+	recipe, err := l.FetchRecipe(ctx, slot.RecipeID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return append(warnings, "La ricetta è stata eliminata"), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := l.userFridgeItems(ctx, slot.ScheduledBy)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*model.InventoryItem, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+	day, _ := parsePlanDay(slot.Date)
+
+	for _, ing := range recipe.Ingredients {
+		if units.IsNonDepleting(ing.Unit) {
+			continue
+		}
+		if ing.InventoryItemID == nil || *ing.InventoryItemID == "" {
+			warnings = append(warnings, fmt.Sprintf("%s non è collegato a nessun prodotto del frigo", ing.Name))
+			continue
+		}
+		item := byID[*ing.InventoryItemID]
+		switch {
+		case item == nil || item.Status != model.ItemStatusAvailable:
+			warnings = append(warnings, fmt.Sprintf("%s non è più nel frigo", ing.Name))
+		case expiresBeforeDay(item, day):
+			warnings = append(warnings, fmt.Sprintf("%s scade prima del %s", item.Name, slot.Date))
+		case slot.Reserved && (!hasLockFor(item, mealLockKey(slot.ID)) || item.VirtualAvailable < -0.001):
+			warnings = append(warnings, fmt.Sprintf("Non è rimasto abbastanza %s", item.Name))
+		}
+	}
+	return warnings, nil
+}
+
+// releaseLapsedMeals gives back what meals planned before today reserved,
+// since they were not cooked on their day.
+func (l *Logic) releaseLapsedMeals(ctx context.Context, now time.Time) int {
+	logger := l.GetLogger()
+	today := now.UTC().Format(planDayLayout)
+
+	plans, err := l.Repos.MealPlans.ListLapsed(ctx, today)
+	if err != nil {
+		logger.Printf("level=error op=ReapLocks stage=query_meal_plans err=%v", err)
+		return 0
+	}
+
+	released := 0
+	for _, stale := range plans {
+		var lapsed []*model.MealSlot
+		_, err := l.updateMealPlan(ctx, stale.FridgeID, stale.WeekStart, func(plan *model.MealPlan) (bool, error) {
+			lapsed = nil
+			for _, slot := range plan.Slots {
+				if slot.Reserved && slot.Date < today {
+					slot.Reserved = false
+					lapsed = append(lapsed, slot)
+				}
+			}
+			return len(lapsed) > 0, nil
+		})
+		if err != nil {
+			logger.Printf("level=error op=ReapLocks stage=update_meal_plan fridgeId=%s week=%s err=%v", stale.FridgeID, stale.WeekStart, err)
+			continue
+		}
+		for _, slot := range lapsed {
+			if err := l.UnlockIngredients(ctx, slot.ScheduledBy, mealLockKey(slot.ID)); err != nil {
+				logger.Printf("level=error op=ReapLocks stage=unlock_meal fridgeId=%s slotId=%s err=%v", stale.FridgeID, slot.ID, err)
+				continue
+			}
+			released++
+		}
+	}
+	return released
+}
+
+// updateMealPlan runs mutate against the plan of a fridge for a week,
+// creating the plan on its first write, with the same retry rules as
+// UpdateFridge.
+func (l *Logic) updateMealPlan(ctx context.Context, fridgeID, week string, mutate func(plan *model.MealPlan) (bool, error)) (*model.MealPlan, error) {
+	logger := l.GetLogger()
+
+	var plan *model.MealPlan
+	err := l.withWriteRetry(ctx, "SaveMealPlan", repository.MealPlanID(fridgeID, week), "meal plan", func() error {
+		current, etag, err := l.Repos.MealPlans.Get(ctx, fridgeID, week)
+		if errors.Is(err, repository.ErrNotFound) {
+			current, etag = emptyMealPlan(fridgeID, week), ""
+		} else if err != nil {
+			logger.Printf("level=error op=GetMealPlan stage=read_item fridgeId=%s week=%s err=%v", fridgeID, week, err)
+			return err
+		}
+
+		changed, err := mutate(current)
+		if err != nil {
+			return err
+		}
+		plan = current
+		if !changed {
+			return nil
+		}
+
+		current.UpdatedAt = toPtr(time.Now().Format(time.RFC3339))
+		if etag == "" {
+			err = l.Repos.MealPlans.Create(ctx, current)
+		} else {
+			err = l.Repos.MealPlans.Replace(ctx, current, etag)
+		}
+		if err != nil && !errors.Is(err, repository.ErrConflict) {
+			logger.Printf("level=error op=SaveMealPlan stage=write fridgeId=%s week=%s err=%v", fridgeID, week, err)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// mealRecipe is the recipe as the slot cooks it: filed under the slot's lock
+// key, with quantities scaled to its portions.
+func (l *Logic) mealRecipe(ctx context.Context, recipe *model.Recipe, slot *model.MealSlot) *model.Recipe {
+	factor := float64(slot.Portions) / float64(l.recipeServings(ctx, slot.ScheduledBy, recipe))
+
+	meal := *recipe
+	meal.ID = mealLockKey(slot.ID)
+	meal.Ingredients = scaleIngredients(recipe.Ingredients, factor)
+	return &meal
+}
+
+func emptyMealPlan(fridgeID, week string) *model.MealPlan {
+	return &model.MealPlan{
+		ID:        repository.MealPlanID(fridgeID, week),
+		FridgeID:  fridgeID,
+		WeekStart: week,
+		Slots:     []*model.MealSlot{},
+	}
+}
+
+// expiresBeforeDay reports whether the item expires before the given day.
+func expiresBeforeDay(item *model.InventoryItem, day time.Time) bool {
+	exp, err := time.Parse(time.RFC3339, item.ExpiryDate)
+	if err != nil {
+		return false
+	}
+	return exp.UTC().Format(planDayLayout) < day.Format(planDayLayout)
+}
+
+// dayDistance is how many days apart two YYYY-MM-DD days are.
+func dayDistance(a, b string) int {
+	ta, errA := time.Parse(planDayLayout, a)
+	tb, errB := time.Parse(planDayLayout, b)
+	if errA != nil || errB != nil {
+		return 1 << 30
+	}
+	d := int(ta.Sub(tb).Hours() / 24)
+	if d < 0 {
+		return -d
+	}
+	return d
+}
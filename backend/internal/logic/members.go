@@ -0,0 +1,370 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mariocosenza/mocc/graph/model"
+	"github.com/redis/go-redis/v9"
+)
+
+var errInviteNotFound = errors.New("invite not found or expired")
+
+// fridgeInvite is what an invite code stands for. It lives in Redis until it
+// is used, revoked or expires.
+type fridgeInvite struct {
+	FridgeID  string           `json:"fridgeId"`
+	AuthorID  string           `json:"authorId"`
+	Role      model.FridgeRole `json:"role"`
+	ExpiresAt string           `json:"expiresAt"`
+}
+
+func roleRank(role model.FridgeRole) int {
+	switch role {
+	case model.FridgeRoleOwner:
+		return 3
+	case model.FridgeRoleEditor:
+		return 2
+	case model.FridgeRoleViewer:
+		return 1
+	}
+	return 0
+}
+
+// FridgeRoleOf tells the role of the user in the fridge, or "" when they are
+// not a member. Whoever the fridge is named after always owns it. Fridges
+// shared before roles existed only list members in OwnerID: there the first
+// one owns the fridge and everybody else edits it.
+func FridgeRoleOf(fridge *model.Fridge, userID string) model.FridgeRole {
+	if fridge.ID == userID {
+		return model.FridgeRoleOwner
+	}
+	if !canAccessFridge(fridge, userID) {
+		return ""
+	}
+	for _, member := range fridge.Members {
+		if member.UserID == userID {
+			return member.Role
+		}
+	}
+	if fridge.OwnerID[0] == userID {
+		return model.FridgeRoleOwner
+	}
+	return model.FridgeRoleEditor
+}
+
+func ownerMember(userID string) *model.FridgeMember {
+	return &model.FridgeMember{
+		UserID:   userID,
+		Role:     model.FridgeRoleOwner,
+		JoinedAt: toPtr(time.Now().UTC().Format(time.RFC3339)),
+	}
+}
+
+// members lists everybody in the fridge with their role, in the order they
+// joined. Nicknames are left out.
+func members(fridge *model.Fridge) []*model.FridgeMember {
+	out := make([]*model.FridgeMember, 0, len(fridge.OwnerID))
+	for _, id := range fridge.OwnerID {
+		member := &model.FridgeMember{UserID: id, Role: FridgeRoleOf(fridge, id)}
+		for _, stored := range fridge.Members {
+			if stored.UserID == id {
+				member.JoinedAt = stored.JoinedAt
+			}
+		}
+		out = append(out, member)
+	}
+	return out
+}
+
+// requireRole reads a fridge the user has at least the given role in.
+func (l *Logic) requireRole(ctx context.Context, userID, fridgeID string, min model.FridgeRole) (*model.Fridge, error) {
+	fridge, _, err := l.accessibleFridge(ctx, userID, fridgeID)
+	if err != nil {
+		return nil, err
+	}
+	if roleRank(FridgeRoleOf(fridge, userID)) < roleRank(min) {
+		return nil, codedError(ErrCodeForbidden, "you need the %s role in %s", min, fridge.Name)
+	}
+	return fridge, nil
+}
+
+// FridgeMembersOf lists the members of the fridge with their nicknames.
+func (l *Logic) FridgeMembersOf(ctx context.Context, fridge *model.Fridge) []*model.FridgeMember {
+	out := members(fridge)
+	for _, member := range out {
+		if user, err := l.FetchUser(ctx, member.UserID); err == nil {
+			member.Nickname = &user.Nickname
+		}
+	}
+	return out
+}
+
+// ListFridgeMembers lists the members of a fridge the user belongs to.
+func (l *Logic) ListFridgeMembers(ctx context.Context, userID, fridgeID string) ([]*model.FridgeMember, error) {
+	fridge, _, err := l.accessibleFridge(ctx, userID, fridgeID)
+	if err != nil {
+		return nil, err
+	}
+	return l.FridgeMembersOf(ctx, fridge), nil
+}
+
+func (l *Logic) inviteTTL() time.Duration {
+	if l.InviteTTL > 0 {
+		return l.InviteTTL
+	}
+	return DefaultInviteTTL
+}
+
+// CreateInvite issues a single-use code letting whoever redeems it join the
+// fridge with the given role. Only owners can invite.
+func (l *Logic) CreateInvite(ctx context.Context, userID string, fridgeID *string, role model.FridgeRole, expiresInMinutes *int32) (*model.SharedFridgeLink, error) {
+	logger := l.GetLogger()
+
+	target, err := l.ResolveFridgeID(ctx, userID, fridgeID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := l.requireRole(ctx, userID, target, model.FridgeRoleOwner); err != nil {
+		return nil, err
+	}
+	if !role.IsValid() {
+		return nil, fmt.Errorf("invalid role")
+	}
+
+	ttl := l.inviteTTL()
+	if expiresInMinutes != nil {
+		ttl = time.Duration(*expiresInMinutes) * time.Minute
+		if ttl <= 0 || ttl > MaxInviteTTL {
+			return nil, fmt.Errorf("expiresInMinutes must be between 1 and %d", int(MaxInviteTTL/time.Minute))
+		}
+	}
+
+	invite := fridgeInvite{
+		FridgeID:  target,
+		AuthorID:  userID,
+		Role:      role,
+		ExpiresAt: time.Now().UTC().Add(ttl).Format(time.RFC3339),
+	}
+	data, err := json.Marshal(invite)
+	if err != nil {
+		return nil, err
+	}
+	code := uuid.New().String()
+	if err := l.Redis.Set(ctx, FridgeInvitePrefix+code, data, ttl).Err(); err != nil {
+		logger.Printf("level=error op=GenerateSharedFridgeLink stage=redis_set userId=%s fridgeId=%s err=%v", userID, target, err)
+		return nil, err
+	}
+
+	return &model.SharedFridgeLink{
+		AuthorID:   userID,
+		InviteCode: code,
+		FridgeID:   target,
+		Role:       role,
+		ExpiresAt:  invite.ExpiresAt,
+	}, nil
+}
+
+// readInvite looks an invite code up. Codes issued before invites carried a
+// fridge and a role hold the author's id only: they open the author's own
+// fridge for editing.
+func (l *Logic) readInvite(ctx context.Context, code string) (*fridgeInvite, error) {
+	raw, err := l.Redis.Get(ctx, FridgeInvitePrefix+code).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, errInviteNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var invite fridgeInvite
+	if json.Unmarshal([]byte(raw), &invite) != nil || invite.FridgeID == "" {
+		fridgeID, err := l.FridgeIDForUser(ctx, raw)
+		if err != nil {
+			return nil, err
+		}
+		invite = fridgeInvite{FridgeID: fridgeID, AuthorID: raw, Role: model.FridgeRoleEditor}
+	}
+	return &invite, nil
+}
+
+// RevokeInvite cancels an invite code before anybody uses it. Its author and
+// the owners of the fridge can revoke it. It reports whether there was a code
+// to revoke.
+func (l *Logic) RevokeInvite(ctx context.Context, userID, code string) (bool, error) {
+	invite, err := l.readInvite(ctx, code)
+	if errors.Is(err, errInviteNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if invite.AuthorID != userID {
+		if _, err := l.requireRole(ctx, userID, invite.FridgeID, model.FridgeRoleOwner); err != nil {
+			return false, err
+		}
+	}
+
+	n, err := l.Redis.Del(ctx, FridgeInvitePrefix+code).Result()
+	if err != nil {
+		l.GetLogger().Printf("level=error op=RevokeSharedFridgeLink stage=redis_del userId=%s err=%v", userID, err)
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// JoinFridge redeems an invite code, adding the user to the fridge with the
+// role the invite grants. The code cannot be used again.
+func (l *Logic) JoinFridge(ctx context.Context, userID, code string) (string, error) {
+	invite, err := l.readInvite(ctx, code)
+	if err != nil {
+		return "", err
+	}
+	fridge, _, err := l.Repos.Fridges.Get(ctx, invite.FridgeID)
+	if err != nil {
+		return "", fmt.Errorf("fridge not found")
+	}
+	if FridgeRoleOf(fridge, userID) != "" {
+		return "", fmt.Errorf("you are already a member of this fridge")
+	}
+
+	// Claiming the code before joining keeps two users from redeeming it.
+	if err := l.Redis.GetDel(ctx, FridgeInvitePrefix+code).Err(); err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", errInviteNotFound
+		}
+		return "", err
+	}
+
+	joined := false
+	_, err = l.UpdateFridge(ctx, invite.FridgeID, func(fridge *model.Fridge) (bool, error) {
+		if FridgeRoleOf(fridge, userID) != "" {
+			joined = false
+			return false, nil
+		}
+		fridge.Members = members(fridge)
+		fridge.OwnerID = append(fridge.OwnerID, userID)
+		fridge.Members = append(fridge.Members, &model.FridgeMember{
+			UserID:   userID,
+			Role:     invite.Role,
+			JoinedAt: toPtr(time.Now().UTC().Format(time.RFC3339)),
+		})
+		joined = true
+		return true, nil
+	})
+	if err != nil {
+		l.GetLogger().Printf("level=error op=AddFridgeShared stage=join userId=%s fridgeId=%s err=%v", userID, invite.FridgeID, err)
+		return "", err
+	}
+
+	if joined {
+		if member, err := l.FetchUser(ctx, userID); err == nil {
+			l.Notify(ctx, invite.AuthorID, model.NotificationTypeFridgeShared, invite.FridgeID, "%s si è unito al tuo frigo", member.Nickname)
+		}
+		l.EvaluateBadges(ctx, userID, model.BadgeCriterionFridgesShared)
+		l.EvaluateBadges(ctx, invite.AuthorID, model.BadgeCriterionFridgesShared)
+	}
+	return invite.FridgeID, nil
+}
+
+// QuitFridge takes the user out of a fridge shared with them. The fridge
+// named after the user cannot be left, nor can a fridge lose its last owner.
+func (l *Logic) QuitFridge(ctx context.Context, userID, fridgeID string) error {
+	if _, _, err := l.accessibleFridge(ctx, userID, fridgeID); err != nil {
+		return err
+	}
+	return l.dropMember(ctx, fridgeID, userID)
+}
+
+// ExpelMember takes another member out of a fridge the user owns.
+func (l *Logic) ExpelMember(ctx context.Context, userID, fridgeID, memberID string) error {
+	if _, err := l.requireRole(ctx, userID, fridgeID, model.FridgeRoleOwner); err != nil {
+		return err
+	}
+	if memberID == userID {
+		return fmt.Errorf("use leaveFridge to leave a fridge")
+	}
+	return l.dropMember(ctx, fridgeID, memberID)
+}
+
+func (l *Logic) dropMember(ctx context.Context, fridgeID, memberID string) error {
+	_, err := l.UpdateFridge(ctx, fridgeID, func(fridge *model.Fridge) (bool, error) {
+		role := FridgeRoleOf(fridge, memberID)
+		if role == "" {
+			return false, fmt.Errorf("not a member of this fridge")
+		}
+		if fridge.ID == memberID {
+			return false, fmt.Errorf("nobody can leave the fridge named after them")
+		}
+		if role == model.FridgeRoleOwner && countOwners(fridge) == 1 {
+			return false, codedError(ErrCodeConflict, "a fridge cannot lose its last owner")
+		}
+
+		fridge.Members = slices.DeleteFunc(members(fridge), func(m *model.FridgeMember) bool { return m.UserID == memberID })
+		fridge.OwnerID = slices.DeleteFunc(fridge.OwnerID, func(id string) bool { return id == memberID })
+		return true, nil
+	})
+	if err != nil {
+		l.GetLogger().Printf("level=warn op=RemoveFridgeMember stage=update fridgeId=%s memberId=%s err=%v", fridgeID, memberID, err)
+	}
+	return err
+}
+
+// SetMemberRole changes the role of a member of a fridge the user owns.
+func (l *Logic) SetMemberRole(ctx context.Context, userID, fridgeID, memberID string, role model.FridgeRole) (*model.FridgeMember, error) {
+	if !role.IsValid() {
+		return nil, fmt.Errorf("invalid role")
+	}
+	if _, err := l.requireRole(ctx, userID, fridgeID, model.FridgeRoleOwner); err != nil {
+		return nil, err
+	}
+
+	fridge, err := l.UpdateFridge(ctx, fridgeID, func(fridge *model.Fridge) (bool, error) {
+		current := FridgeRoleOf(fridge, memberID)
+		if current == "" {
+			return false, fmt.Errorf("not a member of this fridge")
+		}
+		if current == role {
+			return false, nil
+		}
+		if fridge.ID == memberID {
+			return false, fmt.Errorf("the fridge named after a user always belongs to them")
+		}
+		if current == model.FridgeRoleOwner && countOwners(fridge) == 1 {
+			return false, codedError(ErrCodeConflict, "a fridge cannot lose its last owner")
+		}
+
+		fridge.Members = members(fridge)
+		for _, member := range fridge.Members {
+			if member.UserID == memberID {
+				member.Role = role
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, member := range l.FridgeMembersOf(ctx, fridge) {
+		if member.UserID == memberID {
+			return member, nil
+		}
+	}
+	return nil, fmt.Errorf("not a member of this fridge")
+}
+
+func countOwners(fridge *model.Fridge) int {
+	n := 0
+	for _, member := range members(fridge) {
+		if member.Role == model.FridgeRoleOwner {
+			n++
+		}
+	}
+	return n
+}
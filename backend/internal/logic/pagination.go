@@ -0,0 +1,54 @@
+package logic
+
+import (
+	"errors"
+
+	"github.com/mariocosenza/mocc/graph/model"
+	"github.com/mariocosenza/mocc/internal/repository"
+)
+
+// pageSize turns the first argument of a connection field into a page size
+// between 1 and MaxPageSize.
+func pageSize(first *int32, def int) int {
+	n := def
+	if first != nil {
+		n = int(*first)
+	}
+	if n < 1 {
+		n = 1
+	}
+	if n > MaxPageSize {
+		n = MaxPageSize
+	}
+	return n
+}
+
+func cursorValue(after *string) string {
+	if after == nil {
+		return ""
+	}
+	return *after
+}
+
+// pageError hides repository errors about cursors behind a client error.
+func pageError(err error) error {
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		return codedError(ErrCodeBadCursor, "invalid after cursor")
+	}
+	return err
+}
+
+func pageInfo[T any](page *repository.Page[T]) *model.PageInfo {
+	return &model.PageInfo{
+		HasNextPage: page.HasNextPage,
+		EndCursor:   page.EndCursor(),
+	}
+}
+
+func edges[T, E any](page *repository.Page[T], edge func(cursor string, node *T) E) []E {
+	out := make([]E, 0, len(page.Edges))
+	for _, e := range page.Edges {
+		out = append(out, edge(e.Cursor, e.Node))
+	}
+	return out
+}
@@ -0,0 +1,226 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/mariocosenza/mocc/graph/model"
+	"github.com/mariocosenza/mocc/internal/repository"
+)
+
+// openingBalanceKey is the ledger entry carrying the points a user earned
+// before the ledger was kept.
+const openingBalanceKey = "opening_balance"
+
+// pointsKey is the idempotency key of the points given for reason on a
+// source, so that a retried award or deduction is recorded once.
+func pointsKey(reason model.PointsReason, sourceID string) string {
+	return strings.ToLower(reason.String()) + ":" + sourceID
+}
+
+// AwardPoints gives the user points for reason on the recipe or post
+// sourceID, at most once however often it is called.
+func (l *Logic) AwardPoints(ctx context.Context, userID string, reason model.PointsReason, sourceID string, points int32) error {
+	return l.recordPoints(ctx, userID, &model.PointsEntry{
+		ID:       pointsKey(reason, sourceID),
+		Points:   points,
+		Reason:   reason,
+		SourceID: &sourceID,
+	})
+}
+
+// RevokePoints takes back, as a revoked entry, the points awarded for
+// awarded on sourceID. Awards made before the ledger was kept are not in it,
+// so fallback is taken back for those.
+func (l *Logic) RevokePoints(ctx context.Context, userID string, awarded, revoked model.PointsReason, sourceID string, fallback int32) error {
+	entries, err := l.Repos.Points.ListByUser(ctx, userID)
+	if err != nil {
+		l.GetLogger().Printf("level=error op=RevokePoints stage=list userId=%s err=%v", userID, err)
+		return err
+	}
+	points := fallback
+	for _, entry := range entries {
+		if entry.ID == pointsKey(awarded, sourceID) {
+			points = entry.Points
+		}
+	}
+	if points == 0 {
+		return nil
+	}
+
+	return l.recordPoints(ctx, userID, &model.PointsEntry{
+		ID:       pointsKey(revoked, sourceID),
+		Points:   -points,
+		Reason:   revoked,
+		SourceID: &sourceID,
+	})
+}
+
+// recordPoints appends entry to the user's ledger and brings their total,
+// level and leaderboard scores in line with it. An entry recorded already is
+// not added twice, but the totals are still checked, in case the attempt
+// that recorded it failed before updating them.
+func (l *Logic) recordPoints(ctx context.Context, userID string, entry *model.PointsEntry) error {
+	logger := l.GetLogger()
+
+	user, err := l.FetchUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	normalizeGamification(user)
+	if err := l.openLedger(ctx, user); err != nil {
+		return err
+	}
+
+	entry.CreatedAt = time.Now().UTC().Format(eventTimeLayout)
+	if err := l.Repos.Points.Append(ctx, userID, entry); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			logger.Printf("level=info op=RecordPoints stage=already_recorded userId=%s key=%s", userID, entry.ID)
+			// Totals only change here if that attempt never got to them, in
+			// which case the period scores missed the entry too. The stored
+			// user is what that attempt would have written.
+			stored, err := l.GetUserFromStore(ctx, userID)
+			if err != nil {
+				return err
+			}
+			_, err = l.applyLedger(ctx, stored, int(entry.Points))
+			return err
+		}
+		logger.Printf("level=error op=RecordPoints stage=append userId=%s key=%s err=%v", userID, entry.ID, err)
+		return err
+	}
+	logger.Printf("level=info op=RecordPoints stage=recorded userId=%s key=%s points=%d", userID, entry.ID, entry.Points)
+
+	_, err = l.applyLedger(ctx, user, int(entry.Points))
+	return err
+}
+
+// openLedger records the points the user earned before the ledger was kept
+// as its first entry, so totals derived from it lose nothing.
+func (l *Logic) openLedger(ctx context.Context, user *model.User) error {
+	entries, err := l.Repos.Points.ListByUser(ctx, user.ID)
+	if err != nil {
+		l.GetLogger().Printf("level=error op=RecordPoints stage=list userId=%s err=%v", user.ID, err)
+		return err
+	}
+	if len(entries) > 0 || user.Gamification.TotalEcoPoints <= 0 {
+		return nil
+	}
+
+	err = l.Repos.Points.Append(ctx, user.ID, &model.PointsEntry{
+		ID:        openingBalanceKey,
+		Points:    user.Gamification.TotalEcoPoints,
+		Reason:    model.PointsReasonOpeningBalance,
+		CreatedAt: time.Now().UTC().Format(eventTimeLayout),
+	})
+	if err != nil && !errors.Is(err, repository.ErrConflict) {
+		l.GetLogger().Printf("level=error op=RecordPoints stage=opening_balance userId=%s err=%v", user.ID, err)
+		return err
+	}
+	return nil
+}
+
+// applyLedger sets the user's total to the sum of their ledger, never below
+// zero, and their level to the one that total reaches. earned is what the
+// newest entry adds to the current week and month, zero for corrections. It
+// reports whether anything changed.
+func (l *Logic) applyLedger(ctx context.Context, user *model.User, earned int) (bool, error) {
+	logger := l.GetLogger()
+
+	entries, err := l.Repos.Points.ListByUser(ctx, user.ID)
+	if err != nil {
+		logger.Printf("level=error op=RecordPoints stage=list userId=%s err=%v", user.ID, err)
+		return false, err
+	}
+	total := int32(0)
+	for _, entry := range entries {
+		total += entry.Points
+	}
+	total = max(total, 0)
+
+	normalizeGamification(user)
+	before := *user.Gamification
+	user.Gamification.TotalEcoPoints = total
+	user.Gamification.CurrentLevel = 1
+	user.Gamification.NextLevelThreshold = 100
+	l.EvaluateLevelUp(user)
+
+	if user.Gamification.TotalEcoPoints == before.TotalEcoPoints &&
+		user.Gamification.CurrentLevel == before.CurrentLevel &&
+		user.Gamification.NextLevelThreshold == before.NextLevelThreshold {
+		return false, nil
+	}
+
+	if err := l.UpsertUser(ctx, user); err != nil {
+		return false, err
+	}
+	l.SetUserCache(ctx, user)
+	l.UpsertLeaderboardEntry(ctx, user, earned)
+	return true, nil
+}
+
+// FetchPointsHistory returns a page of the user's ledger, newest first.
+func (l *Logic) FetchPointsHistory(ctx context.Context, userID string, first *int32, after *string) (*model.PointsEntryConnection, error) {
+	page, err := l.Repos.Points.ListByUserPage(ctx, userID, pageSize(first, 20), cursorValue(after))
+	if err != nil {
+		l.GetLogger().Printf("level=error op=GetPointsHistory stage=query userId=%s err=%v", userID, err)
+		return nil, pageError(err)
+	}
+	return &model.PointsEntryConnection{
+		Edges: edges(page, func(cursor string, node *model.PointsEntry) *model.PointsEntryEdge {
+			return &model.PointsEntryEdge{Cursor: cursor, Node: node}
+		}),
+		PageInfo: pageInfo(page),
+	}, nil
+}
+
+// RebuildEcoPoints recomputes the total and level of every user from their
+// ledger, opening it first for users who only have points from before it was
+// kept. It returns how many users were corrected.
+func (l *Logic) RebuildEcoPoints(ctx context.Context) (int, error) {
+	logger := l.GetLogger()
+
+	ids, err := l.Repos.Points.ListUserIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	users, err := l.Repos.Users.ListWithEcoPoints(ctx)
+	if err != nil {
+		return 0, err
+	}
+	seen := map[string]bool{}
+	for _, id := range ids {
+		seen[id] = true
+	}
+	for _, user := range users {
+		if !seen[user.ID] {
+			seen[user.ID] = true
+			ids = append(ids, user.ID)
+		}
+	}
+
+	corrected := 0
+	for _, id := range ids {
+		user, err := l.GetUserFromStore(ctx, id)
+		if err != nil {
+			continue
+		}
+		normalizeGamification(user)
+		if err := l.openLedger(ctx, user); err != nil {
+			return corrected, err
+		}
+		before := user.Gamification.TotalEcoPoints
+		changed, err := l.applyLedger(ctx, user, 0)
+		if err != nil {
+			return corrected, err
+		}
+		if changed {
+			corrected++
+			logger.Printf("level=info op=RebuildEcoPoints stage=corrected userId=%s before=%d after=%d", id, before, user.Gamification.TotalEcoPoints)
+		}
+	}
+	logger.Printf("level=info op=RebuildEcoPoints stage=done users=%d corrected=%d", len(ids), corrected)
+	return corrected, nil
+}
@@ -0,0 +1,78 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/mariocosenza/mocc/graph/model"
+)
+
+// recipeServings is how many portions the recipe's quantities are for: the
+// servings it states, else the user's default portions.
+func (l *Logic) recipeServings(ctx context.Context, userID string, recipe *model.Recipe) int32 {
+	if recipe.Servings != nil && *recipe.Servings > 0 {
+		return *recipe.Servings
+	}
+	return l.defaultPortions(ctx, userID)
+}
+
+// defaultPortions is how many people the user usually cooks for, 1 when
+// they did not say.
+func (l *Logic) defaultPortions(ctx context.Context, userID string) int32 {
+	user, err := l.FetchUser(ctx, userID)
+	if err != nil || user.Preferences == nil || user.Preferences.DefaultPortions == nil || *user.Preferences.DefaultPortions <= 0 {
+		return 1
+	}
+	return *user.Preferences.DefaultPortions
+}
+
+// ScaleRecipe returns a copy of the recipe with its quantities for portions.
+// The copy keeps the recipe's id, so it locks and cooks as the recipe.
+func (l *Logic) ScaleRecipe(ctx context.Context, userID string, recipe *model.Recipe, portions int32) (*model.Recipe, error) {
+	if portions <= 0 {
+		return nil, fmt.Errorf("portions must be positive")
+	}
+
+	scaled := *recipe
+	scaled.Ingredients = scaleIngredients(recipe.Ingredients, float64(portions)/float64(l.recipeServings(ctx, userID, recipe)))
+	scaled.Servings = &portions
+	return &scaled, nil
+}
+
+// PreparedRecipe is the recipe as it is prepared or cooked: scaled to
+// portions, else to the portions it was put in preparation for, else as
+// written.
+func (l *Logic) PreparedRecipe(ctx context.Context, userID string, recipe *model.Recipe, portions *int32) (*model.Recipe, error) {
+	if portions == nil {
+		portions = recipe.PreparationPortions
+	}
+	if portions == nil {
+		return recipe, nil
+	}
+	return l.ScaleRecipe(ctx, userID, recipe, *portions)
+}
+
+func scaleIngredients(ingredients []*model.RecipeIngredient, factor float64) []*model.RecipeIngredient {
+	scaled := make([]*model.RecipeIngredient, 0, len(ingredients))
+	for _, ing := range ingredients {
+		out := *ing
+		out.Quantity = scaleQuantity(ing.Quantity, ing.Unit, factor)
+		scaled = append(scaled, &out)
+	}
+	return scaled
+}
+
+// scaleQuantity multiplies an amount by factor. Pieces go to the nearest
+// whole one, never below one, since nobody cooks with 1.4 eggs; the other
+// units keep two decimals.
+func scaleQuantity(value float64, unit model.Unit, factor float64) float64 {
+	if factor == 1 || value <= 0 {
+		return value
+	}
+	v := value * factor
+	if unit == model.UnitPz {
+		return math.Max(1, math.Round(v))
+	}
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,149 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mariocosenza/mocc/graph/model"
+)
+
+// publish broadcasts payload on a Redis channel so that subscribers connected
+// to any replica receive it. Subscriptions are a convenience on top of the
+// mutation result, so failures are logged and never surface to the caller.
+func (l *Logic) publish(ctx context.Context, channel string, payload any) {
+	logger := l.GetLogger()
+
+	if l.Redis == nil {
+		return
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		logger.Printf("level=error op=Publish stage=marshal channel=%s err=%v", channel, err)
+		return
+	}
+	if err := l.Redis.Publish(ctx, channel, data).Err(); err != nil {
+		logger.Printf("level=warn op=Publish stage=redis_publish channel=%s err=%v", channel, err)
+	}
+}
+
+// PublishFridgeChanged tells subscribers the fridge or one of its items
+// changed. Only the id travels: subscribers re-read the fridge themselves.
+func (l *Logic) PublishFridgeChanged(ctx context.Context, fridgeID string) {
+	l.publish(ctx, EventsFridgePrefix+fridgeID, fridgeID)
+}
+
+func (l *Logic) PublishPostUpdated(ctx context.Context, post *model.Post) {
+	l.publish(ctx, EventsPostPrefix+post.ID, post)
+}
+
+// Notify pushes a notification to the user's open subscriptions. Nothing is
+// stored: users who are not connected simply miss it.
+func (l *Logic) Notify(ctx context.Context, userID string, kind model.NotificationType, refID string, format string, args ...any) {
+	notification := &model.Notification{
+		ID:        uuid.New().String(),
+		Type:      kind,
+		Message:   fmt.Sprintf(format, args...),
+		RefID:     &refID,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	l.publish(ctx, EventsNotificationPrefix+userID, notification)
+}
+
+// SubscribeFridge streams the fridge every time it changes, for as long as
+// ctx lives and the user still has access to it.
+func (l *Logic) SubscribeFridge(ctx context.Context, userID, fridgeID string) (<-chan *model.Fridge, error) {
+	logger := l.GetLogger()
+
+	fridge, _, err := l.Repos.Fridges.Get(ctx, fridgeID)
+	if err != nil {
+		logger.Printf("level=warn op=SubscribeFridge stage=read_item userId=%s fridgeId=%s err=%v", userID, fridgeID, err)
+		return nil, fmt.Errorf("not found")
+	}
+	if !canAccessFridge(fridge, userID) {
+		return nil, fmt.Errorf("unauthorized")
+	}
+
+	changes, err := subscribe[string](ctx, l, EventsFridgePrefix+fridgeID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan *model.Fridge, 1)
+	go func() {
+		defer close(out)
+		for range changes {
+			fridge, _, err := l.Repos.Fridges.Get(ctx, fridgeID)
+			if err != nil {
+				logger.Printf("level=warn op=SubscribeFridge stage=refresh fridgeId=%s err=%v", fridgeID, err)
+				continue
+			}
+			if !canAccessFridge(fridge, userID) {
+				return
+			}
+			select {
+			case out <- fridge:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (l *Logic) SubscribePost(ctx context.Context, postID string) (<-chan *model.Post, error) {
+	if _, err := l.FetchPost(ctx, postID); err != nil {
+		return nil, err
+	}
+	return subscribe[model.Post](ctx, l, EventsPostPrefix+postID)
+}
+
+func (l *Logic) SubscribeNotifications(ctx context.Context, userID string) (<-chan *model.Notification, error) {
+	return subscribe[model.Notification](ctx, l, EventsNotificationPrefix+userID)
+}
+
+// subscribe relays the decoded messages of a Redis channel until ctx is done.
+func subscribe[T any](ctx context.Context, l *Logic, channel string) (<-chan *T, error) {
+	logger := l.GetLogger()
+
+	if l.Redis == nil {
+		return nil, fmt.Errorf("subscriptions are not available")
+	}
+	pubsub := l.Redis.Subscribe(ctx, channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		logger.Printf("level=error op=Subscribe stage=redis_subscribe channel=%s err=%v", channel, err)
+		return nil, err
+	}
+
+	out := make(chan *T, 1)
+	go func() {
+		defer close(out)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				var v T
+				if err := json.Unmarshal([]byte(msg.Payload), &v); err != nil {
+					logger.Printf("level=warn op=Subscribe stage=unmarshal channel=%s err=%v", channel, err)
+					continue
+				}
+				select {
+				case out <- &v:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
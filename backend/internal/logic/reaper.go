@@ -0,0 +1,148 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mariocosenza/mocc/graph/model"
+	"github.com/mariocosenza/mocc/internal/repository"
+)
+
+func (l *Logic) recipeLockTTL() time.Duration {
+	if l.LockTTL > 0 {
+		return l.LockTTL
+	}
+	return DefaultRecipeLockTTL
+}
+
+// RecipeTTLRemaining returns how many seconds the recipe may still hold its
+// ingredients, or nil when it is not in preparation.
+func (l *Logic) RecipeTTLRemaining(recipe *model.Recipe, now time.Time) *int32 {
+	if recipe.Status != model.RecipeStatusInPreparation || recipe.PreparationStartedAt == nil {
+		return nil
+	}
+	started, err := time.Parse(time.RFC3339, *recipe.PreparationStartedAt)
+	if err != nil {
+		return nil
+	}
+
+	left := started.Add(l.recipeLockTTL()).Sub(now)
+	if left < 0 {
+		left = 0
+	}
+	return toPtr(int32(left / time.Second))
+}
+
+// RunLockReaper releases the ingredients of recipes left in preparation past
+// the lock TTL, every interval until ctx is done. With several replicas only
+// the one holding the Redis lease does the work on a given tick.
+func (l *Logic) RunLockReaper(ctx context.Context, interval time.Duration) {
+	logger := l.GetLogger()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if l.Redis != nil {
+			leased, err := l.Redis.SetNX(ctx, LockReaperLeaseKey, "1", interval/2).Result()
+			if err != nil {
+				logger.Printf("level=warn op=ReapLocks stage=lease err=%v", err)
+				continue
+			}
+			if !leased {
+				continue
+			}
+		}
+
+		if _, err := l.ReapExpiredLocks(ctx); err != nil {
+			logger.Printf("level=error op=ReapLocks stage=sweep err=%v", err)
+		}
+	}
+}
+
+// ReapExpiredLocks moves every recipe in preparation for longer than the lock
+// TTL back to SAVED, unlocking its ingredients. It returns how many recipes
+// were released.
+func (l *Logic) ReapExpiredLocks(ctx context.Context) (int, error) {
+	logger := l.GetLogger()
+	now := time.Now()
+
+	recipes, err := l.Repos.Recipes.ListByStatus(ctx, model.RecipeStatusInPreparation)
+	if err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for _, listed := range recipes {
+		// Cooking or editing the recipe since it was listed must win over
+		// the sweep, so every write below is conditional on this read.
+		recipe, etag, err := l.Repos.Recipes.GetWithETag(ctx, listed.AuthorID, listed.ID)
+		if err != nil {
+			if !errors.Is(err, repository.ErrNotFound) {
+				logger.Printf("level=error op=ReapLocks stage=read authorId=%s recipeId=%s err=%v", listed.AuthorID, listed.ID, err)
+			}
+			continue
+		}
+		if recipe.Status != model.RecipeStatusInPreparation || !sameStart(recipe.PreparationStartedAt, listed.PreparationStartedAt) {
+			continue
+		}
+
+		if recipe.PreparationStartedAt == nil {
+			// Put in preparation before the TTL existed: start counting now.
+			recipe.PreparationStartedAt = toPtr(now.Format(time.RFC3339))
+			if err := l.Repos.Recipes.Replace(ctx, recipe, etag); err != nil && !errors.Is(err, repository.ErrConflict) {
+				logger.Printf("level=error op=ReapLocks stage=start_ttl authorId=%s recipeId=%s err=%v", recipe.AuthorID, recipe.ID, err)
+			}
+			continue
+		}
+
+		left := l.RecipeTTLRemaining(recipe, now)
+		if left == nil || *left > 0 {
+			continue
+		}
+
+		if err := l.UnlockIngredients(ctx, recipe.AuthorID, recipe.ID); err != nil {
+			logger.Printf("level=error op=ReapLocks stage=unlock authorId=%s recipeId=%s err=%v", recipe.AuthorID, recipe.ID, err)
+			continue
+		}
+
+		recipe.Status = model.RecipeStatusSaved
+		recipe.PreparationStartedAt = nil
+		recipe.PreparationPortions = nil
+		if err := l.Repos.Recipes.Replace(ctx, recipe, etag); err != nil {
+			if errors.Is(err, repository.ErrConflict) {
+				logger.Printf("level=info op=ReapLocks stage=changed authorId=%s recipeId=%s", recipe.AuthorID, recipe.ID)
+			} else {
+				logger.Printf("level=error op=ReapLocks stage=save authorId=%s recipeId=%s err=%v", recipe.AuthorID, recipe.ID, err)
+			}
+			continue
+		}
+
+		released++
+		l.Notify(ctx, recipe.AuthorID, model.NotificationTypeRecipeExpired, recipe.ID,
+			"La preparazione di %s è scaduta, gli ingredienti sono di nuovo disponibili", recipe.Title)
+	}
+
+	released += l.releaseLapsedMeals(ctx, now)
+
+	if released > 0 {
+		logger.Printf("level=info op=ReapLocks stage=done released=%d", released)
+	}
+	return released, nil
+}
+
+// sameStart reports whether two preparation start times are the same, both
+// being unset included.
+func sameStart(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -2,71 +2,147 @@ package logic
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/google/uuid"
 	"github.com/mariocosenza/mocc/graph/model"
+	"github.com/mariocosenza/mocc/internal/repository"
 )
 
 func (l *Logic) FetchRecipe(ctx context.Context, id string) (*model.Recipe, error) {
 	logger := l.GetLogger()
 
-	container, err := l.Cosmos.NewContainer(CosmosDatabase, ContainerCookbook)
+	recipe, err := l.Repos.Recipes.Get(ctx, id)
 	if err != nil {
-		logger.Printf("level=error op=GetRecipe stage=new_container db=%s container=%s recipeId=%s err=%v",
-			CosmosDatabase, ContainerCookbook, id, err)
+		logger.Printf("level=warn op=GetRecipe stage=query recipeId=%s err=%v", id, err)
 		return nil, err
 	}
+	return recipe, nil
+}
 
-	query := "SELECT * FROM c WHERE c.id = @id"
-	qOpts := azcosmos.QueryOptions{
-		QueryParameters: []azcosmos.QueryParameter{
-			{Name: "@id", Value: id},
-		},
+func (l *Logic) FetchRecipes(ctx context.Context, authorID string, status *model.RecipeStatus) ([]*model.Recipe, error) {
+	logger := l.GetLogger()
+
+	recipes, err := l.Repos.Recipes.ListByAuthor(ctx, authorID, status)
+	if err != nil {
+		logger.Printf("level=error op=GetRecipes stage=query authorId=%s err=%v", authorID, err)
+		return nil, err
 	}
+	return recipes, nil
+}
 
-	pager := container.NewQueryItemsPager(query, azcosmos.PartitionKey{}, &qOpts)
+func (l *Logic) FetchRecipesPage(ctx context.Context, authorID string, status *model.RecipeStatus, first *int32, after *string) (*model.RecipeConnection, error) {
+	logger := l.GetLogger()
 
-	for pager.More() {
-		resp, err := pager.NextPage(ctx)
-		if err != nil {
-			logger.Printf("level=error op=GetRecipe stage=query_next_page recipeId=%s err=%v", id, err)
-			return nil, err
-		}
-		if len(resp.Items) > 0 {
-			var recipe model.Recipe
-			if err := json.Unmarshal(resp.Items[0], &recipe); err != nil {
-				logger.Printf("level=warn op=GetRecipe stage=json_unmarshal recipeId=%s err=%v", id, err)
-				continue
-			}
-			return &recipe, nil
-		}
+	page, err := l.Repos.Recipes.ListByAuthorPage(ctx, authorID, status, pageSize(first, 20), cursorValue(after))
+	if err != nil {
+		logger.Printf("level=error op=GetRecipes stage=query authorId=%s err=%v", authorID, err)
+		return nil, pageError(err)
 	}
-	return nil, fmt.Errorf("not found")
+
+	return &model.RecipeConnection{
+		Edges: edges(page, func(cursor string, node *model.Recipe) *model.RecipeEdge {
+			return &model.RecipeEdge{Cursor: cursor, Node: node}
+		}),
+		PageInfo: pageInfo(page),
+	}, nil
 }
 
 func (l *Logic) UpsertRecipe(ctx context.Context, recipe *model.Recipe) error {
 	logger := l.GetLogger()
 
-	container, err := l.Cosmos.NewContainer(CosmosDatabase, ContainerCookbook)
+	err := l.Repos.Recipes.Upsert(ctx, recipe)
 	if err != nil {
-		logger.Printf("level=error op=SaveRecipe stage=new_container db=%s container=%s authorId=%s recipeId=%s err=%v",
-			CosmosDatabase, ContainerCookbook, recipe.AuthorID, recipe.ID, err)
-		return err
+		logger.Printf("level=error op=SaveRecipe stage=upsert authorId=%s recipeId=%s pk=%s err=%v",
+			recipe.AuthorID, recipe.ID, recipe.AuthorID, err)
 	}
+	return err
+}
+
+func (l *Logic) RemoveRecipe(ctx context.Context, recipe *model.Recipe) error {
+	logger := l.GetLogger()
 
-	data, err := json.Marshal(recipe)
+	err := l.Repos.Recipes.Delete(ctx, recipe.AuthorID, recipe.ID)
 	if err != nil {
-		logger.Printf("level=error op=SaveRecipe stage=json_marshal authorId=%s recipeId=%s err=%v",
+		logger.Printf("level=error op=DeleteRecipe stage=delete authorId=%s recipeId=%s err=%v",
 			recipe.AuthorID, recipe.ID, err)
-		return err
+	}
+	return err
+}
+
+// CloneRecipeFromPost copies the recipe shared in a post into the user's
+// cookbook as SAVED, keeping track of where it came from. Saving the same post
+// twice returns the copy made the first time.
+func (l *Logic) CloneRecipeFromPost(ctx context.Context, userID, postID string) (*model.Recipe, error) {
+	logger := l.GetLogger()
+
+	post, err := l.FetchPost(ctx, postID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("post not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if post.RecipeSnapshot == nil {
+		return nil, fmt.Errorf("post has no recipe")
 	}
 
-	_, err = container.UpsertItem(ctx, azcosmos.NewPartitionKeyString(recipe.AuthorID), data, nil)
+	existing, err := l.FetchRecipes(ctx, userID, nil)
 	if err != nil {
-		logger.Printf("level=error op=SaveRecipe stage=upsert authorId=%s recipeId=%s pk=%s err=%v",
-			recipe.AuthorID, recipe.ID, recipe.AuthorID, err)
+		return nil, err
 	}
-	return err
+	for _, recipe := range existing {
+		if recipe.SavedFrom != nil && recipe.SavedFrom.PostID == postID {
+			return recipe, nil
+		}
+	}
+
+	snapshot := post.RecipeSnapshot
+	ingredients := make([]*model.RecipeIngredient, 0, len(snapshot.Ingredients))
+	for _, ing := range snapshot.Ingredients {
+		ingredients = append(ingredients, &model.RecipeIngredient{
+			Name:     ing.Name,
+			Quantity: ing.Quantity,
+			Unit:     ing.Unit,
+		})
+	}
+	if err := l.MatchFridgeIngredients(ctx, userID, ingredients); err != nil {
+		// The copy is still useful without links to the fridge.
+		logger.Printf("level=warn op=SaveRecipeFromPost stage=match userId=%s postId=%s err=%v", userID, postID, err)
+	}
+
+	steps := []string{}
+	if snapshot.Steps != nil {
+		steps = append(steps, snapshot.Steps...)
+	}
+	desc := ""
+	if snapshot.Description != nil {
+		desc = *snapshot.Description
+	}
+
+	recipe := &model.Recipe{
+		ID:              uuid.New().String(),
+		AuthorID:        userID,
+		Title:           snapshot.Title,
+		Description:     desc,
+		Status:          model.RecipeStatusSaved,
+		Ingredients:     ingredients,
+		Steps:           steps,
+		PrepTimeMinutes: snapshot.PrepTimeMinutes,
+		Calories:        snapshot.Calories,
+		EcoPointsReward: snapshot.EcoPointsReward,
+		Servings:        snapshot.Servings,
+		GeneratedByAi:   false,
+		SavedFrom: &model.RecipeAttribution{
+			PostID:         post.ID,
+			AuthorID:       post.AuthorID,
+			AuthorNickname: post.AuthorNickname,
+		},
+	}
+
+	if err := l.UpsertRecipe(ctx, recipe); err != nil {
+		return nil, err
+	}
+	return recipe, nil
 }
@@ -2,10 +2,10 @@ package logic
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"time"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/google/uuid"
 	"github.com/mariocosenza/mocc/auth"
 	"github.com/mariocosenza/mocc/graph/model"
 )
@@ -13,20 +13,7 @@ import (
 func (l *Logic) UpsertShoppingHistory(ctx context.Context, entry *model.ShoppingHistoryEntry) error {
 	logger := l.GetLogger()
 
-	container, err := l.Cosmos.NewContainer(CosmosDatabase, ContainerHistory)
-	if err != nil {
-		logger.Printf("level=error op=SaveShoppingHistory stage=new_container db=%s container=%s userId=%s err=%v",
-			CosmosDatabase, ContainerHistory, entry.AuthorID, err)
-		return err
-	}
-
-	data, err := json.Marshal(entry)
-	if err != nil {
-		logger.Printf("level=error op=SaveShoppingHistory stage=json_marshal entryId=%s err=%v", entry.ID, err)
-		return err
-	}
-
-	_, err = container.UpsertItem(ctx, azcosmos.NewPartitionKeyString(entry.AuthorID), data, nil)
+	err := l.Repos.History.Upsert(ctx, entry)
 	if err != nil {
 		logger.Printf("level=error op=SaveShoppingHistory stage=upsert entryId=%s pk=%s err=%v", entry.ID, entry.AuthorID, err)
 	}
@@ -41,67 +28,112 @@ func (l *Logic) FetchShoppingHistory(ctx context.Context, id string) (*model.Sho
 		return nil, fmt.Errorf("unauthorized")
 	}
 
-	container, err := l.Cosmos.NewContainer(CosmosDatabase, ContainerHistory)
-	if err != nil {
-		return nil, err
-	}
-
-	itemResponse, err := container.ReadItem(ctx, azcosmos.NewPartitionKeyString(uid), id, nil)
+	entry, err := l.Repos.History.Get(ctx, uid, id)
 	if err != nil {
 		logger.Printf("level=warn op=GetShoppingHistory stage=read_item id=%s pk=%s err=%v", id, uid, err)
 		return nil, err
 	}
 
-	var entry model.ShoppingHistoryEntry
-	if err := json.Unmarshal(itemResponse.Value, &entry); err != nil {
-		return nil, err
+	return entry, nil
+}
+
+// FetchShoppingHistoryPage returns a page of the user's entries, newest first.
+func (l *Logic) FetchShoppingHistoryPage(ctx context.Context, userID string, first *int32, after *string) (*model.ShoppingHistoryConnection, error) {
+	logger := l.GetLogger()
+
+	page, err := l.Repos.History.ListByAuthorPage(ctx, userID, pageSize(first, 10), cursorValue(after))
+	if err != nil {
+		logger.Printf("level=error op=GetShoppingHistoryList stage=query userId=%s err=%v", userID, err)
+		return nil, pageError(err)
 	}
 
-	return &entry, nil
+	return &model.ShoppingHistoryConnection{
+		Edges: edges(page, func(cursor string, node *model.ShoppingHistoryEntry) *model.ShoppingHistoryEdge {
+			return &model.ShoppingHistoryEdge{Cursor: cursor, Node: node}
+		}),
+		PageInfo: pageInfo(page),
+	}, nil
 }
 
-func (l *Logic) FetchShoppingHistoryList(ctx context.Context, userID string, limit, offset int) ([]*model.ShoppingHistoryEntry, error) {
+func (l *Logic) RemoveShoppingHistory(ctx context.Context, entry *model.ShoppingHistoryEntry) error {
 	logger := l.GetLogger()
 
-	container, err := l.Cosmos.NewContainer(CosmosDatabase, ContainerHistory)
+	err := l.Repos.History.Delete(ctx, entry.AuthorID, entry.ID)
 	if err != nil {
-		return nil, err
+		logger.Printf("level=error op=DeleteShoppingHistory stage=delete entryId=%s pk=%s err=%v", entry.ID, entry.AuthorID, err)
 	}
+	return err
+}
+
+// ImportShoppingHistory adds the items of a shopping history entry to a
+// fridge and marks the entry as imported. An entry is only imported once.
+func (l *Logic) ImportShoppingHistory(ctx context.Context, userID string, entry *model.ShoppingHistoryEntry, fridgeID *string, operationID *string) (*model.ShoppingHistoryEntry, error) {
+	logger := l.GetLogger()
 
-	query := "SELECT * FROM c WHERE c.authorId = @uid ORDER BY c.date DESC"
-	qOpts := azcosmos.QueryOptions{
-		QueryParameters: []azcosmos.QueryParameter{
-			{Name: "@uid", Value: userID},
-		},
+	if entry.IsImported {
+		return nil, fmt.Errorf("history already imported")
 	}
 
-	pager := container.NewQueryItemsPager(query, azcosmos.NewPartitionKeyString(userID), &qOpts)
+	now := time.Now()
+	newItems := []*model.InventoryItem{}
+	for _, histItem := range entry.ItemsSnapshot {
+		price := float64(0)
+		if histItem.Price != nil {
+			price = *histItem.Price
+		}
 
-	var entries []*model.ShoppingHistoryEntry
-	for pager.More() {
-		resp, err := pager.NextPage(ctx)
-		if err != nil {
-			logger.Printf("level=error op=GetShoppingHistoryList stage=query_next_page userId=%s err=%v", userID, err)
-			return nil, err
+		var qtyVal float64 = 1.0
+		if histItem.Quantity != nil {
+			qtyVal = *histItem.Quantity
 		}
-		for _, bytes := range resp.Items {
-			var entry model.ShoppingHistoryEntry
-			if err := json.Unmarshal(bytes, &entry); err == nil {
-				entries = append(entries, &entry)
-			}
+
+		var unitVal model.Unit = model.UnitPz
+		if histItem.Unit != nil {
+			unitVal = *histItem.Unit
 		}
-		if len(entries) >= offset+limit {
-			break
+
+		newItem := &model.InventoryItem{
+			ID:               "User@" + uuid.New().String(),
+			Name:             histItem.Name,
+			Quantity:         &model.Quantity{Value: qtyVal, Unit: unitVal},
+			VirtualAvailable: qtyVal,
+			Price:            &price,
+			Status:           model.ItemStatusAvailable,
+			AddedAt:          now.Format(time.RFC3339),
+			ActiveLocks:      []*model.ProductLock{},
+		}
+
+		if histItem.ExpiryDate != nil {
+			newItem.ExpiryDate = *histItem.ExpiryDate
+		} else {
+			newItem.ExpiryDate = now.Add(7 * 24 * time.Hour).Format(time.RFC3339)
 		}
+
+		if histItem.ExpiryType != nil {
+			newItem.ExpiryType = *histItem.ExpiryType
+		} else {
+			newItem.ExpiryType = model.ExpiryTypeBestBefore
+		}
+
+		if histItem.Category != nil {
+			newItem.Category = histItem.Category
+		}
+		if histItem.Brand != nil {
+			newItem.Brand = histItem.Brand
+		}
+
+		newItems = append(newItems, newItem)
 	}
 
-	if offset > len(entries) {
-		return []*model.ShoppingHistoryEntry{}, nil
+	if err := l.ImportInventoryItems(ctx, userID, fridgeID, entry.ID, operationID, newItems...); err != nil {
+		return nil, err
 	}
-	end := offset + limit
-	if end > len(entries) {
-		end = len(entries)
+
+	entry.IsImported = true
+	if err := l.UpsertShoppingHistory(ctx, entry); err != nil {
+		logger.Printf("level=error op=ImportShoppingHistory stage=mark_imported entryId=%s err=%v", entry.ID, err)
+		return nil, err
 	}
 
-	return entries[offset:end], nil
+	return entry, nil
 }
@@ -0,0 +1,193 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mariocosenza/mocc/graph/model"
+	"github.com/mariocosenza/mocc/internal/repository"
+)
+
+// FetchShoppingList returns the shopping list of a fridge the user can see.
+// A fridge nobody planned a purchase for yet has an empty list.
+func (l *Logic) FetchShoppingList(ctx context.Context, userID string, fridgeID *string) (*model.ShoppingList, error) {
+	logger := l.GetLogger()
+
+	target, err := l.ResolveFridgeID(ctx, userID, fridgeID)
+	if err != nil {
+		return nil, err
+	}
+
+	list, _, err := l.Repos.Shopping.Get(ctx, target)
+	if errors.Is(err, repository.ErrNotFound) {
+		return emptyShoppingList(target), nil
+	}
+	if err != nil {
+		logger.Printf("level=error op=GetShoppingList stage=read_item fridgeId=%s err=%v", target, err)
+		return nil, err
+	}
+	return list, nil
+}
+
+// UpdateShoppingList runs mutate against the shopping list of a fridge the
+// user can edit, creating the list on its first write. Like UpdateFridge it
+// retries on concurrent writes, so mutate must derive everything from the
+// list it is given, and it reports whether it changed anything.
+func (l *Logic) UpdateShoppingList(ctx context.Context, userID string, fridgeID *string, mutate func(list *model.ShoppingList) (bool, error)) (*model.ShoppingList, error) {
+	logger := l.GetLogger()
+
+	target, err := l.EditableFridgeID(ctx, userID, fridgeID)
+	if err != nil {
+		return nil, err
+	}
+
+	var list *model.ShoppingList
+	err = l.withWriteRetry(ctx, "SaveShoppingList", target, "shopping list", func() error {
+		current, etag, err := l.Repos.Shopping.Get(ctx, target)
+		if errors.Is(err, repository.ErrNotFound) {
+			current, etag = emptyShoppingList(target), ""
+		} else if err != nil {
+			logger.Printf("level=error op=GetShoppingList stage=read_item fridgeId=%s err=%v", target, err)
+			return err
+		}
+
+		changed, err := mutate(current)
+		if err != nil {
+			return err
+		}
+		list = current
+		if !changed {
+			return nil
+		}
+
+		current.UpdatedAt = toPtr(time.Now().Format(time.RFC3339))
+		if etag == "" {
+			err = l.Repos.Shopping.Create(ctx, current)
+		} else {
+			err = l.Repos.Shopping.Replace(ctx, current, etag)
+		}
+		if err != nil && !errors.Is(err, repository.ErrConflict) {
+			logger.Printf("level=error op=SaveShoppingList stage=write fridgeId=%s err=%v", target, err)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// PurchaseShoppingList turns the checked items of a shopping list into a
+// shopping history entry and drops them from the list. With importToFridge
+// set they are also added to the fridge the list belongs to.
+//
+// The entry is written before the list changes, so no purchase goes
+// unrecorded: if the list cannot be updated the entry is taken back, and if
+// the import fails the entry stays for the user to import later.
+func (l *Logic) PurchaseShoppingList(ctx context.Context, userID string, fridgeID *string, input *model.CheckoutShoppingListInput) (*model.ShoppingHistoryEntry, error) {
+	if input == nil {
+		input = &model.CheckoutShoppingListInput{}
+	}
+
+	target, err := l.EditableFridgeID(ctx, userID, fridgeID)
+	if err != nil {
+		return nil, err
+	}
+	list, err := l.FetchShoppingList(ctx, userID, &target)
+	if err != nil {
+		return nil, err
+	}
+
+	bought := map[string]bool{}
+	items := []*model.HistoryItem{}
+	for _, item := range list.Items {
+		if !item.Checked {
+			continue
+		}
+		bought[item.ID] = true
+		items = append(items, &model.HistoryItem{
+			ID:       toPtr(uuid.New().String()),
+			Name:     item.Name,
+			Quantity: item.Quantity,
+			Unit:     item.Unit,
+			Category: item.Category,
+		})
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no checked items to check out")
+	}
+
+	date := time.Now().Format(time.RFC3339)
+	if input.Date != nil && *input.Date != "" {
+		date = *input.Date
+	}
+
+	entry := &model.ShoppingHistoryEntry{
+		ID:            uuid.New().String(),
+		AuthorID:      userID,
+		Date:          date,
+		StoreName:     input.StoreName,
+		TotalAmount:   input.TotalAmount,
+		Currency:      l.userCurrency(ctx, userID).String(),
+		ItemsSnapshot: items,
+		Status:        model.ShoppingHistoryStatusSaved,
+	}
+	if err := l.UpsertShoppingHistory(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	_, err = l.UpdateShoppingList(ctx, userID, &target, func(list *model.ShoppingList) (bool, error) {
+		kept := []*model.ShoppingListItem{}
+		for _, item := range list.Items {
+			if !bought[item.ID] {
+				kept = append(kept, item)
+			}
+		}
+		changed := len(kept) != len(list.Items)
+		list.Items = kept
+		return changed, nil
+	})
+	if err != nil {
+		_ = l.RemoveShoppingHistory(ctx, entry)
+		return nil, err
+	}
+
+	if input.ImportToFridge != nil && *input.ImportToFridge {
+		return l.ImportShoppingHistory(ctx, userID, entry, &target, input.OperationID)
+	}
+	return entry, nil
+}
+
+func emptyShoppingList(fridgeID string) *model.ShoppingList {
+	return &model.ShoppingList{
+		ID:       repository.ShoppingListID(fridgeID),
+		FridgeID: fridgeID,
+		Items:    []*model.ShoppingListItem{},
+	}
+}
+
+// ShoppingListItemIndex is the position of an item in the list, -1 when it is
+// not there.
+func ShoppingListItemIndex(list *model.ShoppingList, itemID string) int {
+	for i, item := range list.Items {
+		if item.ID == itemID {
+			return i
+		}
+	}
+	return -1
+}
+
+// ValidateShoppingListItem checks what the user typed for an item.
+func ValidateShoppingListItem(name string, quantity *float64) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("the item name cannot be empty")
+	}
+	if quantity != nil && *quantity < 0 {
+		return fmt.Errorf("the quantity cannot be negative")
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mariocosenza/mocc/graph/model"
+	"github.com/mariocosenza/mocc/internal/units"
+)
+
+// demand is one product needed by the recipes being planned, summed over all
+// of them in the base unit of its dimension.
+type demand struct {
+	ingredient *model.RecipeIngredient
+	linked     []string
+}
+
+// PlanShoppingList works out what is missing to cook the given recipes:
+// their ingredients are summed, what the user's fridges hold is taken off and
+// the rest is grouped by category. portions scales every recipe from the
+// servings it was written for. With save set the list is kept as a staged
+// shopping history entry, to be edited and imported later.
+func (l *Logic) PlanShoppingList(ctx context.Context, userID string, recipeIDs []string, portions *int32, save bool) (*model.GeneratedShoppingList, error) {
+	if len(recipeIDs) == 0 {
+		return nil, fmt.Errorf("at least one recipe is required")
+	}
+	recipes := make([]*model.Recipe, 0, len(recipeIDs))
+	planned := map[string]bool{}
+	for _, id := range recipeIDs {
+		if planned[id] {
+			continue
+		}
+		recipe, err := l.FetchRecipe(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if recipe.AuthorID != userID {
+			return nil, fmt.Errorf("unauthorized")
+		}
+		if portions != nil {
+			if recipe, err = l.ScaleRecipe(ctx, userID, recipe, *portions); err != nil {
+				return nil, err
+			}
+		}
+		planned[id] = true
+		recipes = append(recipes, recipe)
+	}
+
+	items, err := l.userFridgeItems(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := shortfall(sumIngredients(recipes), items, planned)
+
+	out := &model.GeneratedShoppingList{Groups: groupByCategory(missing)}
+	if save && len(missing) > 0 {
+		entry, err := l.stageShoppingList(ctx, userID, missing)
+		if err != nil {
+			return nil, err
+		}
+		out.Entry = entry
+	}
+	return out, nil
+}
+
+// sumIngredients merges the ingredients of the recipes that name the same
+// product in the same dimension, so "200 g farina" and "0.3 kg di farina"
+// become one demand of 500 g.
+func sumIngredients(recipes []*model.Recipe) []*demand {
+	demands := []*demand{}
+	byKey := map[string]*demand{}
+	for _, recipe := range recipes {
+		for _, ing := range recipe.Ingredients {
+			dim := units.DimensionOf(ing.Unit)
+			unit := baseUnit(dim)
+			quantity, err := units.Convert(ing.Quantity, ing.Unit, unit, units.Lookup(ing.Name))
+			if err != nil {
+				quantity = 0
+			}
+
+			key := strings.Join(matchKey(ing.Name), " ")
+			if key == "" {
+				key = strings.ToLower(strings.TrimSpace(ing.Name))
+			}
+			key = fmt.Sprintf("%s/%d", key, dim)
+
+			d, ok := byKey[key]
+			if !ok {
+				d = &demand{ingredient: &model.RecipeIngredient{Name: ing.Name, Unit: unit}}
+				byKey[key] = d
+				demands = append(demands, d)
+			}
+			d.ingredient.Quantity += quantity
+			if ing.InventoryItemID != nil && *ing.InventoryItemID != "" {
+				d.linked = append(d.linked, *ing.InventoryItemID)
+			}
+		}
+	}
+	return demands
+}
+
+// shortfall takes what the fridge items can supply off each demand. Locks
+// held by the planned recipes themselves count as available, since those
+// recipes are the ones the stock was put aside for.
+func shortfall(demands []*demand, items []*model.InventoryItem, planned map[string]bool) []*model.HistoryItem {
+	left := map[string]float64{}
+	remaining := func(item *model.InventoryItem) float64 {
+		if v, ok := left[item.ID]; ok {
+			return v
+		}
+		v := item.VirtualAvailable
+		for _, lock := range item.ActiveLocks {
+			if planned[lock.RecipeID] {
+				v += lock.Amount
+			}
+		}
+		return v
+	}
+
+	missing := []*model.HistoryItem{}
+	used := map[string]bool{}
+	for _, d := range demands {
+		ing := d.ingredient
+		candidates := matchIngredient(ing, items, "")
+		for _, id := range d.linked {
+			candidates = linkedFirst(candidates, items, id)
+		}
+
+		var category *string
+		if len(candidates) > 0 {
+			category = candidates[0].Item.Category
+		}
+
+		need := ing.Quantity
+		if units.IsNonDepleting(ing.Unit) {
+			if len(candidates) > 0 {
+				continue
+			}
+		} else {
+			need = drawStock(need, ing.Unit, candidates, remaining, left, used)
+			if need <= 0.001 {
+				continue
+			}
+		}
+
+		quantity, unit := displayQuantity(need, ing.Unit)
+		missing = append(missing, &model.HistoryItem{
+			ID:       toPtr(uuid.New().String()),
+			Name:     ing.Name,
+			Quantity: &quantity,
+			Unit:     &unit,
+			Category: category,
+		})
+	}
+	return missing
+}
+
+// groupByCategory sorts the missing products into categories by name, the
+// uncategorised ones last.
+func groupByCategory(missing []*model.HistoryItem) []*model.ShoppingListGroup {
+	groups := []*model.ShoppingListGroup{}
+	byCategory := map[string]*model.ShoppingListGroup{}
+	for _, item := range missing {
+		key := ""
+		if item.Category != nil {
+			key = *item.Category
+		}
+		group, ok := byCategory[key]
+		if !ok {
+			group = &model.ShoppingListGroup{Category: item.Category, Items: []*model.MissingIngredient{}}
+			byCategory[key] = group
+			groups = append(groups, group)
+		}
+		group.Items = append(group.Items, &model.MissingIngredient{Name: item.Name, Quantity: *item.Quantity, Unit: *item.Unit})
+	}
+
+	for _, group := range groups {
+		sort.SliceStable(group.Items, func(i, j int) bool { return group.Items[i].Name < group.Items[j].Name })
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i].Category, groups[j].Category
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return *a < *b
+	})
+	return groups
+}
+
+// stageShoppingList saves the missing products as a shopping history entry
+// still in staging, which the user edits and imports once bought.
+func (l *Logic) stageShoppingList(ctx context.Context, userID string, missing []*model.HistoryItem) (*model.ShoppingHistoryEntry, error) {
+	entry := &model.ShoppingHistoryEntry{
+		ID:            uuid.New().String(),
+		AuthorID:      userID,
+		Date:          time.Now().Format(time.RFC3339),
+		Currency:      l.userCurrency(ctx, userID).String(),
+		ItemsSnapshot: missing,
+		Status:        model.ShoppingHistoryStatusInStaging,
+	}
+	if err := l.UpsertShoppingHistory(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func baseUnit(d units.Dimension) model.Unit {
+	switch d {
+	case units.Mass:
+		return model.UnitG
+	case units.Volume:
+		return model.UnitMl
+	case units.Count:
+		return model.UnitPz
+	default:
+		return model.UnitQb
+	}
+}
+
+// displayQuantity rounds an amount the way it is bought: whole pieces, and
+// kilograms or litres from 1000 g or ml up.
+func displayQuantity(value float64, unit model.Unit) (float64, model.Unit) {
+	switch unit {
+	case model.UnitPz:
+		return math.Ceil(value - 0.001), unit
+	case model.UnitG:
+		if value >= 1000 {
+			return math.Round(value/10) / 100, model.UnitKg
+		}
+	case model.UnitMl:
+		if value >= 1000 {
+			return math.Round(value/10) / 100, model.UnitL
+		}
+	}
+	return math.Round(value*100) / 100, unit
+}
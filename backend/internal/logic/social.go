@@ -0,0 +1,158 @@
+package logic
+
+import (
+	"context"
+
+	"github.com/mariocosenza/mocc/graph/model"
+	"github.com/mariocosenza/mocc/internal/repository"
+)
+
+func (l *Logic) FetchPost(ctx context.Context, id string) (*model.Post, error) {
+	logger := l.GetLogger()
+
+	post, err := l.Repos.Posts.Get(ctx, id)
+	if err != nil {
+		logger.Printf("level=warn op=GetPost stage=read_item postId=%s err=%v", id, err)
+		return nil, err
+	}
+	return post, nil
+}
+
+func (l *Logic) FetchPosts(ctx context.Context) ([]*model.Post, error) {
+	logger := l.GetLogger()
+
+	posts, err := l.Repos.Posts.List(ctx)
+	if err != nil {
+		logger.Printf("level=error op=GetPosts stage=query err=%v", err)
+		return nil, err
+	}
+	return posts, nil
+}
+
+// FetchFeed returns a page of posts, newest first. With respectDiet set,
+// posts sharing a recipe that goes against the user's dietary restrictions
+// are left out, and further pages are read until this one is full or
+// FeedDietScanPages were read; the page may then come back short, its end
+// cursor pointing past the last post read.
+func (l *Logic) FetchFeed(ctx context.Context, userID string, first *int32, after *string, respectDiet bool) (*model.PostConnection, error) {
+	logger := l.GetLogger()
+
+	var forbidden map[model.DietTag]bool
+	if respectDiet {
+		forbidden = l.forbiddenDietTags(ctx, userID)
+	}
+
+	n := pageSize(first, 20)
+	cursor := cursorValue(after)
+	feed := &repository.Page[model.Post]{}
+	scanned := ""
+	for pages := 1; ; pages++ {
+		page, err := l.Repos.Posts.ListPage(ctx, n, cursor)
+		if err != nil {
+			logger.Printf("level=error op=GetFeed stage=query err=%v", err)
+			return nil, pageError(err)
+		}
+		if forbidden == nil {
+			feed = page
+			break
+		}
+
+		for i, edge := range page.Edges {
+			scanned = edge.Cursor
+			if post := edge.Node; post.RecipeSnapshot != nil && !fitsDiet(SnapshotDietTags(post.RecipeSnapshot), forbidden) {
+				continue
+			}
+			feed.Edges = append(feed.Edges, edge)
+			if len(feed.Edges) == n {
+				feed.HasNextPage = i < len(page.Edges)-1 || page.HasNextPage
+				break
+			}
+		}
+		if len(feed.Edges) == n || !page.HasNextPage || len(page.Edges) == 0 {
+			break
+		}
+		if pages == FeedDietScanPages {
+			feed.HasNextPage = true
+			break
+		}
+		cursor = page.Edges[len(page.Edges)-1].Cursor
+	}
+
+	info := pageInfo(feed)
+	if feed.HasNextPage && len(feed.Edges) < n {
+		info.EndCursor = &scanned
+	}
+	return &model.PostConnection{
+		Edges: edges(feed, func(cursor string, node *model.Post) *model.PostEdge {
+			return &model.PostEdge{Cursor: cursor, Node: node}
+		}),
+		PageInfo: info,
+	}, nil
+}
+
+func (l *Logic) UpsertPost(ctx context.Context, post *model.Post) error {
+	logger := l.GetLogger()
+
+	err := l.Repos.Posts.Upsert(ctx, post)
+	if err != nil {
+		logger.Printf("level=error op=SavePost stage=upsert postId=%s err=%v", post.ID, err)
+		return err
+	}
+	l.PublishPostUpdated(ctx, post)
+	return nil
+}
+
+func (l *Logic) RemovePost(ctx context.Context, id string) error {
+	logger := l.GetLogger()
+
+	err := l.Repos.Posts.Delete(ctx, id)
+	if err != nil {
+		logger.Printf("level=error op=DeletePost stage=delete postId=%s err=%v", id, err)
+	}
+	return err
+}
+
+// PropagateNickname rewrites the denormalized nickname on the user's posts and
+// on their comments under other people's posts.
+func (l *Logic) PropagateNickname(ctx context.Context, userID, nickname string) {
+	logger := l.GetLogger()
+
+	authored, err := l.Repos.Posts.ListByAuthor(ctx, userID)
+	if err != nil {
+		logger.Printf("level=warn op=PropagateNickname stage=query_authored userId=%s err=%v", userID, err)
+	}
+	for _, post := range authored {
+		if post.AuthorNickname == nickname {
+			continue
+		}
+		post.AuthorNickname = nickname
+		renameCommenter(post, userID, nickname)
+		if err := l.UpsertPost(ctx, post); err == nil {
+			logger.Printf("level=info op=PropagateNickname stage=post_updated postId=%s", post.ID)
+		}
+	}
+
+	commented, err := l.Repos.Posts.ListCommentedBy(ctx, userID)
+	if err != nil {
+		logger.Printf("level=warn op=PropagateNickname stage=query_commented userId=%s err=%v", userID, err)
+	}
+	for _, post := range commented {
+		if !renameCommenter(post, userID, nickname) {
+			continue
+		}
+		if err := l.UpsertPost(ctx, post); err == nil {
+			logger.Printf("level=info op=PropagateNickname stage=comment_updated postId=%s", post.ID)
+		}
+	}
+}
+
+func renameCommenter(post *model.Post, userID, nickname string) bool {
+	updated := false
+	for _, c := range post.Comments {
+		if c.UserID == userID && c.UserNickname != nickname {
+			c.UserNickname = nickname
+			updated = true
+		}
+	}
+	return updated
+}
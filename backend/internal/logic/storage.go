@@ -19,6 +19,9 @@ import (
 )
 
 func (l *Logic) SetupBlobCORS(ctx context.Context) error {
+	if l.BlobClient == nil {
+		return ErrBlobStorageDisabled
+	}
 	logger := l.GetLogger()
 
 	serviceProps, err := l.BlobClient.ServiceClient().GetProperties(ctx, nil)
@@ -50,6 +53,10 @@ func (l *Logic) SetupBlobCORS(ctx context.Context) error {
 }
 
 func (l *Logic) CreateSAS(ctx context.Context, containerName string, blobName string, permissions sas.BlobPermissions, duration time.Duration) (string, error) {
+	if l.BlobClient == nil {
+		return "", ErrBlobStorageDisabled
+	}
+
 	connStr := os.Getenv("AZURE_STORAGE_CONNECTION_STRING")
 
 	if connStr != "" {
@@ -107,6 +114,9 @@ func (l *Logic) CreateSAS(ctx context.Context, containerName string, blobName st
 }
 
 func (l *Logic) RelocateBlob(ctx context.Context, containerName, srcBlobName, destBlobName string) (string, error) {
+	if l.BlobClient == nil {
+		return "", ErrBlobStorageDisabled
+	}
 	containerClient := l.BlobClient.ServiceClient().NewContainerClient(containerName)
 	srcClient := containerClient.NewBlobClient(srcBlobName)
 	destClient := containerClient.NewBlobClient(destBlobName)
@@ -155,6 +165,9 @@ func (l *Logic) RelocateBlob(ctx context.Context, containerName, srcBlobName, de
 }
 
 func (l *Logic) CreateContainerIfNotExists(ctx context.Context, containerName string) error {
+	if l.BlobClient == nil {
+		return ErrBlobStorageDisabled
+	}
 	_, err := l.BlobClient.ServiceClient().NewContainerClient(containerName).Create(ctx, nil)
 	if err != nil {
 		if strings.Contains(err.Error(), "ContainerAlreadyExists") || strings.Contains(err.Error(), "409") {
@@ -191,7 +204,7 @@ func (l *Logic) ForwardToAzureFunction(ctx context.Context, url string, payload
 }
 
 func (l *Logic) DeleteBlob(ctx context.Context, blobUrl string) error {
-	if blobUrl == "" {
+	if blobUrl == "" || l.BlobClient == nil {
 		return nil
 	}
 	u, err := url.Parse(blobUrl)
@@ -0,0 +1,272 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mariocosenza/mocc/graph/model"
+	"github.com/mariocosenza/mocc/internal/repository"
+	"github.com/redis/go-redis/v9"
+)
+
+// maxOperationIDLength bounds the operation ids clients may choose.
+const maxOperationIDLength = 64
+
+// inventoryOperation is what undoInventoryOperation needs to put the items an
+// operation touched back. It is kept in Redis for the undo window only.
+type inventoryOperation struct {
+	ID       string                   `json:"id"`
+	FridgeID string                   `json:"fridgeId"`
+	Type     model.InventoryEventType `json:"type"`
+	Items    []*operationItem         `json:"items"`
+	// WasteEventID is the ledger entry dropped when a waste is undone.
+	WasteEventID string `json:"wasteEventId,omitempty"`
+	// HistoryEntryID is the shopping trip marked as not imported again when
+	// an import is undone.
+	HistoryEntryID string `json:"historyEntryId,omitempty"`
+}
+
+// operationItem holds an item as it was before and after the operation.
+// Before is nil for items the operation created, After for the ones it
+// deleted.
+type operationItem struct {
+	ItemID string               `json:"itemId"`
+	Before *model.InventoryItem `json:"before,omitempty"`
+	After  *model.InventoryItem `json:"after,omitempty"`
+}
+
+func (l *Logic) undoWindow() time.Duration {
+	if l.UndoWindow > 0 {
+		return l.UndoWindow
+	}
+	return DefaultUndoWindow
+}
+
+func operationKey(userID, operationID string) string {
+	return UndoOperationPrefix + userID + ":" + operationID
+}
+
+// beginOperation starts recording an undoable operation under the id the
+// client chose, or a new one. Ids cannot be reused while their undo window is
+// open.
+func (l *Logic) beginOperation(ctx context.Context, userID, fridgeID string, operationID *string, eventType model.InventoryEventType) (*inventoryOperation, error) {
+	id := uuid.New().String()
+	if operationID != nil {
+		id = *operationID
+		if id == "" || len(id) > maxOperationIDLength {
+			return nil, fmt.Errorf("operationId must be between 1 and %d characters", maxOperationIDLength)
+		}
+		n, err := l.Redis.Exists(ctx, operationKey(userID, id)).Result()
+		if err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			return nil, codedError(ErrCodeConflict, "operation %s already exists", id)
+		}
+	}
+	return &inventoryOperation{ID: id, FridgeID: fridgeID, Type: eventType}, nil
+}
+
+// track wraps mutate so the item before and after it ran is recorded in the
+// operation. Only the last attempt of a retried write counts, and nothing is
+// recorded when the item is kept as it was.
+func (op *inventoryOperation) track(mutate func(item *model.InventoryItem) (ItemWrite, error)) func(item *model.InventoryItem) (ItemWrite, error) {
+	return func(item *model.InventoryItem) (ItemWrite, error) {
+		op.Items = nil
+		before := cloneItem(item)
+		write, err := mutate(item)
+		if err != nil || write == ItemKeep {
+			return write, err
+		}
+
+		tracked := &operationItem{ItemID: item.ID, Before: before}
+		if write != ItemDelete {
+			tracked.After = cloneItem(item)
+		}
+		op.Items = []*operationItem{tracked}
+		return write, nil
+	}
+}
+
+// event starts an inventory event belonging to the operation.
+func (op *inventoryOperation) event(actorID string) *model.InventoryEvent {
+	event := itemEvent(op.Type, actorID)
+	event.OperationID = &op.ID
+	return event
+}
+
+// saveOperation keeps the operation for the undo window. The change itself
+// has already been written, so a failure only costs the ability to undo it.
+func (l *Logic) saveOperation(ctx context.Context, userID string, op *inventoryOperation) {
+	if len(op.Items) == 0 {
+		return
+	}
+	data, err := json.Marshal(op)
+	if err == nil {
+		err = l.Redis.Set(ctx, operationKey(userID, op.ID), data, l.undoWindow()).Err()
+	}
+	if err != nil {
+		l.GetLogger().Printf("level=warn op=SaveInventoryOperation stage=redis_set userId=%s operationId=%s err=%v", userID, op.ID, err)
+	}
+}
+
+// MutateInventoryItemUndoable is MutateInventoryItem for changes the user can
+// take back with UndoOperation.
+func (l *Logic) MutateInventoryItemUndoable(ctx context.Context, userID string, fridgeID *string, itemID string, operationID *string, eventType model.InventoryEventType, mutate func(item *model.InventoryItem) (ItemWrite, error)) (*model.InventoryItem, error) {
+	target, err := l.FridgeIDForItem(ctx, userID, fridgeID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	op, err := l.beginOperation(ctx, userID, target, operationID, eventType)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := l.mutateItem(ctx, target, itemID, op.event(userID), op.track(mutate))
+	if err != nil {
+		return nil, err
+	}
+	l.saveOperation(ctx, userID, op)
+	return item, nil
+}
+
+// UndoOperation puts back the items touched by one of the user's operations
+// still inside the undo window, quantities, locks and all. Items changed
+// since are left alone and the undo fails with a conflict. It returns the
+// restored items.
+//
+// The operation is forgotten only once every item is back, so an undo that
+// fails halfway can be tried again and picks up where it stopped.
+func (l *Logic) UndoOperation(ctx context.Context, userID, operationID string) ([]*model.InventoryItem, error) {
+	logger := l.GetLogger()
+
+	data, err := l.Redis.Get(ctx, operationKey(userID, operationID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("operation not found or no longer undoable")
+	}
+	if err != nil {
+		logger.Printf("level=error op=UndoInventoryOperation stage=redis_get userId=%s operationId=%s err=%v", userID, operationID, err)
+		return nil, err
+	}
+	var op inventoryOperation
+	if err := json.Unmarshal(data, &op); err != nil {
+		return nil, err
+	}
+	if _, err := l.requireRole(ctx, userID, op.FridgeID, model.FridgeRoleEditor); err != nil {
+		return nil, err
+	}
+
+	for _, tracked := range op.Items {
+		if err := l.checkUndoable(ctx, op.FridgeID, tracked); err != nil {
+			return nil, err
+		}
+	}
+
+	restored := []*model.InventoryItem{}
+	for _, tracked := range op.Items {
+		item, err := l.undoItem(ctx, userID, &op, tracked)
+		if err != nil {
+			logger.Printf("level=error op=UndoInventoryOperation stage=restore userId=%s operationId=%s itemId=%s err=%v", userID, operationID, tracked.ItemID, err)
+			return nil, err
+		}
+		if item != nil {
+			restored = append(restored, item)
+		}
+	}
+
+	// Forgetting the operation makes a second undo fail.
+	if err := l.Redis.Del(ctx, operationKey(userID, operationID)).Err(); err != nil {
+		logger.Printf("level=warn op=UndoInventoryOperation stage=redis_del userId=%s operationId=%s err=%v", userID, operationID, err)
+	}
+
+	if op.WasteEventID != "" {
+		if err := l.Repos.Waste.Remove(ctx, userID, op.WasteEventID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			logger.Printf("level=error op=UndoInventoryOperation stage=waste_ledger userId=%s operationId=%s err=%v", userID, operationID, err)
+		}
+	}
+	if op.HistoryEntryID != "" {
+		if entry, err := l.FetchShoppingHistory(ctx, op.HistoryEntryID); err == nil && entry.AuthorID == userID {
+			entry.IsImported = false
+			if err := l.UpsertShoppingHistory(ctx, entry); err != nil {
+				logger.Printf("level=error op=UndoInventoryOperation stage=history userId=%s operationId=%s err=%v", userID, operationID, err)
+			}
+		}
+	}
+	return restored, nil
+}
+
+// checkUndoable makes sure the item is still as the operation left it, or
+// already as it was before, when an earlier undo stopped halfway.
+func (l *Logic) checkUndoable(ctx context.Context, fridgeID string, tracked *operationItem) error {
+	current, _, err := l.Repos.Inventory.Get(ctx, fridgeID, tracked.ItemID)
+	if errors.Is(err, repository.ErrNotFound) {
+		current = nil
+	} else if err != nil {
+		return err
+	}
+	if !sameItemState(current, tracked.After) && !sameItemState(current, tracked.Before) {
+		return codedError(ErrCodeConflict, "the item was changed after the operation and cannot be restored")
+	}
+	return nil
+}
+
+func (l *Logic) undoItem(ctx context.Context, userID string, op *inventoryOperation, tracked *operationItem) (*model.InventoryItem, error) {
+	event := op.event(userID)
+	event.Type = model.InventoryEventTypeRestored
+
+	if tracked.After == nil {
+		// Deleted by the operation: write it back as it was.
+		current, _, err := l.Repos.Inventory.Get(ctx, op.FridgeID, tracked.ItemID)
+		if err == nil && sameItemState(current, tracked.Before) {
+			return current, nil
+		}
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+		if err := l.Repos.Inventory.Upsert(ctx, op.FridgeID, tracked.Before); err != nil {
+			return nil, err
+		}
+		completeEvent(event, op.FridgeID, nil, tracked.Before, ItemReplace)
+		l.appendEvent(ctx, event)
+		l.PublishFridgeChanged(ctx, op.FridgeID)
+		return tracked.Before, nil
+	}
+
+	item, err := l.mutateItem(ctx, op.FridgeID, tracked.ItemID, event, func(item *model.InventoryItem) (ItemWrite, error) {
+		if sameItemState(item, tracked.Before) {
+			return ItemKeep, nil
+		}
+		if !sameItemState(item, tracked.After) {
+			return ItemKeep, codedError(ErrCodeConflict, "the item was changed after the operation and cannot be restored")
+		}
+		if tracked.Before == nil {
+			// Created by the operation: take it out again.
+			return ItemDelete, nil
+		}
+		*item = *cloneItem(tracked.Before)
+		return ItemReplace, nil
+	})
+	if tracked.Before == nil && errors.Is(err, ErrNoSuchItem) {
+		// Taken out by an earlier attempt.
+		return nil, nil
+	}
+	if err != nil || tracked.Before == nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// sameItemState compares two stored item states, nil meaning no item.
+func sameItemState(a, b *model.InventoryItem) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	da, errA := json.Marshal(a)
+	db, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(da, db)
+}
@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"math/big"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
 	"github.com/mariocosenza/mocc/auth"
 	"github.com/mariocosenza/mocc/graph/model"
 )
@@ -37,13 +36,13 @@ func (l *Logic) FetchUser(ctx context.Context, userID string) (*model.User, erro
 		logger.Printf("level=info op=GetUser stage=redis_get userId=%s err=%v", userID, err)
 	}
 
-	user, err := l.GetUserFromCosmos(ctx, userID)
+	user, err := l.GetUserFromStore(ctx, userID)
 	if err == nil && user != nil {
 		l.SetUserCache(ctx, user)
 		return user, nil
 	}
 	if err != nil {
-		logger.Printf("level=warn op=GetUser stage=cosmos_read userId=%s err=%v", userID, err)
+		logger.Printf("level=warn op=GetUser stage=store_read userId=%s err=%v", userID, err)
 	}
 
 	defaultPortions := int32(1)
@@ -65,7 +64,7 @@ func (l *Logic) FetchUser(ctx context.Context, userID string) (*model.User, erro
 	}
 
 	if err := l.UpsertUser(ctx, newUser); err != nil {
-		logger.Printf("level=error op=GetUser stage=cosmos_upsert_user userId=%s err=%v", userID, err)
+		logger.Printf("level=error op=GetUser stage=store_upsert_user userId=%s err=%v", userID, err)
 		return nil, err
 	}
 
@@ -92,50 +91,24 @@ func (l *Logic) SetUserCache(ctx context.Context, user *model.User) {
 	}
 }
 
-func (l *Logic) GetUserFromCosmos(ctx context.Context, userID string) (*model.User, error) {
+func (l *Logic) GetUserFromStore(ctx context.Context, userID string) (*model.User, error) {
 	logger := l.GetLogger()
 
-	container, err := l.Cosmos.NewContainer(CosmosDatabase, ContainerUsers)
+	user, err := l.Repos.Users.Get(ctx, userID)
 	if err != nil {
-		logger.Printf("level=error op=GetUserFromCosmos stage=new_container db=%s container=%s userId=%s err=%v",
-			CosmosDatabase, ContainerUsers, userID, err)
+		logger.Printf("level=warn op=GetUserFromStore stage=read_item userId=%s pk=%s err=%v", userID, userID, err)
 		return nil, err
 	}
 
-	itemResponse, err := container.ReadItem(ctx, azcosmos.NewPartitionKeyString(userID), userID, nil)
-	if err != nil {
-		logger.Printf("level=warn op=GetUserFromCosmos stage=read_item userId=%s pk=%s err=%v", userID, userID, err)
-		return nil, err
-	}
-
-	var user model.User
-	if err := json.Unmarshal(itemResponse.Value, &user); err != nil {
-		logger.Printf("level=warn op=GetUserFromCosmos stage=json_unmarshal userId=%s err=%v", userID, err)
-		return nil, err
-	}
-
-	return &user, nil
+	return user, nil
 }
 
 func (l *Logic) UpsertUser(ctx context.Context, user *model.User) error {
 	logger := l.GetLogger()
 
-	container, err := l.Cosmos.NewContainer(CosmosDatabase, ContainerUsers)
-	if err != nil {
-		logger.Printf("level=error op=SaveUserToCosmos stage=new_container db=%s container=%s userId=%s err=%v",
-			CosmosDatabase, ContainerUsers, user.ID, err)
-		return err
-	}
-
-	data, err := json.Marshal(user)
-	if err != nil {
-		logger.Printf("level=error op=SaveUserToCosmos stage=json_marshal userId=%s err=%v", user.ID, err)
-		return err
-	}
-
-	_, err = container.UpsertItem(ctx, azcosmos.NewPartitionKeyString(user.ID), data, nil)
+	err := l.Repos.Users.Upsert(ctx, user)
 	if err != nil {
-		logger.Printf("level=error op=SaveUserToCosmos stage=upsert userId=%s pk=%s err=%v", user.ID, user.ID, err)
+		logger.Printf("level=error op=SaveUser stage=upsert userId=%s pk=%s err=%v", user.ID, user.ID, err)
 	}
 	return err
 }
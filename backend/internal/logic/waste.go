@@ -0,0 +1,190 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mariocosenza/mocc/graph/model"
+)
+
+const (
+	uncategorizedWaste = "uncategorized"
+	unspecifiedReason  = "unspecified"
+)
+
+// RecordWaste takes amount out of the item like a consumption would, then
+// writes what was thrown away, and what it was worth, to the user's waste
+// ledger. The cost is the share of the item's price the amount represents.
+// Undoing the operation also takes the waste off the ledger.
+func (l *Logic) RecordWaste(ctx context.Context, userID string, fridgeID *string, itemID string, amount float64, unit *model.Unit, reason *string, operationID *string) (*model.InventoryItem, error) {
+	logger := l.GetLogger()
+
+	target, err := l.FridgeIDForItem(ctx, userID, fridgeID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	op, err := l.beginOperation(ctx, userID, target, operationID, model.InventoryEventTypeWasted)
+	if err != nil {
+		return nil, err
+	}
+
+	var event *model.WasteEvent
+	item, err := l.mutateItem(ctx, target, itemID, op.event(userID), op.track(func(item *model.InventoryItem) (ItemWrite, error) {
+		before, price := item.Quantity.Value, item.Price
+		write, err := DepleteItem(item, amount, unit)
+		if err != nil || write == ItemKeep {
+			event = nil
+			return write, err
+		}
+
+		event = &model.WasteEvent{
+			ID:       uuid.New().String(),
+			FridgeID: target,
+			ItemID:   item.ID,
+			ItemName: item.Name,
+			Category: item.Category,
+			Amount:   before - item.Quantity.Value,
+			Unit:     item.Quantity.Unit,
+			Reason:   reason,
+		}
+		if price != nil && item.Price != nil {
+			event.Cost = *price - *item.Price
+		}
+		return write, nil
+	}))
+	if err != nil || event == nil {
+		return item, err
+	}
+
+	event.Currency = l.userCurrency(ctx, userID)
+	event.WastedAt = time.Now().UTC().Format(time.RFC3339)
+	if err := l.Repos.Waste.Add(ctx, userID, event); err != nil {
+		logger.Printf("level=error op=WasteInventoryItem stage=ledger userId=%s itemId=%s err=%v", userID, itemID, err)
+		return nil, err
+	}
+	op.WasteEventID = event.ID
+	l.saveOperation(ctx, userID, op)
+	// Waste only ever breaks a streak, this starts tracking it for new users.
+	l.EvaluateBadges(ctx, userID, model.BadgeCriterionZeroWasteDays)
+	return item, nil
+}
+
+// WastedMoneyYTD sums the cost of what the user wasted since the start of the
+// year. Events recorded in another currency are left out rather than
+// converted.
+func (l *Logic) WastedMoneyYTD(ctx context.Context, userID string) (float64, error) {
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	events, err := l.Repos.Waste.ListByUser(ctx, userID, from.Format(time.RFC3339), now.Add(time.Second).Format(time.RFC3339))
+	if err != nil {
+		l.GetLogger().Printf("level=error op=WastedMoneyYTD stage=query userId=%s err=%v", userID, err)
+		return 0, err
+	}
+
+	currency := l.userCurrency(ctx, userID)
+	total := 0.0
+	for _, e := range events {
+		if e.Currency == currency {
+			total += e.Cost
+		}
+	}
+	return total, nil
+}
+
+// BuildWasteReport groups the user's waste between from and to by category
+// and by reason. Only events in the user's current currency count towards
+// the costs.
+func (l *Logic) BuildWasteReport(ctx context.Context, userID, from, to string) (*model.WasteReport, error) {
+	start, err := time.Parse(time.RFC3339, from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from date: %w", err)
+	}
+	end, err := time.Parse(time.RFC3339, to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to date: %w", err)
+	}
+	if !end.After(start) {
+		return nil, fmt.Errorf("to must be after from")
+	}
+
+	events, err := l.Repos.Waste.ListByUser(ctx, userID, start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339))
+	if err != nil {
+		l.GetLogger().Printf("level=error op=WasteReport stage=query userId=%s err=%v", userID, err)
+		return nil, err
+	}
+	if events == nil {
+		events = []*model.WasteEvent{}
+	}
+
+	report := &model.WasteReport{
+		From:     from,
+		To:       to,
+		Currency: l.userCurrency(ctx, userID),
+		Events:   events,
+	}
+	categories := map[string]*model.WasteReportBucket{}
+	reasons := map[string]*model.WasteReportBucket{}
+	for _, e := range events {
+		cost := 0.0
+		if e.Currency == report.Currency {
+			cost = e.Cost
+		}
+		report.TotalEvents++
+		report.TotalCost += cost
+
+		category := uncategorizedWaste
+		if e.Category != nil && *e.Category != "" {
+			category = *e.Category
+		}
+		reason := unspecifiedReason
+		if e.Reason != nil && *e.Reason != "" {
+			reason = *e.Reason
+		}
+		addToBucket(categories, category, cost)
+		addToBucket(reasons, reason, cost)
+	}
+	report.ByCategory = sortedBuckets(categories)
+	report.ByReason = sortedBuckets(reasons)
+	return report, nil
+}
+
+func addToBucket(buckets map[string]*model.WasteReportBucket, key string, cost float64) {
+	b, ok := buckets[key]
+	if !ok {
+		b = &model.WasteReportBucket{Key: key}
+		buckets[key] = b
+	}
+	b.Events++
+	b.Cost += cost
+}
+
+// sortedBuckets orders the buckets by cost, then by number of events.
+func sortedBuckets(buckets map[string]*model.WasteReportBucket) []*model.WasteReportBucket {
+	out := make([]*model.WasteReportBucket, 0, len(buckets))
+	for _, b := range buckets {
+		out = append(out, b)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Cost != out[j].Cost {
+			return out[i].Cost > out[j].Cost
+		}
+		if out[i].Events != out[j].Events {
+			return out[i].Events > out[j].Events
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
+
+// userCurrency is the currency the user picked, EUR when none is set.
+func (l *Logic) userCurrency(ctx context.Context, userID string) model.Currency {
+	user, err := l.FetchUser(ctx, userID)
+	if err != nil || user.Preferences == nil || user.Preferences.Currency == "" {
+		return model.CurrencyEur
+	}
+	return user.Preferences.Currency
+}
@@ -0,0 +1,1007 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/mariocosenza/mocc/graph/model"
+)
+
+const (
+	CosmosDatabase        = "mocc-db"
+	ContainerUsers        = "Users"        // PK: /id
+	ContainerInventory    = "Inventory"    // PK: /fridgeId
+	ContainerCookbook     = "Cookbook"     // PK: /authorId
+	ContainerSocial       = "Social"       // PK: /type
+	ContainerHistory      = "History"      // PK: /authorId
+	ContainerStaging      = "Staging"      // PK: /id
+	ContainerLeaderboard  = "Leaderboard"  // PK: /period
+	ContainerLedger       = "Ledger"       // PK: /userId
+	ContainerInventoryLog = "InventoryLog" // PK: /fridgeId
+
+	socialTypePost = "post"
+
+	// Fridges and their items share the Inventory container, told apart by type.
+	inventoryTypeFridge       = "fridge"
+	inventoryTypeItem         = "item"
+	inventoryTypeShoppingList = "shoppingList"
+	inventoryTypeMealPlan     = "mealPlan"
+
+	// Waste events and eco-point entries share the Ledger container.
+	ledgerTypeWaste  = "waste"
+	ledgerTypePoints = "points"
+)
+
+// NewCosmos returns repositories backed by the mocc-db Cosmos database.
+func NewCosmos(client *azcosmos.Client) *Repositories {
+	db := &cosmosDB{client: client}
+	return &Repositories{
+		Users:       &cosmosUsers{db},
+		Fridges:     &cosmosFridges{db},
+		Inventory:   &cosmosInventory{db},
+		Recipes:     &cosmosRecipes{db},
+		Posts:       &cosmosPosts{db},
+		History:     &cosmosHistory{db},
+		Leaderboard: &cosmosLeaderboard{db},
+		Waste:       &cosmosWaste{db},
+		Events:      &cosmosEvents{db},
+		Shopping:    &cosmosShopping{db},
+		MealPlans:   &cosmosMealPlans{db},
+		Points:      &cosmosPoints{db},
+	}
+}
+
+type cosmosDB struct {
+	client *azcosmos.Client
+}
+
+func (d *cosmosDB) container(name string) (*azcosmos.ContainerClient, error) {
+	c, err := d.client.NewContainer(CosmosDatabase, name)
+	if err != nil {
+		return nil, fmt.Errorf("new container db=%s container=%s: %w", CosmosDatabase, name, err)
+	}
+	return c, nil
+}
+
+func isCosmosNotFound(err error) bool {
+	var respErr *azcore.ResponseError
+	return errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound
+}
+
+func isCosmosConflict(err error) bool {
+	var respErr *azcore.ResponseError
+	return errors.As(err, &respErr) && respErr.StatusCode == http.StatusConflict
+}
+
+func isCosmosPreconditionFailed(err error) bool {
+	var respErr *azcore.ResponseError
+	return errors.As(err, &respErr) && respErr.StatusCode == http.StatusPreconditionFailed
+}
+
+func readItem[T any](ctx context.Context, c *azcosmos.ContainerClient, pk, id string) (*T, error) {
+	v, _, err := readItemWithETag[T](ctx, c, pk, id)
+	return v, err
+}
+
+func readItemWithETag[T any](ctx context.Context, c *azcosmos.ContainerClient, pk, id string) (*T, string, error) {
+	resp, err := c.ReadItem(ctx, azcosmos.NewPartitionKeyString(pk), id, nil)
+	if err != nil {
+		if isCosmosNotFound(err) {
+			return nil, "", ErrNotFound
+		}
+		return nil, "", err
+	}
+
+	var v T
+	if err := json.Unmarshal(resp.Value, &v); err != nil {
+		return nil, "", err
+	}
+	return &v, string(resp.ETag), nil
+}
+
+// replaceItem writes data only if the stored document still carries etag.
+func replaceItem(ctx context.Context, c *azcosmos.ContainerClient, pk, id string, data []byte, etag string) error {
+	ifMatch := azcore.ETag(etag)
+	_, err := c.ReplaceItem(ctx, azcosmos.NewPartitionKeyString(pk), id, data, &azcosmos.ItemOptions{IfMatchEtag: &ifMatch})
+	return conditionalWriteError(err)
+}
+
+func conditionalWriteError(err error) error {
+	switch {
+	case isCosmosPreconditionFailed(err):
+		return ErrConflict
+	case isCosmosNotFound(err):
+		return ErrNotFound
+	}
+	return err
+}
+
+// queryItems drains the pager and skips documents that do not decode into T.
+func queryItems[T any](ctx context.Context, c *azcosmos.ContainerClient, query string, pk azcosmos.PartitionKey, params ...azcosmos.QueryParameter) ([]*T, error) {
+	qOpts := azcosmos.QueryOptions{QueryParameters: params}
+	pager := c.NewQueryItemsPager(query, pk, &qOpts)
+
+	var out []*T
+	for pager.More() {
+		resp, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range resp.Items {
+			var v T
+			if err := json.Unmarshal(item, &v); err != nil {
+				continue
+			}
+			out = append(out, &v)
+		}
+	}
+	return out, nil
+}
+
+// queryPage returns up to first items following the after cursor, resuming the
+// query from the Cosmos continuation token the cursor carries instead of
+// reading the listing from the start.
+func queryPage[T any](ctx context.Context, c *azcosmos.ContainerClient, query string, pk azcosmos.PartitionKey, first int, after string, params ...azcosmos.QueryParameter) (*Page[T], error) {
+	cursor, err := decodeCosmosCursor(after)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page[T]{}
+	token, skip := cursor.Token, cursor.Skip
+	for len(page.Edges) < first {
+		qOpts := azcosmos.QueryOptions{
+			QueryParameters: params,
+			PageSizeHint:    int32(first - len(page.Edges) + skip),
+		}
+		if token != "" {
+			qOpts.ContinuationToken = &token
+		}
+		resp, err := c.NewQueryItemsPager(query, pk, &qOpts).NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		// The server may return fewer items from token than when the cursor
+		// was made, so what is left of skip carries over to the next page.
+		if skip >= len(resp.Items) {
+			skip -= len(resp.Items)
+		} else {
+			for i := skip; i < len(resp.Items); i++ {
+				if len(page.Edges) == first {
+					page.HasNextPage = true
+					return page, nil
+				}
+				var v T
+				if err := json.Unmarshal(resp.Items[i], &v); err != nil {
+					continue
+				}
+				page.Edges = append(page.Edges, Edge[T]{
+					Node:   &v,
+					Cursor: encodeCosmosCursor(cosmosCursor{Token: token, Skip: i + 1}),
+				})
+			}
+			skip = 0
+		}
+
+		if resp.ContinuationToken == nil || *resp.ContinuationToken == "" {
+			return page, nil
+		}
+		token = *resp.ContinuationToken
+	}
+	page.HasNextPage = true
+	return page, nil
+}
+
+func deleteItem(ctx context.Context, c *azcosmos.ContainerClient, pk, id string) error {
+	_, err := c.DeleteItem(ctx, azcosmos.NewPartitionKeyString(pk), id, nil)
+	if isCosmosNotFound(err) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// withFields marshals v and adds the extra top-level properties Cosmos needs,
+// such as partition keys that are not part of the GraphQL model.
+func withFields(v any, extra map[string]any) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	doc := map[string]any{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	for k, val := range extra {
+		doc[k] = val
+	}
+	return json.Marshal(doc)
+}
+
+type cosmosUsers struct{ db *cosmosDB }
+
+func (r *cosmosUsers) Get(ctx context.Context, id string) (*model.User, error) {
+	c, err := r.db.container(ContainerUsers)
+	if err != nil {
+		return nil, err
+	}
+	return readItem[model.User](ctx, c, id, id)
+}
+
+func (r *cosmosUsers) Upsert(ctx context.Context, user *model.User) error {
+	c, err := r.db.container(ContainerUsers)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(user)
+	if err != nil {
+		return err
+	}
+	_, err = c.UpsertItem(ctx, azcosmos.NewPartitionKeyString(user.ID), data, nil)
+	return err
+}
+
+func (r *cosmosUsers) NicknameExists(ctx context.Context, nickname string) (bool, error) {
+	c, err := r.db.container(ContainerUsers)
+	if err != nil {
+		return false, err
+	}
+	users, err := queryItems[model.User](ctx, c, "SELECT c.id FROM c WHERE c.nickname = @nickname", azcosmos.PartitionKey{},
+		azcosmos.QueryParameter{Name: "@nickname", Value: nickname})
+	if err != nil {
+		return false, err
+	}
+	return len(users) > 0, nil
+}
+
+func (r *cosmosUsers) FindByNickname(ctx context.Context, nickname string) (*model.User, error) {
+	c, err := r.db.container(ContainerUsers)
+	if err != nil {
+		return nil, err
+	}
+	users, err := queryItems[model.User](ctx, c, "SELECT * FROM c WHERE c.nickname = @nickname", azcosmos.PartitionKey{},
+		azcosmos.QueryParameter{Name: "@nickname", Value: nickname})
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, ErrNotFound
+	}
+	return users[0], nil
+}
+
+func (r *cosmosUsers) ListWithEcoPoints(ctx context.Context) ([]*model.User, error) {
+	c, err := r.db.container(ContainerUsers)
+	if err != nil {
+		return nil, err
+	}
+	return queryItems[model.User](ctx, c, "SELECT * FROM c WHERE c.gamification.totalEcoPoints > 0", azcosmos.PartitionKey{})
+}
+
+type cosmosFridges struct{ db *cosmosDB }
+
+func (r *cosmosFridges) ListForUser(ctx context.Context, userID string) ([]*model.Fridge, error) {
+	c, err := r.db.container(ContainerInventory)
+	if err != nil {
+		return nil, err
+	}
+	return queryItems[model.Fridge](ctx, c, "SELECT * FROM c WHERE (c.id = @id or ARRAY_CONTAINS(c.ownerId, @id)) AND (NOT IS_DEFINED(c.type) OR c.type = @type)", azcosmos.PartitionKey{},
+		azcosmos.QueryParameter{Name: "@id", Value: userID},
+		azcosmos.QueryParameter{Name: "@type", Value: inventoryTypeFridge})
+}
+
+func (r *cosmosFridges) Get(ctx context.Context, id string) (*model.Fridge, string, error) {
+	c, err := r.db.container(ContainerInventory)
+	if err != nil {
+		return nil, "", err
+	}
+	return readItemWithETag[model.Fridge](ctx, c, id, id)
+}
+
+func (r *cosmosFridges) Upsert(ctx context.Context, fridge *model.Fridge) error {
+	c, err := r.db.container(ContainerInventory)
+	if err != nil {
+		return err
+	}
+	data, err := fridgeDocument(fridge)
+	if err != nil {
+		return err
+	}
+	_, err = c.UpsertItem(ctx, azcosmos.NewPartitionKeyString(fridge.ID), data, nil)
+	return err
+}
+
+func (r *cosmosFridges) Replace(ctx context.Context, fridge *model.Fridge, etag string) error {
+	c, err := r.db.container(ContainerInventory)
+	if err != nil {
+		return err
+	}
+	data, err := fridgeDocument(fridge)
+	if err != nil {
+		return err
+	}
+	return replaceItem(ctx, c, fridge.ID, fridge.ID, data, etag)
+}
+
+func (r *cosmosFridges) ListWithEmbeddedItems(ctx context.Context) ([]*model.Fridge, error) {
+	c, err := r.db.container(ContainerInventory)
+	if err != nil {
+		return nil, err
+	}
+	return queryItems[model.Fridge](ctx, c, "SELECT * FROM c WHERE IS_DEFINED(c.items) AND ARRAY_LENGTH(c.items) > 0 AND (NOT IS_DEFINED(c.type) OR c.type = @type)", azcosmos.PartitionKey{},
+		azcosmos.QueryParameter{Name: "@type", Value: inventoryTypeFridge})
+}
+
+func (r *cosmosFridges) Delete(ctx context.Context, id string) error {
+	c, err := r.db.container(ContainerInventory)
+	if err != nil {
+		return err
+	}
+	return deleteItem(ctx, c, id, id)
+}
+
+// fridgeDocument never persists items: they live in documents of their own.
+func fridgeDocument(fridge *model.Fridge) ([]byte, error) {
+	doc := *fridge
+	doc.Items = nil
+	return withFields(&doc, map[string]any{"fridgeId": fridge.ID, "type": inventoryTypeFridge})
+}
+
+type cosmosInventory struct{ db *cosmosDB }
+
+func (r *cosmosInventory) ListByFridge(ctx context.Context, fridgeID string) ([]*model.InventoryItem, error) {
+	c, err := r.db.container(ContainerInventory)
+	if err != nil {
+		return nil, err
+	}
+	return queryItems[model.InventoryItem](ctx, c, "SELECT * FROM c WHERE c.type = @type ORDER BY c.addedAt",
+		azcosmos.NewPartitionKeyString(fridgeID), azcosmos.QueryParameter{Name: "@type", Value: inventoryTypeItem})
+}
+
+func (r *cosmosInventory) Get(ctx context.Context, fridgeID, id string) (*model.InventoryItem, string, error) {
+	c, err := r.db.container(ContainerInventory)
+	if err != nil {
+		return nil, "", err
+	}
+	item, etag, err := readItemWithETag[inventoryDocument](ctx, c, fridgeID, id)
+	if err != nil {
+		return nil, "", err
+	}
+	if item.Type != inventoryTypeItem {
+		return nil, "", ErrNotFound
+	}
+	return &item.InventoryItem, etag, nil
+}
+
+func (r *cosmosInventory) Upsert(ctx context.Context, fridgeID string, item *model.InventoryItem) error {
+	c, err := r.db.container(ContainerInventory)
+	if err != nil {
+		return err
+	}
+	data, err := withFields(item, map[string]any{"fridgeId": fridgeID, "type": inventoryTypeItem})
+	if err != nil {
+		return err
+	}
+	_, err = c.UpsertItem(ctx, azcosmos.NewPartitionKeyString(fridgeID), data, nil)
+	return err
+}
+
+func (r *cosmosInventory) Replace(ctx context.Context, fridgeID string, item *model.InventoryItem, etag string) error {
+	c, err := r.db.container(ContainerInventory)
+	if err != nil {
+		return err
+	}
+	data, err := withFields(item, map[string]any{"fridgeId": fridgeID, "type": inventoryTypeItem})
+	if err != nil {
+		return err
+	}
+	return replaceItem(ctx, c, fridgeID, item.ID, data, etag)
+}
+
+func (r *cosmosInventory) Delete(ctx context.Context, fridgeID, id, etag string) error {
+	c, err := r.db.container(ContainerInventory)
+	if err != nil {
+		return err
+	}
+	if etag == "" {
+		return deleteItem(ctx, c, fridgeID, id)
+	}
+	ifMatch := azcore.ETag(etag)
+	_, err = c.DeleteItem(ctx, azcosmos.NewPartitionKeyString(fridgeID), id, &azcosmos.ItemOptions{IfMatchEtag: &ifMatch})
+	return conditionalWriteError(err)
+}
+
+// inventoryDocument lets point reads tell an item apart from the fridge
+// document that shares its partition.
+type inventoryDocument struct {
+	model.InventoryItem
+	Type string `json:"type"`
+}
+
+type cosmosRecipes struct{ db *cosmosDB }
+
+func (r *cosmosRecipes) Get(ctx context.Context, id string) (*model.Recipe, error) {
+	c, err := r.db.container(ContainerCookbook)
+	if err != nil {
+		return nil, err
+	}
+	recipes, err := queryItems[model.Recipe](ctx, c, "SELECT * FROM c WHERE c.id = @id", azcosmos.PartitionKey{},
+		azcosmos.QueryParameter{Name: "@id", Value: id})
+	if err != nil {
+		return nil, err
+	}
+	if len(recipes) == 0 {
+		return nil, ErrNotFound
+	}
+	return recipes[0], nil
+}
+
+func (r *cosmosRecipes) GetWithETag(ctx context.Context, authorID, id string) (*model.Recipe, string, error) {
+	c, err := r.db.container(ContainerCookbook)
+	if err != nil {
+		return nil, "", err
+	}
+	return readItemWithETag[model.Recipe](ctx, c, authorID, id)
+}
+
+func (r *cosmosRecipes) ListByAuthor(ctx context.Context, authorID string, status *model.RecipeStatus) ([]*model.Recipe, error) {
+	c, err := r.db.container(ContainerCookbook)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT * FROM c WHERE c.authorId = @uid"
+	params := []azcosmos.QueryParameter{{Name: "@uid", Value: authorID}}
+	if status != nil {
+		query += " AND c.status = @status"
+		params = append(params, azcosmos.QueryParameter{Name: "@status", Value: status.String()})
+	}
+	return queryItems[model.Recipe](ctx, c, query, azcosmos.NewPartitionKeyString(authorID), params...)
+}
+
+func (r *cosmosRecipes) ListByAuthorPage(ctx context.Context, authorID string, status *model.RecipeStatus, first int, after string) (*Page[model.Recipe], error) {
+	c, err := r.db.container(ContainerCookbook)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT * FROM c WHERE c.authorId = @uid"
+	params := []azcosmos.QueryParameter{{Name: "@uid", Value: authorID}}
+	if status != nil {
+		query += " AND c.status = @status"
+		params = append(params, azcosmos.QueryParameter{Name: "@status", Value: status.String()})
+	}
+	return queryPage[model.Recipe](ctx, c, query, azcosmos.NewPartitionKeyString(authorID), first, after, params...)
+}
+
+func (r *cosmosRecipes) ListByStatus(ctx context.Context, status model.RecipeStatus) ([]*model.Recipe, error) {
+	c, err := r.db.container(ContainerCookbook)
+	if err != nil {
+		return nil, err
+	}
+	return queryItems[model.Recipe](ctx, c, "SELECT * FROM c WHERE c.status = @status", azcosmos.PartitionKey{},
+		azcosmos.QueryParameter{Name: "@status", Value: status.String()})
+}
+
+func (r *cosmosRecipes) Upsert(ctx context.Context, recipe *model.Recipe) error {
+	c, err := r.db.container(ContainerCookbook)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(recipe)
+	if err != nil {
+		return err
+	}
+	_, err = c.UpsertItem(ctx, azcosmos.NewPartitionKeyString(recipe.AuthorID), data, nil)
+	return err
+}
+
+func (r *cosmosRecipes) Replace(ctx context.Context, recipe *model.Recipe, etag string) error {
+	c, err := r.db.container(ContainerCookbook)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(recipe)
+	if err != nil {
+		return err
+	}
+	return replaceItem(ctx, c, recipe.AuthorID, recipe.ID, data, etag)
+}
+
+func (r *cosmosRecipes) Delete(ctx context.Context, authorID, id string) error {
+	c, err := r.db.container(ContainerCookbook)
+	if err != nil {
+		return err
+	}
+	return deleteItem(ctx, c, authorID, id)
+}
+
+type cosmosPosts struct{ db *cosmosDB }
+
+func (r *cosmosPosts) Get(ctx context.Context, id string) (*model.Post, error) {
+	c, err := r.db.container(ContainerSocial)
+	if err != nil {
+		return nil, err
+	}
+	return readItem[model.Post](ctx, c, socialTypePost, id)
+}
+
+func (r *cosmosPosts) List(ctx context.Context) ([]*model.Post, error) {
+	c, err := r.db.container(ContainerSocial)
+	if err != nil {
+		return nil, err
+	}
+	return queryItems[model.Post](ctx, c, "SELECT * FROM c WHERE c.type = 'post' ORDER BY c.createdAt DESC",
+		azcosmos.NewPartitionKeyString(socialTypePost))
+}
+
+func (r *cosmosPosts) ListPage(ctx context.Context, first int, after string) (*Page[model.Post], error) {
+	c, err := r.db.container(ContainerSocial)
+	if err != nil {
+		return nil, err
+	}
+	return queryPage[model.Post](ctx, c, "SELECT * FROM c WHERE c.type = 'post' ORDER BY c.createdAt DESC",
+		azcosmos.NewPartitionKeyString(socialTypePost), first, after)
+}
+
+func (r *cosmosPosts) ListByAuthor(ctx context.Context, authorID string) ([]*model.Post, error) {
+	c, err := r.db.container(ContainerSocial)
+	if err != nil {
+		return nil, err
+	}
+	return queryItems[model.Post](ctx, c, "SELECT * FROM c WHERE c.authorId = @uid",
+		azcosmos.NewPartitionKeyString(socialTypePost), azcosmos.QueryParameter{Name: "@uid", Value: authorID})
+}
+
+func (r *cosmosPosts) ListCommentedBy(ctx context.Context, userID string) ([]*model.Post, error) {
+	c, err := r.db.container(ContainerSocial)
+	if err != nil {
+		return nil, err
+	}
+	return queryItems[model.Post](ctx, c, "SELECT * FROM c WHERE ARRAY_CONTAINS(c.comments, {'userId': @uid}, true) AND c.authorId != @uid",
+		azcosmos.NewPartitionKeyString(socialTypePost), azcosmos.QueryParameter{Name: "@uid", Value: userID})
+}
+
+func (r *cosmosPosts) Upsert(ctx context.Context, post *model.Post) error {
+	c, err := r.db.container(ContainerSocial)
+	if err != nil {
+		return err
+	}
+	data, err := withFields(post, map[string]any{"type": socialTypePost})
+	if err != nil {
+		return err
+	}
+	_, err = c.UpsertItem(ctx, azcosmos.NewPartitionKeyString(socialTypePost), data, nil)
+	return err
+}
+
+func (r *cosmosPosts) Delete(ctx context.Context, id string) error {
+	c, err := r.db.container(ContainerSocial)
+	if err != nil {
+		return err
+	}
+	return deleteItem(ctx, c, socialTypePost, id)
+}
+
+type cosmosHistory struct{ db *cosmosDB }
+
+func (r *cosmosHistory) Get(ctx context.Context, authorID, id string) (*model.ShoppingHistoryEntry, error) {
+	c, err := r.db.container(ContainerHistory)
+	if err != nil {
+		return nil, err
+	}
+	return readItem[model.ShoppingHistoryEntry](ctx, c, authorID, id)
+}
+
+func (r *cosmosHistory) ListByAuthor(ctx context.Context, authorID string) ([]*model.ShoppingHistoryEntry, error) {
+	c, err := r.db.container(ContainerHistory)
+	if err != nil {
+		return nil, err
+	}
+	return queryItems[model.ShoppingHistoryEntry](ctx, c, "SELECT * FROM c WHERE c.authorId = @uid ORDER BY c.date DESC",
+		azcosmos.NewPartitionKeyString(authorID), azcosmos.QueryParameter{Name: "@uid", Value: authorID})
+}
+
+func (r *cosmosHistory) ListByAuthorPage(ctx context.Context, authorID string, first int, after string) (*Page[model.ShoppingHistoryEntry], error) {
+	c, err := r.db.container(ContainerHistory)
+	if err != nil {
+		return nil, err
+	}
+	return queryPage[model.ShoppingHistoryEntry](ctx, c, "SELECT * FROM c WHERE c.authorId = @uid ORDER BY c.date DESC",
+		azcosmos.NewPartitionKeyString(authorID), first, after, azcosmos.QueryParameter{Name: "@uid", Value: authorID})
+}
+
+func (r *cosmosHistory) Upsert(ctx context.Context, entry *model.ShoppingHistoryEntry) error {
+	c, err := r.db.container(ContainerHistory)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = c.UpsertItem(ctx, azcosmos.NewPartitionKeyString(entry.AuthorID), data, nil)
+	return err
+}
+
+func (r *cosmosHistory) Delete(ctx context.Context, authorID, id string) error {
+	c, err := r.db.container(ContainerHistory)
+	if err != nil {
+		return err
+	}
+	return deleteItem(ctx, c, authorID, id)
+}
+
+type cosmosLeaderboard struct{ db *cosmosDB }
+
+func (r *cosmosLeaderboard) Get(ctx context.Context, period, id string) (*LeaderboardRecord, error) {
+	c, err := r.db.container(ContainerLeaderboard)
+	if err != nil {
+		return nil, err
+	}
+	return readItem[LeaderboardRecord](ctx, c, period, id)
+}
+
+func (r *cosmosLeaderboard) Upsert(ctx context.Context, record *LeaderboardRecord) error {
+	c, err := r.db.container(ContainerLeaderboard)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = c.UpsertItem(ctx, azcosmos.NewPartitionKeyString(record.Period), data, nil)
+	return err
+}
+
+func (r *cosmosLeaderboard) GetWithETag(ctx context.Context, period, id string) (*LeaderboardRecord, string, error) {
+	c, err := r.db.container(ContainerLeaderboard)
+	if err != nil {
+		return nil, "", err
+	}
+	return readItemWithETag[LeaderboardRecord](ctx, c, period, id)
+}
+
+func (r *cosmosLeaderboard) Create(ctx context.Context, record *LeaderboardRecord) error {
+	c, err := r.db.container(ContainerLeaderboard)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = c.CreateItem(ctx, azcosmos.NewPartitionKeyString(record.Period), data, nil)
+	if isCosmosConflict(err) {
+		return ErrConflict
+	}
+	return err
+}
+
+func (r *cosmosLeaderboard) Replace(ctx context.Context, record *LeaderboardRecord, etag string) error {
+	c, err := r.db.container(ContainerLeaderboard)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return replaceItem(ctx, c, record.Period, record.ID, data, etag)
+}
+
+func (r *cosmosLeaderboard) ListByPeriod(ctx context.Context, period string) ([]*LeaderboardRecord, error) {
+	c, err := r.db.container(ContainerLeaderboard)
+	if err != nil {
+		return nil, err
+	}
+	records, err := queryItems[LeaderboardRecord](ctx, c, "SELECT * FROM c", azcosmos.NewPartitionKeyString(period))
+	if err != nil {
+		return nil, err
+	}
+	sortLeaderboard(records)
+	return records, nil
+}
+
+// Archives are stored in the partition named after their kind, WEEK or
+// MONTH, which no period of live records uses.
+func (r *cosmosLeaderboard) CreateArchive(ctx context.Context, archive *model.LeaderboardArchive) error {
+	c, err := r.db.container(ContainerLeaderboard)
+	if err != nil {
+		return err
+	}
+	data, err := withFields(archive, map[string]any{"id": archive.Key})
+	if err != nil {
+		return err
+	}
+	_, err = c.CreateItem(ctx, azcosmos.NewPartitionKeyString(string(archive.Period)), data, nil)
+	if isCosmosConflict(err) {
+		return ErrConflict
+	}
+	return err
+}
+
+func (r *cosmosLeaderboard) ListArchives(ctx context.Context, kind model.LeaderboardPeriod, limit int) ([]*model.LeaderboardArchive, error) {
+	c, err := r.db.container(ContainerLeaderboard)
+	if err != nil {
+		return nil, err
+	}
+	return queryItems[model.LeaderboardArchive](ctx, c, "SELECT TOP @limit * FROM c ORDER BY c.key DESC", azcosmos.NewPartitionKeyString(string(kind)),
+		azcosmos.QueryParameter{Name: "@limit", Value: limit})
+}
+
+func sortLeaderboard(records []*LeaderboardRecord) {
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Score > records[j].Score
+	})
+}
+
+type cosmosWaste struct{ db *cosmosDB }
+
+func (r *cosmosWaste) Add(ctx context.Context, userID string, event *model.WasteEvent) error {
+	c, err := r.db.container(ContainerLedger)
+	if err != nil {
+		return err
+	}
+	data, err := withFields(event, map[string]any{"userId": userID, "type": ledgerTypeWaste})
+	if err != nil {
+		return err
+	}
+	_, err = c.CreateItem(ctx, azcosmos.NewPartitionKeyString(userID), data, nil)
+	return err
+}
+
+func (r *cosmosWaste) Remove(ctx context.Context, userID, id string) error {
+	c, err := r.db.container(ContainerLedger)
+	if err != nil {
+		return err
+	}
+	return deleteItem(ctx, c, userID, id)
+}
+
+func (r *cosmosWaste) ListByUser(ctx context.Context, userID, from, to string) ([]*model.WasteEvent, error) {
+	c, err := r.db.container(ContainerLedger)
+	if err != nil {
+		return nil, err
+	}
+	return queryItems[model.WasteEvent](ctx, c,
+		"SELECT * FROM c WHERE c.type = @type AND c.wastedAt >= @from AND c.wastedAt < @to ORDER BY c.wastedAt ASC",
+		azcosmos.NewPartitionKeyString(userID),
+		azcosmos.QueryParameter{Name: "@type", Value: ledgerTypeWaste},
+		azcosmos.QueryParameter{Name: "@from", Value: from},
+		azcosmos.QueryParameter{Name: "@to", Value: to})
+}
+
+type cosmosPoints struct{ db *cosmosDB }
+
+func (r *cosmosPoints) Append(ctx context.Context, userID string, entry *model.PointsEntry) error {
+	c, err := r.db.container(ContainerLedger)
+	if err != nil {
+		return err
+	}
+	data, err := withFields(entry, map[string]any{"userId": userID, "type": ledgerTypePoints})
+	if err != nil {
+		return err
+	}
+	_, err = c.CreateItem(ctx, azcosmos.NewPartitionKeyString(userID), data, nil)
+	if isCosmosConflict(err) {
+		return ErrConflict
+	}
+	return err
+}
+
+func (r *cosmosPoints) ListByUser(ctx context.Context, userID string) ([]*model.PointsEntry, error) {
+	c, err := r.db.container(ContainerLedger)
+	if err != nil {
+		return nil, err
+	}
+	return queryItems[model.PointsEntry](ctx, c,
+		"SELECT * FROM c WHERE c.type = @type ORDER BY c.createdAt ASC",
+		azcosmos.NewPartitionKeyString(userID),
+		azcosmos.QueryParameter{Name: "@type", Value: ledgerTypePoints})
+}
+
+func (r *cosmosPoints) ListByUserPage(ctx context.Context, userID string, first int, after string) (*Page[model.PointsEntry], error) {
+	c, err := r.db.container(ContainerLedger)
+	if err != nil {
+		return nil, err
+	}
+	return queryPage[model.PointsEntry](ctx, c,
+		"SELECT * FROM c WHERE c.type = @type ORDER BY c.createdAt DESC",
+		azcosmos.NewPartitionKeyString(userID), first, after,
+		azcosmos.QueryParameter{Name: "@type", Value: ledgerTypePoints})
+}
+
+// ListUserIDs reads the owner of every entry across partitions, where the
+// SDK cannot run DISTINCT, and dedupes them here.
+func (r *cosmosPoints) ListUserIDs(ctx context.Context) ([]string, error) {
+	c, err := r.db.container(ContainerLedger)
+	if err != nil {
+		return nil, err
+	}
+	type owner struct {
+		UserID string `json:"userId"`
+	}
+	owners, err := queryItems[owner](ctx, c, "SELECT c.userId FROM c WHERE c.type = @type", azcosmos.PartitionKey{},
+		azcosmos.QueryParameter{Name: "@type", Value: ledgerTypePoints})
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	ids := []string{}
+	for _, o := range owners {
+		if o.UserID != "" && !seen[o.UserID] {
+			seen[o.UserID] = true
+			ids = append(ids, o.UserID)
+		}
+	}
+	return ids, nil
+}
+
+type cosmosEvents struct{ db *cosmosDB }
+
+func (r *cosmosEvents) Append(ctx context.Context, event *model.InventoryEvent) error {
+	c, err := r.db.container(ContainerInventoryLog)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = c.CreateItem(ctx, azcosmos.NewPartitionKeyString(event.FridgeID), data, nil)
+	return err
+}
+
+func (r *cosmosEvents) ListByFridgePage(ctx context.Context, fridgeID string, first int, after string) (*Page[model.InventoryEvent], error) {
+	c, err := r.db.container(ContainerInventoryLog)
+	if err != nil {
+		return nil, err
+	}
+	return queryPage[model.InventoryEvent](ctx, c, "SELECT * FROM c ORDER BY c.occurredAt DESC",
+		azcosmos.NewPartitionKeyString(fridgeID), first, after)
+}
+
+// ListByItemPage spans partitions, where the SDK cannot order results, so the
+// history of the item is sorted and paged here. One item's history is short.
+func (r *cosmosEvents) ListByItemPage(ctx context.Context, fridgeIDs []string, itemID string, first int, after string) (*Page[model.InventoryEvent], error) {
+	c, err := r.db.container(ContainerInventoryLog)
+	if err != nil {
+		return nil, err
+	}
+	events, err := queryItems[model.InventoryEvent](ctx, c, "SELECT * FROM c WHERE c.itemId = @itemId AND ARRAY_CONTAINS(@fridgeIds, c.fridgeId)", azcosmos.PartitionKey{},
+		azcosmos.QueryParameter{Name: "@itemId", Value: itemID},
+		azcosmos.QueryParameter{Name: "@fridgeIds", Value: fridgeIDs})
+	if err != nil {
+		return nil, err
+	}
+	return memPage(sortEvents(events), first, after)
+}
+
+// cosmosShopping keeps each shopping list in the Inventory container, in the
+// partition of its fridge.
+type cosmosShopping struct{ db *cosmosDB }
+
+func (r *cosmosShopping) Get(ctx context.Context, fridgeID string) (*model.ShoppingList, string, error) {
+	c, err := r.db.container(ContainerInventory)
+	if err != nil {
+		return nil, "", err
+	}
+	return readItemWithETag[model.ShoppingList](ctx, c, fridgeID, ShoppingListID(fridgeID))
+}
+
+func (r *cosmosShopping) Create(ctx context.Context, list *model.ShoppingList) error {
+	c, err := r.db.container(ContainerInventory)
+	if err != nil {
+		return err
+	}
+	data, err := withFields(list, map[string]any{"type": inventoryTypeShoppingList})
+	if err != nil {
+		return err
+	}
+	_, err = c.CreateItem(ctx, azcosmos.NewPartitionKeyString(list.FridgeID), data, nil)
+	if isCosmosConflict(err) {
+		return ErrConflict
+	}
+	return err
+}
+
+func (r *cosmosShopping) Replace(ctx context.Context, list *model.ShoppingList, etag string) error {
+	c, err := r.db.container(ContainerInventory)
+	if err != nil {
+		return err
+	}
+	data, err := withFields(list, map[string]any{"type": inventoryTypeShoppingList})
+	if err != nil {
+		return err
+	}
+	return replaceItem(ctx, c, list.FridgeID, list.ID, data, etag)
+}
+
+func (r *cosmosShopping) Delete(ctx context.Context, fridgeID string) error {
+	c, err := r.db.container(ContainerInventory)
+	if err != nil {
+		return err
+	}
+	return deleteItem(ctx, c, fridgeID, ShoppingListID(fridgeID))
+}
+
+// cosmosMealPlans keeps the weekly plans in the Inventory container, in the
+// partition of their fridge.
+type cosmosMealPlans struct{ db *cosmosDB }
+
+func (r *cosmosMealPlans) Get(ctx context.Context, fridgeID, weekStart string) (*model.MealPlan, string, error) {
+	c, err := r.db.container(ContainerInventory)
+	if err != nil {
+		return nil, "", err
+	}
+	return readItemWithETag[model.MealPlan](ctx, c, fridgeID, MealPlanID(fridgeID, weekStart))
+}
+
+func (r *cosmosMealPlans) Create(ctx context.Context, plan *model.MealPlan) error {
+	c, err := r.db.container(ContainerInventory)
+	if err != nil {
+		return err
+	}
+	data, err := withFields(plan, map[string]any{"type": inventoryTypeMealPlan})
+	if err != nil {
+		return err
+	}
+	_, err = c.CreateItem(ctx, azcosmos.NewPartitionKeyString(plan.FridgeID), data, nil)
+	if isCosmosConflict(err) {
+		return ErrConflict
+	}
+	return err
+}
+
+func (r *cosmosMealPlans) Replace(ctx context.Context, plan *model.MealPlan, etag string) error {
+	c, err := r.db.container(ContainerInventory)
+	if err != nil {
+		return err
+	}
+	data, err := withFields(plan, map[string]any{"type": inventoryTypeMealPlan})
+	if err != nil {
+		return err
+	}
+	return replaceItem(ctx, c, plan.FridgeID, plan.ID, data, etag)
+}
+
+func (r *cosmosMealPlans) ListLapsed(ctx context.Context, day string) ([]*model.MealPlan, error) {
+	c, err := r.db.container(ContainerInventory)
+	if err != nil {
+		return nil, err
+	}
+	return queryItems[model.MealPlan](ctx, c,
+		"SELECT * FROM c WHERE c.type = @type AND EXISTS(SELECT VALUE s FROM s IN c.slots WHERE s.reserved = true AND s.date < @day)",
+		azcosmos.PartitionKey{},
+		azcosmos.QueryParameter{Name: "@type", Value: inventoryTypeMealPlan},
+		azcosmos.QueryParameter{Name: "@day", Value: day},
+	)
+}
+
+func (r *cosmosMealPlans) DeleteByFridge(ctx context.Context, fridgeID string) error {
+	c, err := r.db.container(ContainerInventory)
+	if err != nil {
+		return err
+	}
+	plans, err := queryItems[model.MealPlan](ctx, c, "SELECT * FROM c WHERE c.type = @type",
+		azcosmos.NewPartitionKeyString(fridgeID),
+		azcosmos.QueryParameter{Name: "@type", Value: inventoryTypeMealPlan},
+	)
+	if err != nil {
+		return err
+	}
+	for _, plan := range plans {
+		if err := deleteItem(ctx, c, fridgeID, plan.ID); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
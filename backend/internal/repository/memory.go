@@ -0,0 +1,561 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/mariocosenza/mocc/graph/model"
+)
+
+// NewMemory returns repositories that keep every document in process memory.
+// Documents are stored as JSON, so callers never share pointers with the store,
+// mirroring what they get from Cosmos. Intended for local runs and tests.
+func NewMemory() *Repositories {
+	return &Repositories{
+		Users:       &memUsers{newMemCollection()},
+		Fridges:     &memFridges{newMemCollection()},
+		Inventory:   &memInventory{newMemCollection()},
+		Recipes:     &memRecipes{newMemCollection()},
+		Posts:       &memPosts{newMemCollection()},
+		History:     &memHistory{newMemCollection()},
+		Leaderboard: &memLeaderboard{newMemCollection()},
+		Waste:       &memWaste{newMemCollection()},
+		Events:      &memEvents{newMemCollection()},
+		Shopping:    &memShopping{newMemCollection()},
+		MealPlans:   &memMealPlans{newMemCollection()},
+		Points:      &memPoints{newMemCollection()},
+	}
+}
+
+// memCollection is a partitioned JSON document set, the in-memory counterpart
+// of a Cosmos container.
+type memCollection struct {
+	mu    sync.RWMutex
+	docs  map[string]map[string][]byte // partition key -> id -> document
+	etags map[string]string            // partition key + "/" + id -> etag
+}
+
+func newMemCollection() *memCollection {
+	return &memCollection{
+		docs:  map[string]map[string][]byte{},
+		etags: map[string]string{},
+	}
+}
+
+func (c *memCollection) put(pk, id string, v any) error {
+	return c.putIfMatch(pk, id, v, "")
+}
+
+// putIfMatch stores v, refusing with ErrConflict when etag is set and no
+// longer matches the stored document.
+func (c *memCollection) putIfMatch(pk, id string, v any, etag string) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := pk + "/" + id
+	if etag != "" {
+		current, ok := c.etags[key]
+		if !ok {
+			return ErrNotFound
+		}
+		if current != etag {
+			return ErrConflict
+		}
+	}
+	if c.docs[pk] == nil {
+		c.docs[pk] = map[string][]byte{}
+	}
+	c.docs[pk][id] = data
+	c.etags[key] = uuid.New().String()
+	return nil
+}
+
+// create stores v unless a document with the same id exists.
+func (c *memCollection) create(pk, id string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.docs[pk][id]; ok {
+		return ErrConflict
+	}
+	if c.docs[pk] == nil {
+		c.docs[pk] = map[string][]byte{}
+	}
+	c.docs[pk][id] = data
+	c.etags[pk+"/"+id] = uuid.New().String()
+	return nil
+}
+
+func (c *memCollection) delete(pk, id string) error {
+	return c.deleteIfMatch(pk, id, "")
+}
+
+func (c *memCollection) deleteIfMatch(pk, id, etag string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.docs[pk][id]; !ok {
+		return ErrNotFound
+	}
+	if etag != "" && c.etags[pk+"/"+id] != etag {
+		return ErrConflict
+	}
+	delete(c.docs[pk], id)
+	delete(c.etags, pk+"/"+id)
+	return nil
+}
+
+func memGet[T any](c *memCollection, pk, id string) (*T, error) {
+	v, _, err := memGetWithETag[T](c, pk, id)
+	return v, err
+}
+
+func memGetWithETag[T any](c *memCollection, pk, id string) (*T, string, error) {
+	c.mu.RLock()
+	data, ok := c.docs[pk][id]
+	etag := c.etags[pk+"/"+id]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, "", ErrNotFound
+	}
+
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, "", err
+	}
+	return &v, etag, nil
+}
+
+// memList decodes the documents of one partition, or of every partition when
+// pk is empty, keeping those accepted by keep (nil keeps everything).
+func memList[T any](c *memCollection, pk string, keep func(*T) bool) []*T {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var out []*T
+	for part, docs := range c.docs {
+		if pk != "" && part != pk {
+			continue
+		}
+		for _, data := range docs {
+			var v T
+			if err := json.Unmarshal(data, &v); err != nil {
+				continue
+			}
+			if keep == nil || keep(&v) {
+				out = append(out, &v)
+			}
+		}
+	}
+	return out
+}
+
+type memUsers struct{ c *memCollection }
+
+func (r *memUsers) Get(_ context.Context, id string) (*model.User, error) {
+	return memGet[model.User](r.c, id, id)
+}
+
+func (r *memUsers) Upsert(_ context.Context, user *model.User) error {
+	return r.c.put(user.ID, user.ID, user)
+}
+
+func (r *memUsers) NicknameExists(_ context.Context, nickname string) (bool, error) {
+	users := memList(r.c, "", func(u *model.User) bool { return u.Nickname == nickname })
+	return len(users) > 0, nil
+}
+
+func (r *memUsers) FindByNickname(_ context.Context, nickname string) (*model.User, error) {
+	users := memList(r.c, "", func(u *model.User) bool { return u.Nickname == nickname })
+	if len(users) == 0 {
+		return nil, ErrNotFound
+	}
+	return users[0], nil
+}
+
+func (r *memUsers) ListWithEcoPoints(_ context.Context) ([]*model.User, error) {
+	return memList(r.c, "", func(u *model.User) bool {
+		return u.Gamification != nil && u.Gamification.TotalEcoPoints > 0
+	}), nil
+}
+
+type memFridges struct{ c *memCollection }
+
+func (r *memFridges) ListForUser(_ context.Context, userID string) ([]*model.Fridge, error) {
+	fridges := memList(r.c, "", func(f *model.Fridge) bool {
+		if f.ID == userID {
+			return true
+		}
+		for _, owner := range f.OwnerID {
+			if owner == userID {
+				return true
+			}
+		}
+		return false
+	})
+	sort.Slice(fridges, func(i, j int) bool { return fridges[i].ID < fridges[j].ID })
+	return fridges, nil
+}
+
+func (r *memFridges) Get(_ context.Context, id string) (*model.Fridge, string, error) {
+	return memGetWithETag[model.Fridge](r.c, id, id)
+}
+
+func (r *memFridges) Upsert(_ context.Context, fridge *model.Fridge) error {
+	doc := *fridge
+	doc.Items = nil
+	return r.c.put(fridge.ID, fridge.ID, &doc)
+}
+
+func (r *memFridges) Replace(_ context.Context, fridge *model.Fridge, etag string) error {
+	if etag == "" {
+		return ErrConflict
+	}
+	doc := *fridge
+	doc.Items = nil
+	return r.c.putIfMatch(fridge.ID, fridge.ID, &doc, etag)
+}
+
+func (r *memFridges) ListWithEmbeddedItems(_ context.Context) ([]*model.Fridge, error) {
+	return memList(r.c, "", func(f *model.Fridge) bool { return len(f.Items) > 0 }), nil
+}
+
+func (r *memFridges) Delete(_ context.Context, id string) error {
+	return r.c.delete(id, id)
+}
+
+type memInventory struct{ c *memCollection }
+
+func (r *memInventory) ListByFridge(_ context.Context, fridgeID string) ([]*model.InventoryItem, error) {
+	items := memList[model.InventoryItem](r.c, fridgeID, nil)
+	sort.Slice(items, func(i, j int) bool { return items[i].AddedAt < items[j].AddedAt })
+	return items, nil
+}
+
+func (r *memInventory) Get(_ context.Context, fridgeID, id string) (*model.InventoryItem, string, error) {
+	return memGetWithETag[model.InventoryItem](r.c, fridgeID, id)
+}
+
+func (r *memInventory) Upsert(_ context.Context, fridgeID string, item *model.InventoryItem) error {
+	return r.c.put(fridgeID, item.ID, item)
+}
+
+func (r *memInventory) Replace(_ context.Context, fridgeID string, item *model.InventoryItem, etag string) error {
+	if etag == "" {
+		return ErrConflict
+	}
+	return r.c.putIfMatch(fridgeID, item.ID, item, etag)
+}
+
+func (r *memInventory) Delete(_ context.Context, fridgeID, id, etag string) error {
+	return r.c.deleteIfMatch(fridgeID, id, etag)
+}
+
+type memRecipes struct{ c *memCollection }
+
+func (r *memRecipes) Get(_ context.Context, id string) (*model.Recipe, error) {
+	recipes := memList(r.c, "", func(rec *model.Recipe) bool { return rec.ID == id })
+	if len(recipes) == 0 {
+		return nil, ErrNotFound
+	}
+	return recipes[0], nil
+}
+
+func (r *memRecipes) GetWithETag(_ context.Context, authorID, id string) (*model.Recipe, string, error) {
+	return memGetWithETag[model.Recipe](r.c, authorID, id)
+}
+
+func (r *memRecipes) ListByAuthor(_ context.Context, authorID string, status *model.RecipeStatus) ([]*model.Recipe, error) {
+	recipes := memList(r.c, authorID, func(rec *model.Recipe) bool {
+		return status == nil || rec.Status == *status
+	})
+	sort.Slice(recipes, func(i, j int) bool { return recipes[i].ID < recipes[j].ID })
+	return recipes, nil
+}
+
+func (r *memRecipes) ListByAuthorPage(ctx context.Context, authorID string, status *model.RecipeStatus, first int, after string) (*Page[model.Recipe], error) {
+	recipes, _ := r.ListByAuthor(ctx, authorID, status)
+	return memPage(recipes, first, after)
+}
+
+func (r *memRecipes) ListByStatus(_ context.Context, status model.RecipeStatus) ([]*model.Recipe, error) {
+	return memList(r.c, "", func(rec *model.Recipe) bool { return rec.Status == status }), nil
+}
+
+func (r *memRecipes) Upsert(_ context.Context, recipe *model.Recipe) error {
+	return r.c.put(recipe.AuthorID, recipe.ID, recipe)
+}
+
+func (r *memRecipes) Replace(_ context.Context, recipe *model.Recipe, etag string) error {
+	return r.c.putIfMatch(recipe.AuthorID, recipe.ID, recipe, etag)
+}
+
+func (r *memRecipes) Delete(_ context.Context, authorID, id string) error {
+	return r.c.delete(authorID, id)
+}
+
+type memPosts struct{ c *memCollection }
+
+func (r *memPosts) Get(_ context.Context, id string) (*model.Post, error) {
+	return memGet[model.Post](r.c, socialTypePost, id)
+}
+
+func (r *memPosts) List(_ context.Context) ([]*model.Post, error) {
+	return sortPosts(memList[model.Post](r.c, socialTypePost, nil)), nil
+}
+
+func (r *memPosts) ListPage(ctx context.Context, first int, after string) (*Page[model.Post], error) {
+	posts, _ := r.List(ctx)
+	return memPage(posts, first, after)
+}
+
+func (r *memPosts) ListByAuthor(_ context.Context, authorID string) ([]*model.Post, error) {
+	return sortPosts(memList(r.c, socialTypePost, func(p *model.Post) bool { return p.AuthorID == authorID })), nil
+}
+
+func (r *memPosts) ListCommentedBy(_ context.Context, userID string) ([]*model.Post, error) {
+	return sortPosts(memList(r.c, socialTypePost, func(p *model.Post) bool {
+		if p.AuthorID == userID {
+			return false
+		}
+		for _, c := range p.Comments {
+			if c.UserID == userID {
+				return true
+			}
+		}
+		return false
+	})), nil
+}
+
+func (r *memPosts) Upsert(_ context.Context, post *model.Post) error {
+	return r.c.put(socialTypePost, post.ID, post)
+}
+
+func (r *memPosts) Delete(_ context.Context, id string) error {
+	return r.c.delete(socialTypePost, id)
+}
+
+func sortPosts(posts []*model.Post) []*model.Post {
+	sort.Slice(posts, func(i, j int) bool { return posts[i].CreatedAt > posts[j].CreatedAt })
+	return posts
+}
+
+type memHistory struct{ c *memCollection }
+
+func (r *memHistory) Get(_ context.Context, authorID, id string) (*model.ShoppingHistoryEntry, error) {
+	return memGet[model.ShoppingHistoryEntry](r.c, authorID, id)
+}
+
+func (r *memHistory) ListByAuthor(_ context.Context, authorID string) ([]*model.ShoppingHistoryEntry, error) {
+	entries := memList[model.ShoppingHistoryEntry](r.c, authorID, nil)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Date > entries[j].Date })
+	return entries, nil
+}
+
+func (r *memHistory) ListByAuthorPage(ctx context.Context, authorID string, first int, after string) (*Page[model.ShoppingHistoryEntry], error) {
+	entries, _ := r.ListByAuthor(ctx, authorID)
+	return memPage(entries, first, after)
+}
+
+func (r *memHistory) Upsert(_ context.Context, entry *model.ShoppingHistoryEntry) error {
+	return r.c.put(entry.AuthorID, entry.ID, entry)
+}
+
+func (r *memHistory) Delete(_ context.Context, authorID, id string) error {
+	return r.c.delete(authorID, id)
+}
+
+type memLeaderboard struct{ c *memCollection }
+
+func (r *memLeaderboard) Get(_ context.Context, period, id string) (*LeaderboardRecord, error) {
+	return memGet[LeaderboardRecord](r.c, period, id)
+}
+
+func (r *memLeaderboard) Upsert(_ context.Context, record *LeaderboardRecord) error {
+	return r.c.put(record.Period, record.ID, record)
+}
+
+func (r *memLeaderboard) GetWithETag(_ context.Context, period, id string) (*LeaderboardRecord, string, error) {
+	return memGetWithETag[LeaderboardRecord](r.c, period, id)
+}
+
+func (r *memLeaderboard) Create(_ context.Context, record *LeaderboardRecord) error {
+	return r.c.create(record.Period, record.ID, record)
+}
+
+func (r *memLeaderboard) Replace(_ context.Context, record *LeaderboardRecord, etag string) error {
+	return r.c.putIfMatch(record.Period, record.ID, record, etag)
+}
+
+func (r *memLeaderboard) ListByPeriod(_ context.Context, period string) ([]*LeaderboardRecord, error) {
+	records := memList[LeaderboardRecord](r.c, period, nil)
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+	sortLeaderboard(records)
+	return records, nil
+}
+
+// Archives live in a partition named after their kind, next to the records.
+func (r *memLeaderboard) CreateArchive(_ context.Context, archive *model.LeaderboardArchive) error {
+	return r.c.create(string(archive.Period), archive.Key, archive)
+}
+
+func (r *memLeaderboard) ListArchives(_ context.Context, kind model.LeaderboardPeriod, limit int) ([]*model.LeaderboardArchive, error) {
+	archives := memList[model.LeaderboardArchive](r.c, string(kind), nil)
+	sort.Slice(archives, func(i, j int) bool { return archives[i].Key > archives[j].Key })
+	if len(archives) > limit {
+		archives = archives[:limit]
+	}
+	return archives, nil
+}
+
+type memWaste struct{ c *memCollection }
+
+func (r *memWaste) Add(_ context.Context, userID string, event *model.WasteEvent) error {
+	return r.c.put(userID, event.ID, event)
+}
+
+func (r *memWaste) Remove(_ context.Context, userID, id string) error {
+	return r.c.delete(userID, id)
+}
+
+func (r *memWaste) ListByUser(_ context.Context, userID, from, to string) ([]*model.WasteEvent, error) {
+	events := memList(r.c, userID, func(e *model.WasteEvent) bool {
+		return e.WastedAt >= from && e.WastedAt < to
+	})
+	sort.Slice(events, func(i, j int) bool { return events[i].WastedAt < events[j].WastedAt })
+	return events, nil
+}
+
+type memPoints struct{ c *memCollection }
+
+func (r *memPoints) Append(_ context.Context, userID string, entry *model.PointsEntry) error {
+	return r.c.create(userID, entry.ID, entry)
+}
+
+func (r *memPoints) ListByUser(_ context.Context, userID string) ([]*model.PointsEntry, error) {
+	entries := memList[model.PointsEntry](r.c, userID, nil)
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].CreatedAt != entries[j].CreatedAt {
+			return entries[i].CreatedAt < entries[j].CreatedAt
+		}
+		return entries[i].ID < entries[j].ID
+	})
+	return entries, nil
+}
+
+func (r *memPoints) ListByUserPage(ctx context.Context, userID string, first int, after string) (*Page[model.PointsEntry], error) {
+	entries, _ := r.ListByUser(ctx, userID)
+	slices.Reverse(entries)
+	return memPage(entries, first, after)
+}
+
+func (r *memPoints) ListUserIDs(_ context.Context) ([]string, error) {
+	r.c.mu.RLock()
+	defer r.c.mu.RUnlock()
+	ids := make([]string, 0, len(r.c.docs))
+	for userID, docs := range r.c.docs {
+		if len(docs) > 0 {
+			ids = append(ids, userID)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+type memEvents struct{ c *memCollection }
+
+func (r *memEvents) Append(_ context.Context, event *model.InventoryEvent) error {
+	return r.c.put(event.FridgeID, event.ID, event)
+}
+
+func (r *memEvents) ListByFridgePage(_ context.Context, fridgeID string, first int, after string) (*Page[model.InventoryEvent], error) {
+	return memPage(sortEvents(memList[model.InventoryEvent](r.c, fridgeID, nil)), first, after)
+}
+
+func (r *memEvents) ListByItemPage(_ context.Context, fridgeIDs []string, itemID string, first int, after string) (*Page[model.InventoryEvent], error) {
+	events := memList(r.c, "", func(e *model.InventoryEvent) bool {
+		return e.ItemID == itemID && slices.Contains(fridgeIDs, e.FridgeID)
+	})
+	return memPage(sortEvents(events), first, after)
+}
+
+// sortEvents orders events newest first, the order of every event listing.
+func sortEvents(events []*model.InventoryEvent) []*model.InventoryEvent {
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].OccurredAt != events[j].OccurredAt {
+			return events[i].OccurredAt > events[j].OccurredAt
+		}
+		return events[i].ID < events[j].ID
+	})
+	return events
+}
+
+type memShopping struct{ c *memCollection }
+
+func (r *memShopping) Get(_ context.Context, fridgeID string) (*model.ShoppingList, string, error) {
+	return memGetWithETag[model.ShoppingList](r.c, fridgeID, ShoppingListID(fridgeID))
+}
+
+func (r *memShopping) Create(_ context.Context, list *model.ShoppingList) error {
+	return r.c.create(list.FridgeID, list.ID, list)
+}
+
+func (r *memShopping) Replace(_ context.Context, list *model.ShoppingList, etag string) error {
+	if etag == "" {
+		return ErrConflict
+	}
+	return r.c.putIfMatch(list.FridgeID, list.ID, list, etag)
+}
+
+func (r *memShopping) Delete(_ context.Context, fridgeID string) error {
+	return r.c.delete(fridgeID, ShoppingListID(fridgeID))
+}
+
+type memMealPlans struct{ c *memCollection }
+
+func (r *memMealPlans) Get(_ context.Context, fridgeID, weekStart string) (*model.MealPlan, string, error) {
+	return memGetWithETag[model.MealPlan](r.c, fridgeID, MealPlanID(fridgeID, weekStart))
+}
+
+func (r *memMealPlans) Create(_ context.Context, plan *model.MealPlan) error {
+	return r.c.create(plan.FridgeID, plan.ID, plan)
+}
+
+func (r *memMealPlans) Replace(_ context.Context, plan *model.MealPlan, etag string) error {
+	if etag == "" {
+		return ErrConflict
+	}
+	return r.c.putIfMatch(plan.FridgeID, plan.ID, plan, etag)
+}
+
+func (r *memMealPlans) ListLapsed(_ context.Context, day string) ([]*model.MealPlan, error) {
+	return memList(r.c, "", func(p *model.MealPlan) bool {
+		for _, slot := range p.Slots {
+			if slot.Reserved && slot.Date < day {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+func (r *memMealPlans) DeleteByFridge(_ context.Context, fridgeID string) error {
+	for _, plan := range memList[model.MealPlan](r.c, fridgeID, nil) {
+		if err := r.c.delete(fridgeID, plan.ID); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mariocosenza/mocc/graph/model"
+)
+
+func TestMemoryFridgeReplaceRefusesStaleETag(t *testing.T) {
+	ctx := context.Background()
+	repos := NewMemory()
+
+	fridge := &model.Fridge{ID: "u1", Name: "Home", Kind: model.StorageKindFridge, OwnerID: []string{"u1"}}
+	if err := repos.Fridges.Upsert(ctx, fridge); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	stored, etag, err := repos.Fridges.Get(ctx, "u1")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+
+	stored.Name = "Kitchen"
+	if err := repos.Fridges.Replace(ctx, stored, etag); err != nil {
+		t.Fatalf("replace with current etag: %v", err)
+	}
+	stored.Name = "Garage"
+	if err := repos.Fridges.Replace(ctx, stored, etag); !errors.Is(err, ErrConflict) {
+		t.Fatalf("replace with stale etag: got %v, want ErrConflict", err)
+	}
+
+	got, _, _ := repos.Fridges.Get(ctx, "u1")
+	if got.Name != "Kitchen" {
+		t.Fatalf("name = %q, want Kitchen", got.Name)
+	}
+	fridges, err := repos.Fridges.ListForUser(ctx, "u1")
+	if err != nil || len(fridges) != 1 {
+		t.Fatalf("list for user: %d fridges, err %v", len(fridges), err)
+	}
+}
+
+func TestMemoryInventoryIsolatesCallers(t *testing.T) {
+	ctx := context.Background()
+	repos := NewMemory()
+
+	item := &model.InventoryItem{ID: "i1", Name: "Milk", Status: model.ItemStatusAvailable, ExpiryType: model.ExpiryTypeExpiration}
+	if err := repos.Inventory.Upsert(ctx, "f1", item); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	item.Name = "changed after write"
+
+	got, etag, err := repos.Inventory.Get(ctx, "f1", "i1")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.Name != "Milk" {
+		t.Fatalf("store shares the caller's pointer, name = %q", got.Name)
+	}
+	if _, _, err := repos.Inventory.Get(ctx, "f2", "i1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("get from another fridge: got %v, want ErrNotFound", err)
+	}
+	if err := repos.Inventory.Delete(ctx, "f1", "i1", etag); err != nil {
+		t.Fatalf("conditional delete: %v", err)
+	}
+	items, _ := repos.Inventory.ListByFridge(ctx, "f1")
+	if len(items) != 0 {
+		t.Fatalf("%d items left after delete", len(items))
+	}
+}
+
+func TestMemoryPointsLedgerIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	repos := NewMemory()
+
+	entry := &model.PointsEntry{ID: "recipe_cooked:r1", Points: 10, Reason: model.PointsReasonRecipeCooked}
+	if err := repos.Points.Append(ctx, "u1", entry); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if err := repos.Points.Append(ctx, "u1", entry); !errors.Is(err, ErrConflict) {
+		t.Fatalf("second append: got %v, want ErrConflict", err)
+	}
+	entries, _ := repos.Points.ListByUser(ctx, "u1")
+	if len(entries) != 1 {
+		t.Fatalf("%d entries, want 1", len(entries))
+	}
+}
+
+func TestMemoryPostsPageThroughFeed(t *testing.T) {
+	ctx := context.Background()
+	repos := NewMemory()
+
+	for i := range 5 {
+		post := &model.Post{ID: fmt.Sprintf("p%d", i), CreatedAt: fmt.Sprintf("2026-01-0%dT00:00:00Z", i+1)}
+		if err := repos.Posts.Upsert(ctx, post); err != nil {
+			t.Fatalf("upsert: %v", err)
+		}
+	}
+
+	var seen []string
+	after := ""
+	for {
+		page, err := repos.Posts.ListPage(ctx, 2, after)
+		if err != nil {
+			t.Fatalf("list page after %q: %v", after, err)
+		}
+		for _, edge := range page.Edges {
+			seen = append(seen, edge.Node.ID)
+		}
+		if !page.HasNextPage {
+			break
+		}
+		after = *page.EndCursor()
+	}
+
+	want := []string{"p4", "p3", "p2", "p1", "p0"}
+	if fmt.Sprint(seen) != fmt.Sprint(want) {
+		t.Fatalf("feed order %v, want %v", seen, want)
+	}
+	if _, err := repos.Posts.ListPage(ctx, 2, "not a cursor"); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("bad cursor: got %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestMemoryRecipesReplaceWithinAuthorPartition(t *testing.T) {
+	ctx := context.Background()
+	repos := NewMemory()
+
+	recipe := &model.Recipe{ID: "r1", AuthorID: "u1", Title: "Soup", Status: model.RecipeStatusInPreparation}
+	if err := repos.Recipes.Upsert(ctx, recipe); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if _, _, err := repos.Recipes.GetWithETag(ctx, "u2", "r1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("get from another author: got %v, want ErrNotFound", err)
+	}
+	stored, etag, err := repos.Recipes.GetWithETag(ctx, "u1", "r1")
+	if err != nil {
+		t.Fatalf("get with etag: %v", err)
+	}
+
+	stored.Status = model.RecipeStatusSaved
+	if err := repos.Recipes.Replace(ctx, stored, etag); err != nil {
+		t.Fatalf("replace with current etag: %v", err)
+	}
+	if err := repos.Recipes.Replace(ctx, stored, etag); !errors.Is(err, ErrConflict) {
+		t.Fatalf("replace with stale etag: got %v, want ErrConflict", err)
+	}
+
+	got, err := repos.Recipes.Get(ctx, "r1")
+	if err != nil || got.Status != model.RecipeStatusSaved {
+		t.Fatalf("get across partitions: %+v, err %v", got, err)
+	}
+	preparing, _ := repos.Recipes.ListByStatus(ctx, model.RecipeStatusInPreparation)
+	if len(preparing) != 0 {
+		t.Fatalf("%d recipes still in preparation", len(preparing))
+	}
+}
+
+func TestMemoryPlansAndListsAreCreatedOnce(t *testing.T) {
+	ctx := context.Background()
+	repos := NewMemory()
+
+	list := &model.ShoppingList{ID: ShoppingListID("f1"), FridgeID: "f1"}
+	if err := repos.Shopping.Create(ctx, list); err != nil {
+		t.Fatalf("create list: %v", err)
+	}
+	if err := repos.Shopping.Create(ctx, list); !errors.Is(err, ErrConflict) {
+		t.Fatalf("second list: got %v, want ErrConflict", err)
+	}
+	if err := repos.Shopping.Replace(ctx, list, ""); !errors.Is(err, ErrConflict) {
+		t.Fatalf("replace without etag: got %v, want ErrConflict", err)
+	}
+
+	plan := &model.MealPlan{
+		ID:        MealPlanID("f1", "2026-03-02"),
+		FridgeID:  "f1",
+		WeekStart: "2026-03-02",
+		Slots: []*model.MealSlot{
+			{ID: "s1", Date: "2026-03-03", MealType: model.MealTypeLunch, RecipeID: "r1", Portions: 2, Reserved: true},
+		},
+	}
+	if err := repos.MealPlans.Create(ctx, plan); err != nil {
+		t.Fatalf("create plan: %v", err)
+	}
+	if err := repos.MealPlans.Create(ctx, plan); !errors.Is(err, ErrConflict) {
+		t.Fatalf("second plan: got %v, want ErrConflict", err)
+	}
+	if lapsed, _ := repos.MealPlans.ListLapsed(ctx, "2026-03-03"); len(lapsed) != 0 {
+		t.Fatalf("plan lapsed on the day of its slot")
+	}
+	if lapsed, _ := repos.MealPlans.ListLapsed(ctx, "2026-03-04"); len(lapsed) != 1 {
+		t.Fatalf("%d lapsed plans the day after, want 1", len(lapsed))
+	}
+	if err := repos.MealPlans.DeleteByFridge(ctx, "f1"); err != nil {
+		t.Fatalf("delete by fridge: %v", err)
+	}
+	if _, _, err := repos.MealPlans.Get(ctx, "f1", "2026-03-02"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("get after delete: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemoryLeaderboardArchivesNewestFirst(t *testing.T) {
+	ctx := context.Background()
+	repos := NewMemory()
+
+	for _, key := range []string{"2026-W01", "2026-W03", "2026-W02"} {
+		archive := &model.LeaderboardArchive{Period: model.LeaderboardPeriodWeek, Key: key}
+		if err := repos.Leaderboard.CreateArchive(ctx, archive); err != nil {
+			t.Fatalf("archive %s: %v", key, err)
+		}
+	}
+	again := &model.LeaderboardArchive{Period: model.LeaderboardPeriodWeek, Key: "2026-W02"}
+	if err := repos.Leaderboard.CreateArchive(ctx, again); !errors.Is(err, ErrConflict) {
+		t.Fatalf("archive twice: got %v, want ErrConflict", err)
+	}
+
+	archives, err := repos.Leaderboard.ListArchives(ctx, model.LeaderboardPeriodWeek, 2)
+	if err != nil {
+		t.Fatalf("list archives: %v", err)
+	}
+	if len(archives) != 2 || archives[0].Key != "2026-W03" || archives[1].Key != "2026-W02" {
+		t.Fatalf("archives %+v, want 2026-W03 then 2026-W02", archives)
+	}
+	if months, _ := repos.Leaderboard.ListArchives(ctx, model.LeaderboardPeriodMonth, 5); len(months) != 0 {
+		t.Fatalf("%d monthly archives, want 0", len(months))
+	}
+}
+
+func TestMemoryUsersByNickname(t *testing.T) {
+	ctx := context.Background()
+	repos := NewMemory()
+
+	user := &model.User{ID: "u1", Nickname: "greenfork", Origin: model.AccountOriginMicrosoft}
+	if err := repos.Users.Upsert(ctx, user); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	found, err := repos.Users.FindByNickname(ctx, "greenfork")
+	if err != nil || found.ID != "u1" {
+		t.Fatalf("find by nickname: %+v, err %v", found, err)
+	}
+	if _, err := repos.Users.FindByNickname(ctx, "nobody"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("unknown nickname: got %v, want ErrNotFound", err)
+	}
+	if exists, _ := repos.Users.NicknameExists(ctx, "greenfork"); !exists {
+		t.Fatalf("nickname not reported as taken")
+	}
+}
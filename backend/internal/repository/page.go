@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// ErrInvalidCursor is returned when an after cursor was not produced by the
+// listing it is passed back to.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Page is a window of a listing. Every edge carries an opaque cursor that
+// resumes the listing right after its node.
+type Page[T any] struct {
+	Edges       []Edge[T]
+	HasNextPage bool
+}
+
+type Edge[T any] struct {
+	Node   *T
+	Cursor string
+}
+
+// EndCursor returns the cursor of the last edge, or nil on an empty page.
+func (p *Page[T]) EndCursor() *string {
+	if len(p.Edges) == 0 {
+		return nil
+	}
+	return &p.Edges[len(p.Edges)-1].Cursor
+}
+
+// cosmosCursor pins a node by the continuation token of the Cosmos result page
+// it came from and how many items the query returns from that token up to it,
+// itself included. Those may span several pages when the query is re-issued.
+type cosmosCursor struct {
+	Token string `json:"t,omitempty"`
+	Skip  int    `json:"s"`
+}
+
+func encodeCosmosCursor(c cosmosCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCosmosCursor(after string) (cosmosCursor, error) {
+	var c cosmosCursor
+	if after == "" {
+		return c, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(after)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil || c.Skip < 0 {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// memPage slices an already ordered listing; its cursors are plain offsets.
+func memPage[T any](items []*T, first int, after string) (*Page[T], error) {
+	offset := 0
+	if after != "" {
+		data, err := base64.RawURLEncoding.DecodeString(after)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		offset, err = strconv.Atoi(string(data))
+		if err != nil || offset < 0 {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	page := &Page[T]{}
+	for i := offset; i < len(items) && len(page.Edges) < first; i++ {
+		cursor := base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(i + 1)))
+		page.Edges = append(page.Edges, Edge[T]{Node: items[i], Cursor: cursor})
+	}
+	page.HasNextPage = offset+len(page.Edges) < len(items)
+	return page, nil
+}
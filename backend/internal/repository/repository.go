@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mariocosenza/mocc/graph/model"
+)
+
+var (
+	ErrNotFound = errors.New("not found")
+	// ErrConflict means a conditional write lost against a concurrent writer.
+	ErrConflict = errors.New("conflict")
+)
+
+type UserRepository interface {
+	Get(ctx context.Context, id string) (*model.User, error)
+	Upsert(ctx context.Context, user *model.User) error
+	NicknameExists(ctx context.Context, nickname string) (bool, error)
+	// FindByNickname returns the user going by nickname, or ErrNotFound.
+	FindByNickname(ctx context.Context, nickname string) (*model.User, error)
+	ListWithEcoPoints(ctx context.Context) ([]*model.User, error)
+}
+
+type FridgeRepository interface {
+	// ListForUser returns every fridge the user owns or has been invited to.
+	ListForUser(ctx context.Context, userID string) ([]*model.Fridge, error)
+	// Get returns the fridge together with the ETag of the stored document.
+	Get(ctx context.Context, id string) (*model.Fridge, string, error)
+	Upsert(ctx context.Context, fridge *model.Fridge) error
+	// Replace writes the fridge only if the stored document still has etag,
+	// returning ErrConflict otherwise.
+	Replace(ctx context.Context, fridge *model.Fridge, etag string) error
+	// ListWithEmbeddedItems returns fridges still carrying their inventory
+	// inline, as written before items became documents of their own.
+	ListWithEmbeddedItems(ctx context.Context) ([]*model.Fridge, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// InventoryRepository stores each inventory item as its own document in the
+// partition of the fridge it belongs to.
+type InventoryRepository interface {
+	ListByFridge(ctx context.Context, fridgeID string) ([]*model.InventoryItem, error)
+	// Get returns the item together with the ETag of the stored document.
+	Get(ctx context.Context, fridgeID, id string) (*model.InventoryItem, string, error)
+	Upsert(ctx context.Context, fridgeID string, item *model.InventoryItem) error
+	// Replace writes the item only if the stored document still has etag,
+	// returning ErrConflict otherwise.
+	Replace(ctx context.Context, fridgeID string, item *model.InventoryItem, etag string) error
+	// Delete removes the item; a non-empty etag makes the delete conditional.
+	Delete(ctx context.Context, fridgeID, id, etag string) error
+}
+
+type RecipeRepository interface {
+	// Get looks a recipe up by id across every author partition.
+	Get(ctx context.Context, id string) (*model.Recipe, error)
+	ListByAuthor(ctx context.Context, authorID string, status *model.RecipeStatus) ([]*model.Recipe, error)
+	ListByAuthorPage(ctx context.Context, authorID string, status *model.RecipeStatus, first int, after string) (*Page[model.Recipe], error)
+	// GetWithETag reads a recipe from its author's partition together with the
+	// ETag of the stored document.
+	GetWithETag(ctx context.Context, authorID, id string) (*model.Recipe, string, error)
+	// ListByStatus returns every author's recipes in the given status.
+	ListByStatus(ctx context.Context, status model.RecipeStatus) ([]*model.Recipe, error)
+	Upsert(ctx context.Context, recipe *model.Recipe) error
+	// Replace writes the recipe only if the stored document still has etag,
+	// returning ErrConflict otherwise.
+	Replace(ctx context.Context, recipe *model.Recipe, etag string) error
+	Delete(ctx context.Context, authorID, id string) error
+}
+
+type PostRepository interface {
+	Get(ctx context.Context, id string) (*model.Post, error)
+	// List returns posts ordered by creation date, newest first.
+	List(ctx context.Context) ([]*model.Post, error)
+	// ListPage is the paginated form of List.
+	ListPage(ctx context.Context, first int, after string) (*Page[model.Post], error)
+	ListByAuthor(ctx context.Context, authorID string) ([]*model.Post, error)
+	// ListCommentedBy returns posts the user commented on but did not author.
+	ListCommentedBy(ctx context.Context, userID string) ([]*model.Post, error)
+	Upsert(ctx context.Context, post *model.Post) error
+	Delete(ctx context.Context, id string) error
+}
+
+type HistoryRepository interface {
+	Get(ctx context.Context, authorID, id string) (*model.ShoppingHistoryEntry, error)
+	// ListByAuthor returns entries ordered by date, newest first.
+	ListByAuthor(ctx context.Context, authorID string) ([]*model.ShoppingHistoryEntry, error)
+	ListByAuthorPage(ctx context.Context, authorID string, first int, after string) (*Page[model.ShoppingHistoryEntry], error)
+	Upsert(ctx context.Context, entry *model.ShoppingHistoryEntry) error
+	Delete(ctx context.Context, authorID, id string) error
+}
+
+type LeaderboardRecord struct {
+	ID       string `json:"id"`
+	Period   string `json:"period"`
+	Nickname string `json:"nickname"`
+	Score    int    `json:"score"`
+}
+
+type LeaderboardRepository interface {
+	Get(ctx context.Context, period, id string) (*LeaderboardRecord, error)
+	// GetWithETag returns the record together with the ETag of the stored
+	// document.
+	GetWithETag(ctx context.Context, period, id string) (*LeaderboardRecord, string, error)
+	Upsert(ctx context.Context, record *LeaderboardRecord) error
+	// Create writes the user's first record of a period, returning
+	// ErrConflict when there is one already.
+	Create(ctx context.Context, record *LeaderboardRecord) error
+	// Replace writes the record only if the stored document still has etag,
+	// returning ErrConflict otherwise.
+	Replace(ctx context.Context, record *LeaderboardRecord, etag string) error
+	// ListByPeriod returns the period's records ordered by score, highest first.
+	ListByPeriod(ctx context.Context, period string) ([]*LeaderboardRecord, error)
+	// CreateArchive keeps the final standings of a closed period, returning
+	// ErrConflict when the period was archived already.
+	CreateArchive(ctx context.Context, archive *model.LeaderboardArchive) error
+	// ListArchives returns the latest archives of the kind, newest first.
+	ListArchives(ctx context.Context, kind model.LeaderboardPeriod, limit int) ([]*model.LeaderboardArchive, error)
+}
+
+// ShoppingListID is the id of the shopping list of a fridge. It embeds the
+// fridge id so lists stay unique outside their partition too.
+func ShoppingListID(fridgeID string) string {
+	return "shopping-list:" + fridgeID
+}
+
+// ShoppingListRepository keeps the shopping list of each fridge, at most one
+// per fridge.
+type ShoppingListRepository interface {
+	// Get returns the list together with the ETag of the stored document.
+	Get(ctx context.Context, fridgeID string) (*model.ShoppingList, string, error)
+	// Create writes the first list of a fridge, returning ErrConflict when
+	// the fridge already has one.
+	Create(ctx context.Context, list *model.ShoppingList) error
+	// Replace writes the list only if the stored document still has etag,
+	// returning ErrConflict otherwise.
+	Replace(ctx context.Context, list *model.ShoppingList, etag string) error
+	Delete(ctx context.Context, fridgeID string) error
+}
+
+// MealPlanID is the id of the plan of a fridge for the week starting on
+// weekStart, a YYYY-MM-DD Monday.
+func MealPlanID(fridgeID, weekStart string) string {
+	return "meal-plan:" + fridgeID + ":" + weekStart
+}
+
+// MealPlanRepository keeps the weekly meal plans of each fridge.
+type MealPlanRepository interface {
+	// Get returns the plan together with the ETag of the stored document.
+	Get(ctx context.Context, fridgeID, weekStart string) (*model.MealPlan, string, error)
+	// Create writes the first plan of a fridge for a week, returning
+	// ErrConflict when there is one already.
+	Create(ctx context.Context, plan *model.MealPlan) error
+	// Replace writes the plan only if the stored document still has etag,
+	// returning ErrConflict otherwise.
+	Replace(ctx context.Context, plan *model.MealPlan, etag string) error
+	// ListLapsed returns, across fridges, the plans with a slot dated before
+	// day that still holds its ingredients.
+	ListLapsed(ctx context.Context, day string) ([]*model.MealPlan, error)
+	// DeleteByFridge drops every plan of a fridge.
+	DeleteByFridge(ctx context.Context, fridgeID string) error
+}
+
+// InventoryEventRepository is the append-only log of what happened to the
+// items of each fridge. Listings are ordered newest first.
+type InventoryEventRepository interface {
+	Append(ctx context.Context, event *model.InventoryEvent) error
+	ListByFridgePage(ctx context.Context, fridgeID string, first int, after string) (*Page[model.InventoryEvent], error)
+	// ListByItemPage follows one item across the given fridges, as items can
+	// be moved from one to another.
+	ListByItemPage(ctx context.Context, fridgeIDs []string, itemID string, first int, after string) (*Page[model.InventoryEvent], error)
+}
+
+// WasteRepository is the ledger of the food each user threw away. Events are
+// only ever removed when the waste is undone.
+type WasteRepository interface {
+	Add(ctx context.Context, userID string, event *model.WasteEvent) error
+	Remove(ctx context.Context, userID, id string) error
+	// ListByUser returns the events with wastedAt in [from, to), oldest first.
+	ListByUser(ctx context.Context, userID, from, to string) ([]*model.WasteEvent, error)
+}
+
+// PointsLedgerRepository records every eco-point award and deduction of each
+// user. Entries are keyed by their idempotency key and never change.
+type PointsLedgerRepository interface {
+	// Append records the entry, returning ErrConflict when one with the same
+	// id was recorded already.
+	Append(ctx context.Context, userID string, entry *model.PointsEntry) error
+	// ListByUser returns every entry of the user, oldest first.
+	ListByUser(ctx context.Context, userID string) ([]*model.PointsEntry, error)
+	// ListByUserPage returns the user's entries newest first.
+	ListByUserPage(ctx context.Context, userID string, first int, after string) (*Page[model.PointsEntry], error)
+	// ListUserIDs returns every user with at least one entry.
+	ListUserIDs(ctx context.Context) ([]string, error)
+}
+
+// Repositories bundles every store the logic layer depends on.
+type Repositories struct {
+	Users       UserRepository
+	Fridges     FridgeRepository
+	Inventory   InventoryRepository
+	Recipes     RecipeRepository
+	Posts       PostRepository
+	History     HistoryRepository
+	Leaderboard LeaderboardRepository
+	Waste       WasteRepository
+	Events      InventoryEventRepository
+	Shopping    ShoppingListRepository
+	MealPlans   MealPlanRepository
+	Points      PointsLedgerRepository
+}
@@ -0,0 +1,58 @@
+package units
+
+// defaults holds typical piece weights and densities for common products, in
+// Italian and English, so the usual conversions work without user input.
+var defaults = map[string]Product{
+	"acqua":   {GramsPerML: 1},
+	"water":   {GramsPerML: 1},
+	"latte":   {GramsPerML: 1.03},
+	"milk":    {GramsPerML: 1.03},
+	"panna":   {GramsPerML: 1.01},
+	"cream":   {GramsPerML: 1.01},
+	"olio":    {GramsPerML: 0.92},
+	"oil":     {GramsPerML: 0.92},
+	"aceto":   {GramsPerML: 1.01},
+	"vinegar": {GramsPerML: 1.01},
+	"vino":    {GramsPerML: 0.99},
+	"wine":    {GramsPerML: 0.99},
+	"miele":   {GramsPerML: 1.42},
+	"honey":   {GramsPerML: 1.42},
+	"yogurt":  {GramsPerML: 1.03},
+
+	"uovo":             {PieceGrams: 60},
+	"uova":             {PieceGrams: 60},
+	"egg":              {PieceGrams: 60},
+	"eggs":             {PieceGrams: 60},
+	"mela":             {PieceGrams: 180},
+	"mele":             {PieceGrams: 180},
+	"apple":            {PieceGrams: 180},
+	"apples":           {PieceGrams: 180},
+	"banana":           {PieceGrams: 120},
+	"banane":           {PieceGrams: 120},
+	"bananas":          {PieceGrams: 120},
+	"limone":           {PieceGrams: 100},
+	"limoni":           {PieceGrams: 100},
+	"lemon":            {PieceGrams: 100},
+	"lemons":           {PieceGrams: 100},
+	"cipolla":          {PieceGrams: 150},
+	"cipolle":          {PieceGrams: 150},
+	"onion":            {PieceGrams: 150},
+	"onions":           {PieceGrams: 150},
+	"patata":           {PieceGrams: 200},
+	"patate":           {PieceGrams: 200},
+	"potato":           {PieceGrams: 200},
+	"potatoes":         {PieceGrams: 200},
+	"pomodoro":         {PieceGrams: 120},
+	"pomodori":         {PieceGrams: 120},
+	"tomato":           {PieceGrams: 120},
+	"tomatoes":         {PieceGrams: 120},
+	"carota":           {PieceGrams: 80},
+	"carote":           {PieceGrams: 80},
+	"carrot":           {PieceGrams: 80},
+	"carrots":          {PieceGrams: 80},
+	"zucchina":         {PieceGrams: 200},
+	"zucchine":         {PieceGrams: 200},
+	"zucchini":         {PieceGrams: 200},
+	"spicchio d'aglio": {PieceGrams: 5},
+	"garlic clove":     {PieceGrams: 5},
+}
@@ -0,0 +1,156 @@
+// Package units converts recipe and inventory quantities between the units
+// the app knows about, so amounts can be compared and subtracted safely.
+package units
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mariocosenza/mocc/graph/model"
+)
+
+// ErrIncompatible is wrapped by every error returned for a conversion that
+// cannot be done with what is known about the product.
+var ErrIncompatible = errors.New("incompatible units")
+
+// Dimension groups units that convert into each other with a fixed factor.
+type Dimension int
+
+const (
+	// Unmeasured is QB, "quanto basta": an amount nobody weighs.
+	Unmeasured Dimension = iota
+	Mass
+	Volume
+	Count
+)
+
+// Product carries what is known about a product beyond its unit. Zero values
+// mean unknown.
+type Product struct {
+	// PieceGrams is the weight of one piece, used for PZ <-> mass.
+	PieceGrams float64
+	// GramsPerML is the density, used for mass <-> volume.
+	GramsPerML float64
+}
+
+// DimensionOf reports the dimension of u. Unknown units are Unmeasured.
+func DimensionOf(u model.Unit) Dimension {
+	switch u {
+	case model.UnitG, model.UnitKg:
+		return Mass
+	case model.UnitMl, model.UnitL:
+		return Volume
+	case model.UnitPz:
+		return Count
+	default:
+		return Unmeasured
+	}
+}
+
+// IsNonDepleting reports whether an amount in u never uses up stock.
+func IsNonDepleting(u model.Unit) bool {
+	return DimensionOf(u) == Unmeasured
+}
+
+// base returns the factor that takes a value in u to grams, millilitres or
+// pieces.
+func base(u model.Unit) float64 {
+	switch u {
+	case model.UnitKg, model.UnitL:
+		return 1000
+	default:
+		return 1
+	}
+}
+
+// Convert expresses value, given in from, in the unit to.
+func Convert(value float64, from, to model.Unit, p Product) (float64, error) {
+	if from == to {
+		return value, nil
+	}
+
+	df, dt := DimensionOf(from), DimensionOf(to)
+	if df == Unmeasured || dt == Unmeasured {
+		return 0, incompatible(from, to, "QB has no amount")
+	}
+
+	v := value * base(from)
+	if df != dt {
+		grams, err := toGrams(v, df, p, from, to)
+		if err != nil {
+			return 0, err
+		}
+		v, err = fromGrams(grams, dt, p, from, to)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return v / base(to), nil
+}
+
+// Required returns how much of a product stored in stock has to be taken for
+// value in need. It is zero when either side is QB, since those amounts are
+// never counted.
+func Required(value float64, need, stock model.Unit, p Product) (float64, error) {
+	if IsNonDepleting(need) || IsNonDepleting(stock) {
+		return 0, nil
+	}
+	return Convert(value, need, stock, p)
+}
+
+func toGrams(v float64, d Dimension, p Product, from, to model.Unit) (float64, error) {
+	switch d {
+	case Mass:
+		return v, nil
+	case Volume:
+		if p.GramsPerML <= 0 {
+			return 0, incompatible(from, to, "density unknown")
+		}
+		return v * p.GramsPerML, nil
+	default:
+		if p.PieceGrams <= 0 {
+			return 0, incompatible(from, to, "piece weight unknown")
+		}
+		return v * p.PieceGrams, nil
+	}
+}
+
+func fromGrams(g float64, d Dimension, p Product, from, to model.Unit) (float64, error) {
+	switch d {
+	case Mass:
+		return g, nil
+	case Volume:
+		if p.GramsPerML <= 0 {
+			return 0, incompatible(from, to, "density unknown")
+		}
+		return g / p.GramsPerML, nil
+	default:
+		if p.PieceGrams <= 0 {
+			return 0, incompatible(from, to, "piece weight unknown")
+		}
+		return g / p.PieceGrams, nil
+	}
+}
+
+func incompatible(from, to model.Unit, why string) error {
+	return fmt.Errorf("%w: cannot convert %s to %s, %s", ErrIncompatible, from, to, why)
+}
+
+// ProductOf collects the conversion hints of an inventory item, falling back
+// to the built-in table for the ones the user did not set.
+func ProductOf(item *model.InventoryItem) Product {
+	p := Lookup(item.Name)
+	if item.PieceWeightGrams != nil && *item.PieceWeightGrams > 0 {
+		p.PieceGrams = *item.PieceWeightGrams
+	}
+	if item.DensityGramsPerMl != nil && *item.DensityGramsPerMl > 0 {
+		p.GramsPerML = *item.DensityGramsPerMl
+	}
+	return p
+}
+
+// Lookup returns the built-in hints for a product name, if any.
+func Lookup(name string) Product {
+	return defaults[strings.ToLower(strings.TrimSpace(name))]
+}
@@ -0,0 +1,130 @@
+package units
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/mariocosenza/mocc/graph/model"
+)
+
+func TestConvert(t *testing.T) {
+	egg := Product{PieceGrams: 60}
+	milk := Product{GramsPerML: 1.03}
+
+	cases := []struct {
+		name     string
+		value    float64
+		from, to model.Unit
+		product  Product
+		want     float64
+	}{
+		{name: "same unit", value: 3, from: model.UnitPz, to: model.UnitPz, want: 3},
+		{name: "g to kg", value: 250, from: model.UnitG, to: model.UnitKg, want: 0.25},
+		{name: "kg to g", value: 1.5, from: model.UnitKg, to: model.UnitG, want: 1500},
+		{name: "ml to l", value: 330, from: model.UnitMl, to: model.UnitL, want: 0.33},
+		{name: "l to ml", value: 2, from: model.UnitL, to: model.UnitMl, want: 2000},
+		{name: "pieces to g", value: 2, from: model.UnitPz, to: model.UnitG, product: egg, want: 120},
+		{name: "kg to pieces", value: 0.3, from: model.UnitKg, to: model.UnitPz, product: egg, want: 5},
+		{name: "ml to g", value: 100, from: model.UnitMl, to: model.UnitG, product: milk, want: 103},
+		{name: "kg to l", value: 1.03, from: model.UnitKg, to: model.UnitL, product: milk, want: 1},
+		{name: "pieces to ml", value: 1, from: model.UnitPz, to: model.UnitMl, product: Product{PieceGrams: 206, GramsPerML: 1.03}, want: 200},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Convert(tc.value, tc.from, tc.to, tc.product)
+			if err != nil {
+				t.Fatalf("convert: %v", err)
+			}
+			if math.Abs(got-tc.want) > 1e-9 {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestConvertIncompatible(t *testing.T) {
+	cases := []struct {
+		name     string
+		from, to model.Unit
+		product  Product
+	}{
+		{name: "volume to mass without density", from: model.UnitMl, to: model.UnitG, product: Product{PieceGrams: 60}},
+		{name: "mass to volume without density", from: model.UnitKg, to: model.UnitL},
+		{name: "pieces to mass without piece weight", from: model.UnitPz, to: model.UnitG, product: Product{GramsPerML: 1}},
+		{name: "mass to pieces without piece weight", from: model.UnitG, to: model.UnitPz},
+		{name: "qb to mass", from: model.UnitQb, to: model.UnitG},
+		{name: "volume to qb", from: model.UnitL, to: model.UnitQb},
+		{name: "unknown to mass", from: model.Unit("CUP"), to: model.UnitG},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Convert(1, tc.from, tc.to, tc.product); !errors.Is(err, ErrIncompatible) {
+				t.Fatalf("got %v, want ErrIncompatible", err)
+			}
+		})
+	}
+}
+
+func TestRequired(t *testing.T) {
+	cases := []struct {
+		name        string
+		value       float64
+		need, stock model.Unit
+		product     Product
+		want        float64
+	}{
+		{name: "qb needed", value: 5, need: model.UnitQb, stock: model.UnitG, want: 0},
+		{name: "qb in stock", value: 200, need: model.UnitG, stock: model.UnitQb, want: 0},
+		{name: "unknown unit needed", value: 2, need: model.Unit("CUP"), stock: model.UnitMl, want: 0},
+		{name: "grams from kilos", value: 200, need: model.UnitG, stock: model.UnitKg, want: 0.2},
+		{name: "pieces from grams", value: 3, need: model.UnitPz, stock: model.UnitG, product: Product{PieceGrams: 60}, want: 180},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Required(tc.value, tc.need, tc.stock, tc.product)
+			if err != nil {
+				t.Fatalf("required: %v", err)
+			}
+			if math.Abs(got-tc.want) > 1e-9 {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	if _, err := Required(100, model.UnitMl, model.UnitPz, Product{}); !errors.Is(err, ErrIncompatible) {
+		t.Fatalf("ml from pieces without hints: got %v, want ErrIncompatible", err)
+	}
+}
+
+func TestDimensionOf(t *testing.T) {
+	cases := map[model.Unit]Dimension{
+		model.UnitG:       Mass,
+		model.UnitKg:      Mass,
+		model.UnitMl:      Volume,
+		model.UnitL:       Volume,
+		model.UnitPz:      Count,
+		model.UnitQb:      Unmeasured,
+		model.Unit("CUP"): Unmeasured,
+		model.Unit(""):    Unmeasured,
+	}
+	for unit, want := range cases {
+		if got := DimensionOf(unit); got != want {
+			t.Errorf("DimensionOf(%q) = %v, want %v", unit, got, want)
+		}
+		if IsNonDepleting(unit) != (want == Unmeasured) {
+			t.Errorf("IsNonDepleting(%q) = %v", unit, IsNonDepleting(unit))
+		}
+	}
+}
+
+func TestProductOfPrefersItemHints(t *testing.T) {
+	weight := 70.0
+	item := &model.InventoryItem{Name: " Uova ", PieceWeightGrams: &weight}
+	if got := ProductOf(item); got.PieceGrams != 70 {
+		t.Fatalf("piece weight %v, want the item's 70", got.PieceGrams)
+	}
+	if got := ProductOf(&model.InventoryItem{Name: "Latte"}); got.GramsPerML != 1.03 {
+		t.Fatalf("density %v, want the built-in 1.03", got.GramsPerML)
+	}
+}
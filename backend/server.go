@@ -9,6 +9,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/handler/extension"
 	"github.com/99designs/gqlgen/graphql/handler/lru"
@@ -16,10 +17,12 @@ import (
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/gorilla/websocket"
 	"github.com/mariocosenza/mocc/auth"
 	"github.com/mariocosenza/mocc/cosmos"
 	"github.com/mariocosenza/mocc/graph"
 	"github.com/mariocosenza/mocc/internal/logic"
+	"github.com/mariocosenza/mocc/internal/repository"
 	redisx "github.com/mariocosenza/mocc/redis"
 	msgraphsdk "github.com/microsoftgraph/msgraph-sdk-go"
 	"github.com/redis/go-redis/v9"
@@ -51,7 +54,45 @@ func normalizeTenantOrAuthority(s string) string {
 	return s
 }
 
+// authenticator puts the caller's user id in the request context, from an
+// Entra token in production or as given in memory mode.
+type authenticator interface {
+	Middleware(next http.Handler) http.Handler
+	ValidateToken(ctx context.Context, raw string) (string, error)
+}
+
+// websocketInit authenticates subscription connections with the token sent
+// in the connection_init payload, since browsers cannot attach an
+// Authorization header to the websocket upgrade.
+func websocketInit(validator authenticator) transport.WebsocketInitFunc {
+	return func(ctx context.Context, initPayload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+		token := strings.TrimSpace(initPayload.Authorization())
+		if len(token) > 7 && strings.EqualFold(token[:7], "Bearer ") {
+			token = strings.TrimSpace(token[7:])
+		}
+
+		oid, err := validator.ValidateToken(ctx, token)
+		if err != nil {
+			log.Printf("websocket init rejected: %v", err)
+			return ctx, nil, err
+		}
+		return auth.WithUserID(ctx, oid), &initPayload, nil
+	}
+}
+
+// rebuildPointsCommand, given as the first argument, recomputes every user's
+// eco-points and level from the points ledger, then exits instead of serving.
+const rebuildPointsCommand = "rebuild-points"
+
 func main() {
+	command := ""
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+		if command != rebuildPointsCommand {
+			log.Fatalf("unknown command %q, the only one is %s", command, rebuildPointsCommand)
+		}
+	}
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = defaultPort
@@ -100,13 +141,15 @@ func main() {
 		ReadHeaderTimeout: 10 * time.Second,
 	}
 
-	go func() {
-		log.Printf("listening on :%s", port)
-		err := srvHTTP.ListenAndServe()
-		if err != nil && err != http.ErrServerClosed {
-			log.Fatalf("http server error: %v", err)
-		}
-	}()
+	if command == "" {
+		go func() {
+			log.Printf("listening on :%s", port)
+			err := srvHTTP.ListenAndServe()
+			if err != nil && err != http.ErrServerClosed {
+				log.Fatalf("http server error: %v", err)
+			}
+		}()
+	}
 
 	ctx := context.Background()
 	logger := log.New(os.Stdout, "", log.LstdFlags|log.LUTC)
@@ -134,54 +177,123 @@ func main() {
 	}
 	defer func() { _ = redisClose() }()
 
-	cosmosClient, err := cosmos.NewClientCosmos(ctx)
-	if err != nil {
-		log.Fatalf("failed to init cosmos: %v", err)
-	}
-
-	cred, err := azidentity.NewDefaultAzureCredential(nil)
-	if err != nil {
-		log.Fatalf("failed to create credential: %v", err)
-	}
+	memoryBackend := strings.EqualFold(os.Getenv("STORAGE_BACKEND"), "memory")
 
-	graphClient, err := msgraphsdk.NewGraphServiceClientWithCredentials(
-		cred,
-		[]string{"https://graph.microsoft.com/.default"},
-	)
-	if err != nil {
-		log.Fatalf("failed to create graph client: %v", err)
+	var repos *repository.Repositories
+	if memoryBackend {
+		log.Printf("using in-memory storage, data is lost on restart")
+		repos = repository.NewMemory()
+	} else {
+		cosmosClient, err := cosmos.NewClientCosmos(ctx)
+		if err != nil {
+			log.Fatalf("failed to init cosmos: %v", err)
+		}
+		repos = repository.NewCosmos(cosmosClient)
 	}
 
-	tenantOrAuthority := normalizeTenantOrAuthority(os.Getenv("AUTH_AUTHORITY"))
-	validator, err := auth.NewEntraValidator(auth.EntraConfig{
-		TenantID:         tenantOrAuthority,
-		ExpectedAudience: os.Getenv("EXPECTED_AUDIENCE"),
-		RequiredScope:    os.Getenv("REQUIRED_SCOPE"),
-	})
-	if err != nil {
-		log.Fatalf("auth init error: %v", err)
+	var validator authenticator
+	if memoryBackend {
+		devUser := os.Getenv("DEV_USER_ID")
+		log.Printf("dev auth: requests act as the user in the %s header, or %q without one", auth.DevUserHeader, devUser)
+		validator = auth.NewDevAuth(devUser)
+	} else {
+		tenantOrAuthority := normalizeTenantOrAuthority(os.Getenv("AUTH_AUTHORITY"))
+		validator, err = auth.NewEntraValidator(auth.EntraConfig{
+			TenantID:         tenantOrAuthority,
+			ExpectedAudience: os.Getenv("EXPECTED_AUDIENCE"),
+			RequiredScope:    os.Getenv("REQUIRED_SCOPE"),
+		})
+		if err != nil {
+			log.Fatalf("auth init error: %v", err)
+		}
 	}
 
+	var graphClient *msgraphsdk.GraphServiceClient
 	var blobClient *azblob.Client
 	storageConnStr := os.Getenv("AZURE_STORAGE_CONNECTION_STRING")
-	if storageConnStr != "" {
-		blobClient, err = azblob.NewClientFromConnectionString(storageConnStr, nil)
-		if err != nil {
-			log.Fatalf("failed to create blob client from connection string: %v", err)
+	if memoryBackend {
+		// No Azure credential here: uploads work only against a storage
+		// emulator given by connection string, and are refused otherwise.
+		if storageConnStr != "" {
+			blobClient, err = azblob.NewClientFromConnectionString(storageConnStr, nil)
+			if err != nil {
+				log.Fatalf("failed to create blob client from connection string: %v", err)
+			}
+		} else {
+			log.Printf("no AZURE_STORAGE_CONNECTION_STRING, image uploads are disabled")
 		}
 	} else {
-		accountName := os.Getenv("AZURE_STORAGE_ACCOUNT_NAME")
-		if accountName == "" {
-			log.Fatalf("AZURE_STORAGE_ACCOUNT_NAME required in production")
+		cred, err := azidentity.NewDefaultAzureCredential(nil)
+		if err != nil {
+			log.Fatalf("failed to create credential: %v", err)
+		}
+
+		graphClient, err = msgraphsdk.NewGraphServiceClientWithCredentials(
+			cred,
+			[]string{"https://graph.microsoft.com/.default"},
+		)
+		if err != nil {
+			log.Fatalf("failed to create graph client: %v", err)
+		}
+
+		if storageConnStr != "" {
+			blobClient, err = azblob.NewClientFromConnectionString(storageConnStr, nil)
+			if err != nil {
+				log.Fatalf("failed to create blob client from connection string: %v", err)
+			}
+		} else {
+			accountName := os.Getenv("AZURE_STORAGE_ACCOUNT_NAME")
+			if accountName == "" {
+				log.Fatalf("AZURE_STORAGE_ACCOUNT_NAME required in production")
+			}
+			serviceURL := "https://" + accountName + ".blob.core.windows.net/"
+			blobClient, err = azblob.NewClient(serviceURL, cred, nil)
+			if err != nil {
+				log.Fatalf("failed to create blob client: %v", err)
+			}
 		}
-		serviceURL := "https://" + accountName + ".blob.core.windows.net/"
-		blobClient, err = azblob.NewClient(serviceURL, cred, nil)
+	}
+
+	logicLayer := logic.NewLogic(redisClient, repos, graphClient, blobClient, logger)
+
+	if command == rebuildPointsCommand {
+		corrected, err := logicLayer.RebuildEcoPoints(ctx)
 		if err != nil {
-			log.Fatalf("failed to create blob client: %v", err)
+			log.Fatalf("eco-points rebuild failed after correcting %d users: %v", corrected, err)
+		}
+		log.Printf("eco-points rebuilt, %d users corrected", corrected)
+		return
+	}
+
+	if raw := os.Getenv("RECIPE_LOCK_TTL"); raw != "" {
+		ttl, err := time.ParseDuration(raw)
+		if err != nil || ttl <= 0 {
+			log.Fatalf("invalid RECIPE_LOCK_TTL %q, expected a duration such as 90m", raw)
+		}
+		logicLayer.LockTTL = ttl
+	}
+	if raw := os.Getenv("INVENTORY_UNDO_WINDOW"); raw != "" {
+		window, err := time.ParseDuration(raw)
+		if err != nil || window <= 0 {
+			log.Fatalf("invalid INVENTORY_UNDO_WINDOW %q, expected a duration such as 5m", raw)
 		}
+		logicLayer.UndoWindow = window
 	}
+	if raw := os.Getenv("FRIDGE_INVITE_TTL"); raw != "" {
+		ttl, err := time.ParseDuration(raw)
+		if err != nil || ttl <= 0 || ttl > logic.MaxInviteTTL {
+			log.Fatalf("invalid FRIDGE_INVITE_TTL %q, expected a duration such as 24h, at most a week", raw)
+		}
+		logicLayer.InviteTTL = ttl
+	}
+	go logicLayer.RunLockReaper(ctx, logic.LockReaperInterval)
+	go logicLayer.RunLeaderboardRollover(ctx, logic.LeaderboardRolloverInterval)
 
-	logicLayer := logic.NewLogic(redisClient, cosmosClient, graphClient, blobClient, logger)
+	go func() {
+		if err := logicLayer.MigrateEmbeddedInventory(ctx); err != nil {
+			log.Printf("inventory migration failed, fridges will migrate on first access: %v", err)
+		}
+	}()
 
 	srv := handler.New(graph.NewExecutableSchema(graph.Config{
 		Resolvers: &graph.Resolver{
@@ -192,9 +304,26 @@ func main() {
 	srv.AddTransport(transport.Options{})
 	srv.AddTransport(transport.GET{})
 	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+		Upgrader: websocket.Upgrader{
+			// Same policy as the CORS handler: any origin, the token is what counts.
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		InitFunc: websocketInit(validator),
+	})
 
 	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
 
+	srv.AroundOperations(func(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+		// Mutations change the items their resolvers read back and
+		// subscriptions live too long, so only queries share fridge reads.
+		if op := graphql.GetOperationContext(ctx).Operation; op == nil || op.Operation != ast.Query {
+			return next(ctx)
+		}
+		return next(logic.WithRequestCache(ctx))
+	})
+
 	srv.Use(extension.AutomaticPersistedQuery{
 		Cache: lru.New[string](100),
 	})
@@ -1,13 +1,14 @@
 import time
 import logging
 from shared.clients import get_cosmos_client
+from shared.inventory import get_fridge_items
 from services.notifications_service import send_template_notification
 
 def check_user_expiry(user_id: str, fridge_container):
     try:
         today_str = time.strftime("%Y-%m-%d")
         fridges = list(fridge_container.query_items(
-            query="SELECT * FROM c WHERE c.id = @userId",
+            query="SELECT * FROM c WHERE c.id = @userId AND (NOT IS_DEFINED(c.type) OR c.type = 'fridge')",
             parameters=[{"name": "@userId", "value": user_id}],
             enable_cross_partition_query=True,
         ))
@@ -15,7 +16,7 @@ def check_user_expiry(user_id: str, fridge_container):
         expired_items = []
         
         for fridge in fridges:
-            items = fridge.get("items") or []
+            items = get_fridge_items(fridge_container, fridge)
             for item in items:
                 exp_date = item.get("expiryDate", "")
                 if exp_date and exp_date.startswith(today_str):
@@ -7,6 +7,7 @@ from azure.storage.blob import BlobServiceClient
 from shared.clients import get_cosmos_client, get_azure_openai_client, get_blob_service_client
 from services.notifications_service import send_template_notification, send_signalr_refresh
 from shared.config_utils import get_credential, DEV_STORAGE_CONN_STR
+from shared.inventory import get_fridge_items
 
 def run_daily_recipe_logic():
     cosmos_client = get_cosmos_client()
@@ -44,7 +45,7 @@ def run_daily_recipe_logic():
 def _process_user_recipe(user_id: str, fridge_container, users_container, cookbook_container, openai_client, deployment):
     try:
         fridges = list(fridge_container.query_items(
-            query="SELECT * FROM c WHERE c.id = @userId",
+            query="SELECT * FROM c WHERE c.id = @userId AND (NOT IS_DEFINED(c.type) OR c.type = 'fridge')",
             parameters=[{"name": "@userId", "value": user_id}],
             enable_cross_partition_query=True,
         ))
@@ -53,10 +54,14 @@ def _process_user_recipe(user_id: str, fridge_container, users_container, cookbo
         return
 
     for fridge in fridges:
-        _handle_fridge_recipe_generation(user_id, fridge, users_container, cookbook_container, openai_client, deployment)
+        _handle_fridge_recipe_generation(user_id, fridge, fridge_container, users_container, cookbook_container, openai_client, deployment)
 
-def _handle_fridge_recipe_generation(user_id, fridge, users_container, cookbook_container, openai_client, deployment):
-    items = fridge.get("items") or []
+def _handle_fridge_recipe_generation(user_id, fridge, fridge_container, users_container, cookbook_container, openai_client, deployment):
+    try:
+        items = get_fridge_items(fridge_container, fridge)
+    except Exception:
+        logging.exception("Failed to query items for fridge_id=%s", fridge.get("id"))
+        return
     if not items:
         return
 
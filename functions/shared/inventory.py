@@ -0,0 +1,13 @@
+from typing import Dict, List
+
+
+def get_fridge_items(fridge_container, fridge: Dict) -> List[Dict]:
+    # Items are stored as their own documents in the fridge partition; fridges
+    # the backend has not migrated yet still carry them inline.
+    embedded = fridge.get("items") or []
+    if embedded:
+        return embedded
+    return list(fridge_container.query_items(
+        query="SELECT * FROM c WHERE c.type = 'item'",
+        partition_key=fridge["id"],
+    ))